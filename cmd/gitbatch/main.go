@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,27 +16,84 @@ func main() {
 	mode := kingpin.Flag("mode", "Application start mode, more sensible with quick run.").Short('m').String()
 	recursionDepth := kingpin.Flag("recursive-depth", "Find directories recursively.").Default("0").Short('r').Int()
 	logLevel := kingpin.Flag("log-level", "Logging level; trace,debug,info,warn,error").Default("error").Short('l').String()
-	quick := kingpin.Flag("quick", "runs without gui and fetches/pull remote upstream.").Short('q').Bool()
+	quick := kingpin.Flag("quick", "runs without gui and fetch/pull/merge/checkout/push/status the repositories.").Short('q').Bool()
+	var timeoutSet, workersSet bool
+	timeout := kingpin.Flag("timeout", "Maximum duration of an operation on a single repository (e.g. 90s, 5m), zero means no limit.").Short('t').Action(flagSet(&timeoutSet)).Duration()
+	workers := kingpin.Flag("workers", "Number of repositories processed at the same time, defaults to number of CPUs.").Short('w').Action(flagSet(&workersSet)).Int()
+	hostWorkers := kingpin.Flag("host-workers", "Number of repositories processed at the same time against the same remote host.").Int()
+	branch := kingpin.Flag("branch", "Target branch of checkout in quick mode, defaults to the default branch of each repository.").Short('b').String()
+	strategy := kingpin.Flag("strategy", "Strategy of pull and merge; ff-only,rebase,rebase-autostash,merge").Short('s').Enum("ff-only", "rebase", "rebase-autostash", "merge")
+	allRemotes := kingpin.Flag("all-remotes", "Fetch all of the remotes of the repositories instead of the selected one.").Bool()
+	tags := kingpin.Flag("tags", "Fetch all of the tags of the remotes.").Bool()
+	prune := kingpin.Flag("prune", "Remove the remote-tracking branches that no longer exist on the remote while fetching.").Bool()
+	staleDays := kingpin.Flag("stale-days", "Days without a commit that makes a branch inactive in the branch cleanup.").Int()
+	createBranch := kingpin.Flag("create-branch", "Create the target branch of checkout if it does not exist.").Bool()
+	include := kingpin.Flag("include", "Only load the repositories matching the gitignore style pattern, can be repeated.").Strings()
+	exclude := kingpin.Flag("exclude", "Skip the directories matching the gitignore style pattern, can be repeated.").Strings()
+	groups := kingpin.Flag("group", "Only load the repositories in the group, can be repeated.").Short('g').Strings()
+	nested := kingpin.Flag("nested", "Also discover submodules and repositories nested in other repositories.").Bool()
+	output := kingpin.Flag("output", "Output format of quick mode; text,json,ndjson").Short('o').Enum("text", "json", "ndjson")
 
-	kingpin.Parse()
+	manifest := kingpin.Flag("manifest", "Workspace manifest file, searched in the directories and the config directory if not given. A repo tool manifest (.xml) loads exactly the projects it declares.").String()
+	gitmodules := kingpin.Flag("gitmodules", "Load exactly the submodules declared in the .gitmodules file of a superproject.").String()
 
-	if err := run(*dirs, *logLevel, *recursionDepth, *quick, *mode); err != nil {
+	kingpin.Command("run", "Run the gui, or the quick mode with --quick.").Default()
+	syncCommand := kingpin.Command("sync", "Clone the repositories of the workspace manifest that are missing and add their missing remotes.")
+
+	command := kingpin.Parse()
+
+	cfg := &app.Config{
+		Directories:  *dirs,
+		LogLevel:     *logLevel,
+		Depth:        *recursionDepth,
+		QuickMode:    *quick,
+		Mode:         *mode,
+		Timeout:      *timeout,
+		TimeoutSet:   timeoutSet,
+		Workers:      *workers,
+		WorkersSet:   workersSet,
+		HostWorkers:  *hostWorkers,
+		Output:       *output,
+		Branch:       *branch,
+		CreateBranch: *createBranch,
+		Strategy:     *strategy,
+		AllRemotes:   *allRemotes,
+		Tags:         *tags,
+		Prune:        *prune,
+		StaleDays:    *staleDays,
+		Include:      *include,
+		Exclude:      *exclude,
+		Nested:       *nested,
+		Group:        *groups,
+		Manifest:     *manifest,
+		Gitmodules:   *gitmodules,
+	}
+	if err := run(cfg, command == syncCommand.FullCommand()); err != nil {
+		var exitErr *app.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		fmt.Fprintf(os.Stderr, "application quitted with an unhandled error: %v", err)
 		os.Exit(1)
 	}
 }
 
-func run(dirs []string, log string, depth int, quick bool, mode string) error {
-	app, err := app.New(&app.Config{
-		Directories: dirs,
-		LogLevel:    log,
-		Depth:       depth,
-		QuickMode:   quick,
-		Mode:        mode,
-	})
+// flagSet marks the flag as given on the command line, so that its zero value
+// overrides the configuration file as well
+func flagSet(set *bool) kingpin.Action {
+	return func(*kingpin.ParseContext) error {
+		*set = true
+		return nil
+	}
+}
+
+func run(cfg *app.Config, sync bool) error {
+	app, err := app.New(cfg)
 	if err != nil {
 		return err
 	}
-
+	if sync {
+		return app.Sync()
+	}
 	return app.Run()
 }
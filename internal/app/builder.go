@@ -3,8 +3,12 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/isacikgoz/gitbatch/internal/gui"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
 )
 
 // The App struct is responsible to hold app-wide related entities. Currently
@@ -20,6 +24,56 @@ type Config struct {
 	Depth       int
 	QuickMode   bool
 	Mode        string
+	Timeout     time.Duration
+	Workers     int
+	HostWorkers int
+	Output      string
+	// TimeoutSet and WorkersSet tell that the values are given on the command
+	// line, a zero timeout or worker count overrides the configuration then
+	TimeoutSet bool
+	WorkersSet bool
+	// Branch is the target of the checkout in quick mode
+	Branch string
+	// CreateBranch creates the target branch if it does not exist
+	CreateBranch bool
+	// Include and Exclude are the gitignore style patterns to filter the
+	// discovered repositories
+	Include []string
+	Exclude []string
+	// Nested discovers submodules and repositories nested in other ones
+	Nested bool
+	// Manifest is the path of the workspace manifest, it is searched in the
+	// directories and the configuration directory if empty
+	Manifest string
+	// Gitmodules is the .gitmodules file of a superproject to import the
+	// submodules from
+	Gitmodules string
+
+	// Strategy is the way pull and merge incorporate the upstream changes
+	Strategy string
+	// AllRemotes, Tags and Prune are the fetch options, every remote is
+	// fetched with all of its tags and the deleted branches are pruned
+	AllRemotes bool
+	Tags       bool
+	Prune      bool
+	// StaleDays is the age of the last commit of an inactive branch in the
+	// branch cleanup
+	StaleDays int
+	// Groups are the gitignore style path patterns of the repository groups
+	// keyed by group name
+	Groups map[string][]string
+	// Group restricts the repositories to the ones in any of these groups
+	Group []string
+	// Credentials are the sources of the tokens of the http remotes, matched
+	// by host
+	Credentials command.CredentialSources
+
+	// roots are the directories that the group patterns are relative to, the
+	// directories are used if empty
+	roots []string
+	// revisions are the declared revisions of the imported repositories keyed
+	// by path, nil if the directories are not imported
+	revisions map[string]string
 }
 
 // New will handle pre-required operations. It is designed to be a wrapper for
@@ -36,18 +90,46 @@ func New(argConfig *Config) (*App, error) {
 		return nil, err
 	}
 	app.Config = overrideConfig(presetConfig, argConfig)
-
+	if err := app.importDirectories(); err != nil {
+		return nil, err
+	}
 	return app, nil
 }
 
 // Run starts the application.
 func (a *App) Run() error {
-	dirs := generateDirectories(a.Config.Directories, a.Config.Depth)
+	filter := newDirectoryFilter(a.Config.Directories, a.Config.Include, a.Config.Exclude)
+	filter.nested = a.Config.Nested
+	var (
+		dirs []string
+		err  error
+	)
+	if a.Config.revisions != nil {
+		dirs = filterRepositories(a.Config.Directories, filter)
+	} else {
+		dirs = generateDirectories(a.Config.Directories, a.Config.Depth, filter)
+	}
+	strategy, err := command.ParseStrategy(a.Config.Strategy)
+	if err != nil {
+		return err
+	}
+	groups := a.repositoryGroups()
+	if dirs, err = filterGroups(dirs, groups, a.Config.Group); err != nil {
+		return err
+	}
 	if a.Config.QuickMode {
 		return a.execQuickMode(dirs)
 	}
 	// create a gui.Gui struct and run the gui
-	gui, err := gui.New(a.Config.Mode, dirs)
+	gui, err := gui.New(a.Config.Mode, dirs, gui.QueueSettings{
+		Timeout:     a.Config.Timeout,
+		Workers:     a.Config.Workers,
+		HostWorkers: a.Config.HostWorkers,
+		Strategy:    strategy,
+		Fetch:       a.Config.fetchOptions(),
+		StaleDays:   a.Config.StaleDays,
+		Credentials: a.Config.Credentials,
+	}, a.manifestFile(), groups)
 	if err != nil {
 		return err
 	}
@@ -70,13 +152,110 @@ func overrideConfig(appConfig, setupConfig *Config) *Config {
 	if len(setupConfig.Mode) > 0 {
 		appConfig.Mode = setupConfig.Mode
 	}
+	if setupConfig.Timeout > 0 || setupConfig.TimeoutSet {
+		appConfig.Timeout = setupConfig.Timeout
+	}
+	if setupConfig.Workers > 0 || setupConfig.WorkersSet {
+		appConfig.Workers = setupConfig.Workers
+	}
+	if setupConfig.HostWorkers > 0 {
+		appConfig.HostWorkers = setupConfig.HostWorkers
+	}
+	if len(setupConfig.Output) > 0 {
+		appConfig.Output = setupConfig.Output
+	}
+	if len(setupConfig.Branch) > 0 {
+		appConfig.Branch = setupConfig.Branch
+	}
+	if setupConfig.CreateBranch {
+		appConfig.CreateBranch = setupConfig.CreateBranch
+	}
+	if len(setupConfig.Include) > 0 {
+		appConfig.Include = setupConfig.Include
+	}
+	if len(setupConfig.Exclude) > 0 {
+		appConfig.Exclude = setupConfig.Exclude
+	}
+	if setupConfig.Nested {
+		appConfig.Nested = setupConfig.Nested
+	}
+	if len(setupConfig.Manifest) > 0 {
+		appConfig.Manifest = setupConfig.Manifest
+	}
+	if len(setupConfig.Gitmodules) > 0 {
+		appConfig.Gitmodules = setupConfig.Gitmodules
+	}
+	if len(setupConfig.Strategy) > 0 {
+		appConfig.Strategy = setupConfig.Strategy
+	}
+	if setupConfig.AllRemotes {
+		appConfig.AllRemotes = setupConfig.AllRemotes
+	}
+	if setupConfig.Tags {
+		appConfig.Tags = setupConfig.Tags
+	}
+	if setupConfig.Prune {
+		appConfig.Prune = setupConfig.Prune
+	}
+	if setupConfig.StaleDays > 0 {
+		appConfig.StaleDays = setupConfig.StaleDays
+	}
+	if len(setupConfig.Groups) > 0 {
+		appConfig.Groups = setupConfig.Groups
+	}
+	if len(setupConfig.Group) > 0 {
+		appConfig.Group = setupConfig.Group
+	}
 	return appConfig
 }
 
+// fetchOptions returns the options of the fetch jobs, the remote of each
+// repository is set when the job is created
+func (c *Config) fetchOptions() command.FetchOptions {
+	return command.FetchOptions{
+		AllRemotes:  c.AllRemotes,
+		Tags:        c.Tags,
+		Prune:       c.Prune,
+		CommandMode: command.ModeNative,
+	}
+}
+
+// importDirectories replaces the directories with the checkouts declared in
+// the repo tool manifest or the .gitmodules file, if one is given
+func (a *App) importDirectories() error {
+	if fi, err := os.Stat(a.Config.Gitmodules); err == nil && fi.IsDir() {
+		// the superproject is given instead of its .gitmodules file
+		a.Config.Gitmodules = filepath.Join(a.Config.Gitmodules, manifest.GitmodulesFileName)
+	}
+	file := a.Config.Gitmodules
+	if len(file) == 0 && manifest.IsImport(a.Config.Manifest) {
+		file = a.Config.Manifest
+	}
+	if len(file) == 0 {
+		return nil
+	}
+	m, err := manifest.Load(file)
+	if err != nil {
+		return err
+	}
+	a.Config.Directories = make([]string, 0, len(m.Repositories))
+	for _, r := range m.Repositories {
+		a.Config.Directories = append(a.Config.Directories, m.AbsPath(r))
+	}
+	a.Config.revisions = m.Revisions()
+	a.Config.roots = []string{m.RootDir()}
+	return nil
+}
+
 func (a *App) execQuickMode(directories []string) error {
-	if a.Config.Mode != "fetch" && a.Config.Mode != "pull" {
+	if _, ok := quickModes[a.Config.Mode]; !ok {
 		return fmt.Errorf("unrecognized quick mode: " + a.Config.Mode)
 	}
-
-	return quick(directories, a.Config.Mode)
+	if len(a.Config.Output) == 0 {
+		a.Config.Output = OutputText
+	}
+	if a.Config.Output != OutputText && a.Config.Output != OutputJSON && a.Config.Output != OutputNDJSON {
+		return fmt.Errorf("unrecognized output format: " + a.Config.Output)
+	}
+	return quick(os.Stdout, directories, a.Config)
 }
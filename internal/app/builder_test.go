@@ -1,8 +1,12 @@
 package app
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/stretchr/testify/require"
@@ -38,14 +42,35 @@ func TestOverrideConfig(t *testing.T) {
 	}
 }
 
+func TestOverrideConfigFlags(t *testing.T) {
+	file := func() *Config {
+		return &Config{Timeout: 5 * time.Minute, Workers: 4, Output: OutputJSON}
+	}
+	var tests = []struct {
+		args     *Config
+		expected *Config
+	}{
+		// the flags that are not given keep the configuration file
+		{&Config{}, file()},
+		{&Config{Output: OutputNDJSON}, &Config{Timeout: 5 * time.Minute, Workers: 4, Output: OutputNDJSON}},
+		{&Config{Timeout: time.Minute, Workers: 2}, &Config{Timeout: time.Minute, Workers: 2, Output: OutputJSON}},
+		// but a zero flag overrides it once it is given
+		{&Config{TimeoutSet: true, WorkersSet: true}, &Config{Output: OutputJSON}},
+	}
+	for _, test := range tests {
+		output := overrideConfig(file(), test.args)
+		require.Equal(t, test.expected, output)
+	}
+}
+
 func TestExecQuickMode(t *testing.T) {
-	th := git.InitTestRepositoryFromLocal(t)
+	th := git.InitTestRepositoryWithLocalRemote(t)
 	defer th.CleanUp(t)
 
 	var tests = []struct {
 		inp1 []string
 	}{
-		{[]string{th.BasicRepoPath()}},
+		{[]string{th.RepoPath}},
 	}
 	a := App{
 		Config: &Config{
@@ -57,3 +82,31 @@ func TestExecQuickMode(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestImportDirectories(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	sub := filepath.Join(th.RepoPath, "libs", "core")
+	git.RunTestGit(t, th.RepoPath, "clone", th.RemoteRepoPath(), sub)
+	content := "[submodule \"core\"]\n\tpath = libs/core\n\turl = ../remote.git\n\tbranch = master\n" +
+		"[submodule \"missing\"]\n\tpath = libs/missing\n\turl = ../missing.git\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, ".gitmodules"), []byte(content), 0644))
+
+	a := App{
+		Config: &Config{
+			Directories: []string{th.RepoPath},
+			Gitmodules:  th.RepoPath,
+			Mode:        statusMode,
+			Output:      OutputText,
+		},
+	}
+	require.NoError(t, a.importDirectories())
+	require.Equal(t, []string{sub, filepath.Join(th.RepoPath, "libs", "missing")}, a.Config.Directories)
+	dirs := filterRepositories(a.Config.Directories, nil)
+	require.Equal(t, []string{sub}, dirs)
+
+	var buf bytes.Buffer
+	require.NoError(t, quick(&buf, dirs, a.Config))
+	require.Contains(t, buf.String(), sub+": master...origin/master ahead 0 behind 0, clean, declared master")
+}
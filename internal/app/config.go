@@ -1,10 +1,13 @@
 package app
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -28,8 +31,39 @@ var (
 	quickKeyDefault     = false
 	recursionKey        = "recursion"
 	recursionKeyDefault = 1
+	timeoutKey          = "timeout"
+	timeoutKeyDefault   = "0s"
+	workersKey          = "workers"
+	workersKeyDefault   = 0
+	hostWorkersKey      = "host_workers"
+	hostWorkersDefault  = 0
+	outputKey           = "output"
+	includeKey          = "include"
+	excludeKey          = "exclude"
+	nestedKey           = "nested"
+	nestedKeyDefault    = false
+	manifestKey         = "manifest"
+	gitmodulesKey       = "gitmodules"
+	groupsKey           = "groups"
+	strategyKey         = "strategy"
+	allRemotesKey       = "all_remotes"
+	tagsKey             = "tags"
+	pruneKey            = "prune"
+	staleDaysKey        = "stale_days"
+	staleDaysDefault    = 90
+	credentialsKey      = "credentials"
 )
 
+// credentialConfig is an entry of the credentials section, the token is read
+// from one of its sources. Any other key, e.g. a plain token, is refused
+type credentialConfig struct {
+	Host         string `mapstructure:"host"`
+	Username     string `mapstructure:"username"`
+	TokenEnv     string `mapstructure:"token_env"`
+	TokenFile    string `mapstructure:"token_file"`
+	TokenCommand string `mapstructure:"token_command"`
+}
+
 // loadConfiguration returns a Config struct is filled
 func loadConfiguration() (*Config, error) {
 	if err := initializeConfigurationManager(); err != nil {
@@ -41,6 +75,10 @@ func loadConfiguration() (*Config, error) {
 	if err := readConfiguration(); err != nil {
 		return nil, err
 	}
+	credentials, err := readCredentials()
+	if err != nil {
+		return nil, err
+	}
 	var directories []string
 	if len(viper.GetStringSlice(pathsKey)) <= 0 {
 		d, _ := os.Getwd()
@@ -53,15 +91,67 @@ func loadConfiguration() (*Config, error) {
 		Depth:       viper.GetInt(recursionKey),
 		QuickMode:   viper.GetBool(quickKey),
 		Mode:        viper.GetString(modeKey),
+		Timeout:     viper.GetDuration(timeoutKey),
+		Workers:     viper.GetInt(workersKey),
+		HostWorkers: viper.GetInt(hostWorkersKey),
+		Output:      viper.GetString(outputKey),
+		Include:     viper.GetStringSlice(includeKey),
+		Exclude:     viper.GetStringSlice(excludeKey),
+		Nested:      viper.GetBool(nestedKey),
+		Manifest:    viper.GetString(manifestKey),
+		Gitmodules:  viper.GetString(gitmodulesKey),
+		Groups:      viper.GetStringMapStringSlice(groupsKey),
+		Strategy:    viper.GetString(strategyKey),
+		AllRemotes:  viper.GetBool(allRemotesKey),
+		Tags:        viper.GetBool(tagsKey),
+		Prune:       viper.GetBool(pruneKey),
+		StaleDays:   viper.GetInt(staleDaysKey),
+		Credentials: credentials,
 	}
 	return config, nil
 }
 
+// readCredentials reads the credential sources of the hosts
+func readCredentials() (command.CredentialSources, error) {
+	entries := make([]credentialConfig, 0)
+	err := viper.UnmarshalKey(credentialsKey, &entries, func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("credentials: %v, the token must be read with token_env, token_file or token_command", err)
+	}
+	return credentialSources(entries)
+}
+
+// credentialSources validates the entries of the credentials section
+func credentialSources(entries []credentialConfig) (command.CredentialSources, error) {
+	sources := make(command.CredentialSources, 0, len(entries))
+	for _, e := range entries {
+		s := &command.CredentialSource{
+			Host:         e.Host,
+			User:         e.Username,
+			TokenEnv:     e.TokenEnv,
+			TokenFile:    e.TokenFile,
+			TokenCommand: e.TokenCommand,
+		}
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
 // set default configuration parameters
 func setDefaults() error {
 	viper.SetDefault(quickKey, quickKeyDefault)
 	viper.SetDefault(recursionKey, recursionKeyDefault)
 	viper.SetDefault(modeKey, modeKeyDefault)
+	viper.SetDefault(timeoutKey, timeoutKeyDefault)
+	viper.SetDefault(workersKey, workersKeyDefault)
+	viper.SetDefault(hostWorkersKey, hostWorkersDefault)
+	viper.SetDefault(nestedKey, nestedKeyDefault)
+	viper.SetDefault(staleDaysKey, staleDaysDefault)
 	// viper.SetDefault(pathsKey, pathsKeyDefault)
 	return nil
 }
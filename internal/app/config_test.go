@@ -3,6 +3,7 @@ package app
 import (
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,3 +36,35 @@ func TestOsConfigDirectory(t *testing.T) {
 		require.Contains(t, output, test.expected)
 	}
 }
+
+func TestReadCredentials(t *testing.T) {
+	defer viper.Set(credentialsKey, nil)
+	var tests = []struct {
+		input    []map[string]interface{}
+		expected int
+		valid    bool
+	}{
+		{nil, 0, true},
+		{[]map[string]interface{}{
+			{"host": "github.com", "username": "me", "token_env": "GITHUB_TOKEN"},
+			{"host": "*.gitlab.com", "username": "oauth2", "token_command": "pass show gitlab"},
+		}, 2, true},
+		// the secret itself is not accepted
+		{[]map[string]interface{}{
+			{"host": "github.com", "username": "me", "token": "ghp_secret"},
+		}, 0, false},
+		{[]map[string]interface{}{
+			{"host": "github.com", "username": "me"},
+		}, 0, false},
+	}
+	for _, test := range tests {
+		viper.Set(credentialsKey, test.input)
+		sources, err := readCredentials()
+		if !test.valid {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Len(t, sources, test.expected)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
 // generateDirectories returns possible git repositories to pipe into git pkg
-// load function
-func generateDirectories(dirs []string, depth int) []string {
+// load function. The directories excluded by the filter are not walked, a nil
+// filter walks everything
+func generateDirectories(dirs []string, depth int, filter *directoryFilter) []string {
 	gitDirs := make([]string, 0)
 	for i := 0; i < depth; i++ {
-		directories, repositories := walkRecursive(dirs, gitDirs)
+		directories, repositories := walkRecursive(dirs, gitDirs, filter)
 		dirs = directories
 		gitDirs = repositories
 	}
@@ -20,14 +23,14 @@ func generateDirectories(dirs []string, depth int) []string {
 
 // returns given values, first search directories and second stands for possible
 // git repositories. Call this func from a "for i := 0; i<depth; i++" loop
-func walkRecursive(search, appendant []string) ([]string, []string) {
+func walkRecursive(search, appendant []string, filter *directoryFilter) ([]string, []string) {
 	max := len(search)
 	for i := 0; i < max; i++ {
 		if i >= len(search) {
 			continue
 		}
 		// find possible repositories and remaining ones, b slice is possible ones
-		a, b, err := separateDirectories(search[i])
+		a, b, err := separateDirectories(search[i], filter)
 		if err != nil {
 			continue
 		}
@@ -44,7 +47,7 @@ func walkRecursive(search, appendant []string) ([]string, []string) {
 
 // separateDirectories is to find all the files in given path. This method
 // does not check if the given file is a valid git repositories
-func separateDirectories(directory string) ([]string, []string, error) {
+func separateDirectories(directory string, filter *directoryFilter) ([]string, []string, error) {
 	dirs := make([]string, 0)
 	gitDirs := make([]string, 0)
 	files, err := ioutil.ReadDir(directory)
@@ -52,7 +55,16 @@ func separateDirectories(directory string) ([]string, []string, error) {
 	if err != nil {
 		return nil, nil, nil
 	}
+	if filter != nil {
+		if abs, err := filepath.Abs(directory); err == nil {
+			filter.loadIgnoreFile(abs)
+		}
+	}
 	for _, f := range files {
+		// the git directory of a repository is never a repository to load
+		if f.Name() == ".git" {
+			continue
+		}
 		repo := directory + string(os.PathSeparator) + f.Name()
 		file, err := os.Open(repo)
 		// if we cannot open it, simply continue to iteration and don't consider
@@ -65,16 +77,42 @@ func separateDirectories(directory string) ([]string, []string, error) {
 			file.Close()
 			continue
 		}
-		// with this approach, we ignore submodule or sub repositories in a git repository
-		ff, err := os.Open(dir + string(os.PathSeparator) + ".git")
-		if err != nil {
+		if filter.excluded(dir) {
+			file.Close()
+			continue
+		}
+		// submodules and repositories nested in a git repository are only
+		// found if the filter allows walking into the repositories
+		kind := git.DetectKind(dir)
+		if kind == git.KindNone {
 			dirs = append(dirs, dir)
 		} else {
-			gitDirs = append(gitDirs, dir)
+			if filter.included(dir) {
+				gitDirs = append(gitDirs, dir)
+			}
+			if filter.walkNested() && kind != git.KindBare {
+				dirs = append(dirs, dir)
+			}
 		}
-		ff.Close()
 		file.Close()
 
 	}
 	return dirs, gitDirs, nil
 }
+
+// filterRepositories returns the directories that are repositories and pass
+// the filter, it is used when the repositories are known beforehand
+func filterRepositories(dirs []string, filter *directoryFilter) []string {
+	gitDirs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil || git.DetectKind(abs) == git.KindNone {
+			continue
+		}
+		if filter.excluded(abs) || !filter.included(abs) {
+			continue
+		}
+		gitDirs = append(gitDirs, abs)
+	}
+	return gitDirs
+}
@@ -21,7 +21,7 @@ func TestGenerateDirectories(t *testing.T) {
 		{[]string{th.RepoPath}, 2, []string{th.BasicRepoPath(), th.DirtyRepoPath()}}, // maybe move one repo to a sub folder
 	}
 	for _, test := range tests {
-		output := generateDirectories(test.inp1, test.inp2)
+		output := generateDirectories(test.inp1, test.inp2, nil)
 		require.ElementsMatch(t, output, test.expected)
 	}
 }
@@ -39,12 +39,12 @@ func TestWalkRecursive(t *testing.T) {
 		{
 			[]string{th.RepoPath},
 			[]string{""},
-			[]string{filepath.Join(th.RepoPath, ".git"), filepath.Join(th.RepoPath, ".gitmodules"), th.NonRepoPath()},
+			[]string{filepath.Join(th.RepoPath, ".gitmodules"), th.NonRepoPath()},
 			[]string{"", th.BasicRepoPath(), th.DirtyRepoPath()},
 		},
 	}
 	for _, test := range tests {
-		out1, out2 := walkRecursive(test.inp1, test.inp2)
+		out1, out2 := walkRecursive(test.inp1, test.inp2, nil)
 		require.ElementsMatch(t, out1, test.exp1)
 		require.ElementsMatch(t, out2, test.exp2)
 	}
@@ -66,14 +66,38 @@ func TestSeparateDirectories(t *testing.T) {
 		},
 		{
 			th.RepoPath,
-			[]string{filepath.Join(th.RepoPath, ".git"), filepath.Join(th.RepoPath, ".gitmodules"), th.NonRepoPath()},
+			[]string{filepath.Join(th.RepoPath, ".gitmodules"), th.NonRepoPath()},
 			[]string{th.BasicRepoPath(), th.DirtyRepoPath()},
 		},
 	}
 	for _, test := range tests {
-		out1, out2, err := separateDirectories(test.input)
+		out1, out2, err := separateDirectories(test.input, nil)
 		require.NoError(t, err)
 		require.ElementsMatch(t, out1, test.exp1)
 		require.ElementsMatch(t, out2, test.exp2)
 	}
 }
+
+func TestGenerateDirectoriesNested(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	root := filepath.Dir(th.RepoPath)
+	worktree := filepath.Join(root, "worktree")
+	git.RunTestGit(t, th.RepoPath, "worktree", "add", "-b", "feature", worktree)
+	git.RunTestGit(t, th.RepoPath, "-c", "protocol.file.allow=always", "submodule", "add", th.RemoteRepoPath(), "sub")
+
+	var tests = []struct {
+		input    bool
+		expected []string
+	}{
+		{false, []string{th.RepoPath, th.RemoteRepoPath(), worktree}},
+		{true, []string{th.RepoPath, th.RemoteRepoPath(), worktree, filepath.Join(th.RepoPath, "sub")}},
+	}
+	for _, test := range tests {
+		filter := newDirectoryFilter([]string{root}, nil, nil)
+		filter.nested = test.input
+		output := generateDirectories([]string{root}, 3, filter)
+		require.ElementsMatch(t, test.expected, output)
+	}
+}
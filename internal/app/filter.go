@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/isacikgoz/gitbatch/internal/group"
+)
+
+// ignoreFileName is the file that holds the exclude patterns of the
+// directory it resides in, the syntax is same with .gitignore
+const ignoreFileName = ".gitbatchignore"
+
+// directoryFilter decides which directories are walked and which of the found
+// repositories are loaded while discovering repositories. The patterns are in
+// gitignore syntax and relative to the directories they are given for
+type directoryFilter struct {
+	include group.Patterns
+	exclude group.Patterns
+	// nested walks into the repositories to find submodules and the
+	// repositories nested in other ones
+	nested bool
+}
+
+// newDirectoryFilter creates a filter for the given patterns, each pattern
+// applies to all of the root directories
+func newDirectoryFilter(roots, include, exclude []string) *directoryFilter {
+	return &directoryFilter{
+		include: group.ParsePatterns(roots, include),
+		exclude: group.ParsePatterns(roots, exclude),
+	}
+}
+
+// loadIgnoreFile adds the patterns in the .gitbatchignore file of the
+// directory, if there is any
+func (f *directoryFilter) loadIgnoreFile(dir string) {
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	domain := group.SplitPath(dir)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		f.exclude = append(f.exclude, gitignore.ParsePattern(line, domain))
+	}
+}
+
+// excluded returns true if the directory should not be walked or loaded
+func (f *directoryFilter) excluded(dir string) bool {
+	return f != nil && f.exclude.Match(dir)
+}
+
+// included returns true if the repository or one of its parents matches with
+// the include patterns. Everything is included if there is no pattern
+func (f *directoryFilter) included(dir string) bool {
+	return f == nil || len(f.include) == 0 || f.include.Match(dir)
+}
+
+// walkNested returns true if the repositories should be walked as well
+func (f *directoryFilter) walkNested() bool {
+	return f != nil && f.nested
+}
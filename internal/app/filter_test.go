@@ -0,0 +1,67 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryFilter(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitbatch-filter")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	repos := []string{
+		"app",
+		"node_modules/dep",
+		"web/node_modules/dep",
+		"archive/old",
+		"projects/api",
+		"projects/legacy",
+	}
+	for _, repo := range repos {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, repo, ".git"), 0755))
+	}
+	err = ioutil.WriteFile(filepath.Join(root, "projects", ignoreFileName), []byte("# old stuff\nlegacy\n"), 0644)
+	require.NoError(t, err)
+
+	var tests = []struct {
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			nil,
+			nil,
+			[]string{"app", "node_modules/dep", "web/node_modules/dep", "archive/old", "projects/api"},
+		},
+		{
+			nil,
+			[]string{"**/node_modules", "archive/*"},
+			[]string{"app", "projects/api"},
+		},
+		{
+			[]string{"projects"},
+			[]string{"**/node_modules"},
+			[]string{"projects/api"},
+		},
+	}
+	for _, test := range tests {
+		filter := newDirectoryFilter([]string{root}, test.include, test.exclude)
+		output := generateDirectories([]string{root}, 5, filter)
+		expected := make([]string, 0)
+		for _, e := range test.expected {
+			expected = append(expected, filepath.Join(root, e))
+		}
+		require.ElementsMatch(t, expected, output)
+	}
+}
+
+func TestDirectoryFilterNil(t *testing.T) {
+	var filter *directoryFilter
+	require.False(t, filter.excluded("/some/dir"))
+	require.True(t, filter.included("/some/dir"))
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/isacikgoz/gitbatch/internal/group"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
+)
+
+// tagsFileName is the file in the configuration directory that holds the
+// tags given to the repositories from the gui
+var tagsFileName = "tags.yml"
+
+// repositoryGroups collects the groups of the repositories from the
+// configuration, the workspace manifest and the saved tags
+func (a *App) repositoryGroups() *group.Groups {
+	roots := a.Config.roots
+	if len(roots) == 0 {
+		roots = a.Config.Directories
+	}
+	groups := group.New(roots, a.Config.Groups)
+	if file := a.manifestFile(); len(file) > 0 {
+		if m, err := manifest.Load(file); err == nil {
+			for _, r := range m.Repositories {
+				groups.Assign(m.AbsPath(r), r.Groups...)
+			}
+		}
+	}
+	// the repositories can still be grouped by the configuration if the tags
+	// cannot be read
+	_ = groups.LoadTags(filepath.Join(configurationDirectory, tagsFileName))
+	return groups
+}
+
+// filterGroups returns the directories that belong to any of the given
+// groups, an unknown group is an error rather than an empty result
+func filterGroups(dirs []string, groups *group.Groups, names []string) ([]string, error) {
+	known := make(map[string]bool)
+	for _, name := range groups.Names() {
+		known[name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown group: %s", name)
+		}
+	}
+	return groups.Filter(dirs, names), nil
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/group"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterGroups(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "src")
+	api := filepath.Join(root, "services", "api")
+	web := filepath.Join(root, "web")
+	groups := group.New([]string{root}, map[string][]string{"backend": {"services/"}})
+
+	var tests = []struct {
+		names    []string
+		expected []string
+		valid    bool
+	}{
+		{nil, []string{api, web}, true},
+		{[]string{"backend"}, []string{api}, true},
+		{[]string{"frontend"}, nil, false},
+	}
+	for _, test := range tests {
+		dirs, err := filterGroups([]string{api, web}, groups, test.names)
+		if !test.valid {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.expected, dirs)
+	}
+}
@@ -1,49 +1,370 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/isacikgoz/gitbatch/internal/command"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/job"
 )
 
-func quick(directories []string, mode string) error {
-	var wg sync.WaitGroup
+// output formats of the quick mode
+const (
+	OutputText   = "text"
+	OutputJSON   = "json"
+	OutputNDJSON = "ndjson"
+)
+
+// quick modes that are not a job, they only report the state of repositories
+const (
+	statusMode = "status"
+)
+
+// quickModes are the modes that can be run without gui
+var quickModes = map[string]job.Type{
+	"fetch":    job.FetchJob,
+	"pull":     job.PullJob,
+	"merge":    job.MergeJob,
+	"checkout": job.CheckoutJob,
+	"push":     job.PushJob,
+	statusMode: "",
+}
+
+// exit codes of the quick mode, if the repositories failed for different
+// reasons the first matching one in the order of auth, conflict and failure
+// is used
+const (
+	// ExitFailure means at least one repository failed
+	ExitFailure = 1
+	// ExitAuthFailure means at least one repository failed to authenticate
+	// with its remote
+	ExitAuthFailure = 2
+	// ExitConflict means at least one repository ended up with a conflict
+	ExitConflict = 3
+)
+
+// ExitError is returned from quick mode if any of the repositories failed,
+// Code is the exit code that the process should return
+type ExitError struct {
+	Code   int
+	Failed int
+	Total  int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%d of %d repositories failed", e.Failed, e.Total)
+}
+
+// result is the outcome of an operation on a single repository
+type result struct {
+	Path     string `json:"path"`
+	Mode     string `json:"mode"`
+	Kind     string `json:"kind,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Default  string `json:"default_branch,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Clean    *bool  `json:"clean,omitempty"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Ahead    *int   `json:"ahead,omitempty"`
+	Behind   *int   `json:"behind,omitempty"`
+	// Fetched are the references changed by the fetch of each remote
+	Fetched    []*git.RefChanges `json:"fetched,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+	ErrorClass string            `json:"error_class,omitempty"`
+	Output     string            `json:"output,omitempty"`
+
+	err     error
+	started time.Time
+}
+
+// quick runs the jobs of given mode on the repositories through a job queue
+// without the gui, the results are written to w as soon as they are finished
+func quick(w io.Writer, directories []string, cfg *Config) error {
+	var (
+		mx      sync.Mutex
+		results = make([]*result, len(directories))
+		jobs    = make(map[*job.Job]*result)
+	)
+	emit := func(res *result) {
+		mx.Lock()
+		defer mx.Unlock()
+		switch cfg.Output {
+		case OutputNDJSON:
+			writeJSON(w, res)
+		case OutputJSON:
+		default:
+			writeText(w, res)
+		}
+	}
 	start := time.Now()
-	for _, dir := range directories {
+	q := job.CreateJobQueue()
+	q.SetTimeout(cfg.Timeout)
+	q.SetHostLimit(cfg.HostWorkers)
+	for i, dir := range directories {
+		res := &result{Path: dir, Mode: cfg.Mode, Revision: cfg.revisions[dir]}
+		results[i] = res
+		begin := time.Now()
+		j, err := quickJob(dir, cfg, res)
+		if err != nil || len(j.JobType) == 0 {
+			res.started = begin
+			res.finish(j, err)
+			emit(res)
+			continue
+		}
+		if err := q.AddJob(j); err != nil {
+			res.finish(j, err)
+			emit(res)
+			continue
+		}
+		jobs[j] = res
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(d string, mode string) {
+		go func() {
 			defer wg.Done()
-			if err := operate(d, mode); err != nil {
-				fmt.Printf("could not perform %s on %s: %s", mode, d, err)
+			for {
+				j, finished, err := q.StartNext()
+				if finished {
+					return
+				}
+				res := jobs[j]
+				res.finish(j, err)
+				emit(res)
 			}
-			fmt.Printf("%s: successful\n", d)
-		}(dir, mode)
+		}()
 	}
 	wg.Wait()
+
 	elapsed := time.Since(start)
-	fmt.Printf("%d repositories finished in: %s\n", len(directories), elapsed)
-	return nil
+	switch cfg.Output {
+	case OutputJSON:
+		writeJSON(w, results)
+	case OutputNDJSON:
+	default:
+		fmt.Fprintf(w, "%d repositories finished in: %s\n", len(directories), elapsed)
+	}
+	return exitError(results)
+}
+
+// quickJob initializes the repository and creates its job for the quick mode.
+// The job has no type if the mode only reports the state of the repository
+func quickJob(dir string, cfg *Config, res *result) (*job.Job, error) {
+	r, err := git.InitializeRepo(dir)
+	if err != nil {
+		return nil, err
+	}
+	res.Before = head(r)
+	j := &job.Job{
+		JobType:    quickModes[cfg.Mode],
+		Repository: r,
+	}
+	switch j.JobType {
+	case job.FetchJob, job.PushJob, job.PullJob:
+		if r.State.Remote == nil {
+			return j, gerr.ErrRemoteNotFound
+		}
+	}
+	// the remote operations read the configured token of each remote's
+	// host, if any
+	switch j.JobType {
+	case "":
+		return j, nil
+	case job.FetchJob:
+		opts := cfg.fetchOptions()
+		opts.RemoteName = r.State.Remote.Name
+		opts.Sources = cfg.Credentials
+		j.Options = &opts
+	case job.PushJob:
+		j.Options = &command.PushOptions{
+			RemoteName:  r.State.Remote.Name,
+			SetUpstream: r.State.Branch.Upstream == nil,
+			CommandMode: command.ModeNative,
+			Sources:     cfg.Credentials,
+		}
+	case job.PullJob:
+		j.Options = &command.PullOptions{
+			RemoteName:  r.State.Remote.Name,
+			Strategy:    command.Strategy(cfg.Strategy),
+			CommandMode: command.ModeNative,
+			Sources:     cfg.Credentials,
+		}
+	case job.MergeJob:
+		j.Options = &command.MergeOptions{
+			Strategy: command.Strategy(cfg.Strategy),
+		}
+	case job.CheckoutJob:
+		// each repository switches to its own default branch if there is
+		// no target
+		j.Options = &command.CheckoutOptions{
+			TargetRef:      cfg.Branch,
+			CreateIfAbsent: cfg.CreateBranch,
+			CommandMode:    command.ModeNative,
+		}
+	}
+	// the job starts when the repository is set to working, so that the
+	// duration does not include the time spent in the queue
+	r.On(git.RepositoryUpdated, func(event *git.RepositoryEvent) error {
+		if r.WorkStatus() == git.Working && res.started.IsZero() {
+			res.started = time.Now()
+		}
+		return nil
+	})
+	return j, nil
 }
 
-func operate(directory, mode string) error {
-	r, err := git.FastInitializeRepo(directory)
+// finish fills the outcome of the job into the result, a job that failed
+// without an error is reported with the status message of its repository
+func (res *result) finish(j *job.Job, err error) {
+	if !res.started.IsZero() {
+		res.DurationMS = time.Since(res.started).Milliseconds()
+	}
+	if j != nil {
+		r := j.Repository
+		if err == nil && r.WorkStatus() == git.Fail {
+			err = errors.New(r.State.Message)
+		}
+		res.After = head(r)
+		res.Kind = r.Kind.String()
+		res.Default = r.DefaultBranch
+		res.Fetched = r.State.Fetched
+		if b := r.State.Branch; b != nil {
+			res.Branch = b.Name
+			res.Ahead = count(b.Pushables)
+			res.Behind = count(b.Pullables)
+			clean := b.Clean
+			res.Clean = &clean
+			if b.Upstream != nil {
+				res.Upstream = b.Upstream.Name
+			}
+		}
+	}
 	if err != nil {
-		return err
-	}
-	switch mode {
-	case "fetch":
-		return command.Fetch(r, &command.FetchOptions{
-			RemoteName: "origin",
-			Progress:   true,
-		})
-	case "pull":
-		return command.Pull(r, &command.PullOptions{
-			RemoteName: "origin",
-			Progress:   true,
-		})
-	}
-	return nil
+		res.err = err
+		res.Error = err.Error()
+		res.ErrorClass = classify(err)
+		var gitErr *gerr.Error
+		if errors.As(err, &gitErr) {
+			res.Output = gitErr.Output
+		}
+	}
+}
+
+// head returns the hash of the HEAD, or an empty string if it is not resolved
+func head(r *git.Repository) string {
+	ref, err := r.Repo.Head()
+	if err != nil {
+		return ""
+	}
+	return ref.Hash().String()
+}
+
+// count converts the ahead/behind count of a branch, which is "?" if unknown
+func count(s string) *int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// classify returns the class of the error, errors that are not recognized
+// are reported as unclassified
+func classify(err error) string {
+	return gerr.CategoryOf(err).Error()
+}
+
+// exitError returns the error that carries the exit code for given results,
+// nil if all of them succeeded
+func exitError(results []*result) error {
+	e := &ExitError{Total: len(results)}
+	for _, res := range results {
+		if res.err == nil {
+			continue
+		}
+		e.add(res.err)
+	}
+	if e.Failed == 0 {
+		return nil
+	}
+	return e
+}
+
+// add counts the error as a failure and updates the exit code according to
+// its precedence
+func (e *ExitError) add(err error) {
+	e.Failed++
+	code := ExitFailure
+	switch gerr.CategoryOf(err) {
+	case gerr.ErrAuthenticationRequired, gerr.ErrAuthorizationFailed,
+		gerr.ErrPermissionDenied, gerr.ErrInvalidAuthMethod, gerr.ErrPassphraseRequired,
+		gerr.ErrUnknownHostKey, gerr.ErrHostKeyMismatch:
+		code = ExitAuthFailure
+	case gerr.ErrConflictAfterMerge, gerr.ErrConflictAfterRebase, gerr.ErrUnmergedFiles,
+		gerr.ErrMergeAbortedTryCommit, gerr.ErrOverwrittenByMerge:
+		code = ExitConflict
+	}
+	if e.Code == 0 || code == ExitAuthFailure || (code == ExitConflict && e.Code == ExitFailure) {
+		e.Code = code
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+func writeText(w io.Writer, res *result) {
+	if res.err != nil {
+		fmt.Fprintf(w, "could not perform %s on %s: %s\n", res.Mode, res.Path, res.err)
+		return
+	}
+	if len(res.Fetched) > 0 {
+		msgs := make([]string, 0, len(res.Fetched))
+		for _, c := range res.Fetched {
+			msgs = append(msgs, c.String())
+		}
+		fmt.Fprintf(w, "%s: successful, %s\n", res.Path, strings.Join(msgs, "; "))
+		return
+	}
+	if res.Mode != statusMode {
+		fmt.Fprintf(w, "%s: successful\n", res.Path)
+		return
+	}
+	state := "dirty"
+	if res.Clean != nil && *res.Clean {
+		state = "clean"
+	}
+	ahead, behind := "?", "?"
+	if res.Ahead != nil {
+		ahead = strconv.Itoa(*res.Ahead)
+	}
+	if res.Behind != nil {
+		behind = strconv.Itoa(*res.Behind)
+	}
+	declared := ""
+	if len(res.Revision) > 0 {
+		declared = ", declared " + res.Revision
+	}
+	fmt.Fprintf(w, "%s: %s...%s ahead %s behind %s, %s%s\n", res.Path, res.Branch, res.Upstream, ahead, behind, state, declared)
 }
@@ -1,14 +1,20 @@
 package app
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/stretchr/testify/require"
 )
 
 func TestQuick(t *testing.T) {
-	th := git.InitTestRepositoryFromLocal(t)
+	th := git.InitTestRepositoryWithLocalRemote(t)
 	defer th.CleanUp(t)
 
 	var tests = []struct {
@@ -16,15 +22,133 @@ func TestQuick(t *testing.T) {
 		inp2 string
 	}{
 		{
-			[]string{th.DirtyRepoPath()},
+			[]string{th.RepoPath},
 			"fetch",
 		}, {
-			[]string{th.DirtyRepoPath()},
+			[]string{th.RepoPath},
 			"pull",
+		}, {
+			[]string{th.RepoPath},
+			"merge",
+		}, {
+			[]string{th.RepoPath},
+			"push",
 		},
 	}
 	for _, test := range tests {
-		err := quick(test.inp1, test.inp2)
+		var buf bytes.Buffer
+		err := quick(&buf, test.inp1, &Config{Mode: test.inp2, Output: OutputText})
 		require.NoError(t, err)
+		require.Contains(t, buf.String(), th.RepoPath+": successful")
+	}
+}
+
+func TestQuickJSON(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	git.CreateTestCommit(t, th.RepoPath, "ahead.txt")
+	missing := th.RepoPath + "-missing"
+
+	var buf bytes.Buffer
+	err := quick(&buf, []string{th.RepoPath, missing}, &Config{Mode: "fetch", Output: OutputJSON})
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, ExitFailure, exitErr.Code)
+	require.Equal(t, 1, exitErr.Failed)
+
+	var results []*result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	require.Len(t, results, 2)
+	require.Equal(t, th.RepoPath, results[0].Path)
+	require.Equal(t, "master", results[0].Branch)
+	require.Len(t, results[0].Before, 40)
+	require.Equal(t, results[0].Before, results[0].After)
+	require.NotNil(t, results[0].Ahead)
+	require.Equal(t, 1, *results[0].Ahead)
+	require.Equal(t, "origin/master", results[0].Upstream)
+	require.Equal(t, []*git.RefChanges{{Remote: "origin"}}, results[0].Fetched)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, missing, results[1].Path)
+	require.NotEmpty(t, results[1].Error)
+	require.Equal(t, gerr.ErrUnclassified.Error(), results[1].ErrorClass)
+}
+
+func TestQuickNDJSON(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	var buf bytes.Buffer
+	err := quick(&buf, []string{th.RepoPath, th.RepoPath}, &Config{Mode: "fetch", Output: OutputNDJSON})
+	require.NoError(t, err)
+
+	lines := 0
+	scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+	for scanner.Scan() {
+		var res result
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &res))
+		require.Equal(t, "fetch", res.Mode)
+		lines++
+	}
+	require.Equal(t, 2, lines)
+}
+
+func TestQuickCheckout(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	var buf bytes.Buffer
+	err := quick(&buf, []string{th.RepoPath}, &Config{Mode: "checkout", Branch: "feature", Output: OutputJSON})
+	require.Error(t, err)
+
+	buf.Reset()
+	err = quick(&buf, []string{th.RepoPath}, &Config{Mode: "checkout", Branch: "feature", CreateBranch: true, Output: OutputJSON})
+	require.NoError(t, err)
+	var results []*result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	require.Equal(t, "feature", results[0].Branch)
+
+	// without a branch the default branch is checked out
+	buf.Reset()
+	err = quick(&buf, []string{th.RepoPath}, &Config{Mode: "checkout", Output: OutputJSON})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &results))
+	require.Equal(t, "master", results[0].Branch)
+}
+
+func TestQuickStatus(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	var buf bytes.Buffer
+	err := quick(&buf, []string{th.RepoPath}, &Config{Mode: "status", Output: OutputText})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), th.RepoPath+": master...origin/master ahead 0 behind 0, clean")
+}
+
+func TestExitError(t *testing.T) {
+	var tests = []struct {
+		input  []error
+		output int
+	}{
+		{[]error{nil, nil}, 0},
+		{[]error{nil, errors.New("oops")}, ExitFailure},
+		{[]error{errors.New("oops"), gerr.ErrConflictAfterMerge}, ExitConflict},
+		{[]error{gerr.ErrConflictAfterMerge, gerr.ErrAuthenticationRequired}, ExitAuthFailure},
+		{[]error{gerr.ErrPermissionDenied, gerr.ErrUnmergedFiles, errors.New("oops")}, ExitAuthFailure},
+	}
+	for _, test := range tests {
+		results := make([]*result, 0)
+		for _, err := range test.input {
+			results = append(results, &result{err: err})
+		}
+		err := exitError(results)
+		if test.output == 0 {
+			require.NoError(t, err)
+			continue
+		}
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr))
+		require.Equal(t, test.output, exitErr.Code)
 	}
 }
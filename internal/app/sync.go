@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/isacikgoz/gitbatch/internal/job"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
+)
+
+// Sync clones the repositories that are declared in the workspace manifest but
+// missing on the disk, and adds the missing remotes of the existing ones
+func (a *App) Sync() error {
+	file := a.manifestFile()
+	if len(file) == 0 {
+		return fmt.Errorf("no manifest found, create %s in the workspace root or %s in %s",
+			manifest.WorkspaceFileName, manifest.FileName, configurationDirectory)
+	}
+	m, err := manifest.Load(file)
+	if err != nil {
+		return err
+	}
+	return a.sync(os.Stdout, m)
+}
+
+func (a *App) sync(w io.Writer, m *manifest.Manifest) error {
+	root := m.RootDir()
+	filter := newDirectoryFilter([]string{root}, a.Config.Include, a.Config.Exclude)
+	filter.nested = a.Config.Nested
+	depth := m.Depth()
+	if a.Config.Depth > depth {
+		depth = a.Config.Depth
+	}
+	found := generateDirectories([]string{root}, depth, filter)
+
+	q := job.CreateJobQueue()
+	q.SetTimeout(a.Config.Timeout)
+	q.SetWorkers(a.Config.Workers)
+	q.SetHostLimit(a.Config.HostWorkers)
+	report := m.Sync(q, found, a.Config.Credentials, nil)
+
+	switch a.Config.Output {
+	case OutputJSON, OutputNDJSON:
+		writeJSON(w, report)
+	default:
+		writeSyncReport(w, report)
+	}
+	if len(report.Failed) == 0 {
+		return nil
+	}
+	e := &ExitError{Total: len(m.Repositories)}
+	for _, f := range report.Failed {
+		e.add(f.Err)
+	}
+	return e
+}
+
+// manifestFile returns the manifest given with the configuration, otherwise
+// it looks for one in the directories and in the configuration directory
+func (a *App) manifestFile() string {
+	if len(a.Config.Gitmodules) > 0 {
+		return a.Config.Gitmodules
+	}
+	if len(a.Config.Manifest) > 0 {
+		return a.Config.Manifest
+	}
+	dirs := append([]string{}, a.Config.Directories...)
+	return manifest.Find(append(dirs, configurationDirectory)...)
+}
+
+func writeSyncReport(w io.Writer, report *manifest.Report) {
+	for _, path := range report.Cloned {
+		fmt.Fprintf(w, "%s: cloned\n", path)
+	}
+	paths := make([]string, 0, len(report.RemotesAdded))
+	for path := range report.RemotesAdded {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(w, "%s: added remotes %s\n", path, strings.Join(report.RemotesAdded[path], ", "))
+	}
+	for _, path := range report.Untracked {
+		fmt.Fprintf(w, "%s: not in the manifest\n", path)
+	}
+	for _, f := range report.Failed {
+		fmt.Fprintf(w, "could not sync %s: %s\n", f.Path, f.Error)
+	}
+	fmt.Fprintf(w, "%d cloned, %d failed, %d not in the manifest\n", len(report.Cloned), len(report.Failed), len(report.Untracked))
+}
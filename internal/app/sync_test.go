@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	workspace := filepath.Dir(th.RepoPath)
+	url := "file://" + filepath.ToSlash(th.RemoteRepoPath())
+	content := fmt.Sprintf("repositories:\n  - path: cloned\n    url: %s\n  - path: local\n    url: %s\n", url, url)
+	file := filepath.Join(workspace, manifest.WorkspaceFileName)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+
+	a := App{
+		Config: &Config{
+			Directories: []string{workspace},
+			Output:      OutputJSON,
+		},
+	}
+	require.Equal(t, file, a.manifestFile())
+	m, err := manifest.Load(a.manifestFile())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, a.sync(&buf, m))
+	report := &manifest.Report{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), report))
+	require.Equal(t, []string{filepath.Join(workspace, "cloned")}, report.Cloned)
+	require.Equal(t, []string{th.RemoteRepoPath()}, report.Untracked)
+
+	// a repository that can not be cloned fails the sync
+	m.Repositories = append(m.Repositories, &manifest.Repository{Path: "broken", URL: url + "-missing"})
+	a.Config.Output = OutputText
+	buf.Reset()
+	err = a.sync(&buf, m)
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	require.Equal(t, ExitFailure, exitErr.Code)
+	require.Contains(t, buf.String(), "0 cloned, 1 failed, 1 not in the manifest")
+}
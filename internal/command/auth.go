@@ -0,0 +1,275 @@
+package command
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// errNoNativeAuth is returned when there is no key or known_hosts file to
+// authenticate with, git may still handle it with its own configuration
+var errNoNativeAuth = errors.New("no native auth method")
+
+// defaultIdentityFiles are the keys that ssh tries if none is configured
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// remoteURL returns the first url of the remote, an empty string is returned
+// if the remote is not configured
+func remoteURL(r *git.Repository, name string) string {
+	rm, err := r.Repo.Remote(name)
+	if err != nil || len(rm.Config().URLs) == 0 {
+		return ""
+	}
+	return rm.Config().URLs[0]
+}
+
+// authMethod returns the auth method for the remote url. The credentials are
+// used as basic auth for http remotes, the ones of the session are used if
+// not given. The helpers are asked once the server requires credentials, see
+// challengeAuth. For ssh remotes the key file of the credentials is used if
+// it is given, otherwise the keys of the ssh-agent and the identity files are
+// tried. The host keys are checked against known_hosts
+func authMethod(rawURL string, c *git.Credentials) (transport.AuthMethod, error) {
+	u, err := git.ParseRemoteURL(rawURL)
+	if err != nil {
+		// let the transport decide what to do with it
+		return nil, nil
+	}
+	switch u.Scheme {
+	case git.AuthProtocolHTTP, git.AuthProtocolHTTPS:
+		if c == nil {
+			c = cachedCredentials(rawURL)
+		}
+		if c == nil {
+			return nil, nil
+		}
+		return &http.BasicAuth{
+			Username: c.User,
+			Password: c.Password,
+		}, nil
+	case git.AuthProtocolSSH:
+		if c == nil {
+			c = cachedCredentials(rawURL)
+		}
+		return sshAuthMethod(u, c)
+	}
+	return nil, nil
+}
+
+// challengeAuth looks up the credentials of a http remote after the server
+// asked for them to an anonymous request, the same way as git does. So the
+// credential helpers are not run for public repositories. A nil auth method
+// is returned if there is nothing to retry with
+func challengeAuth(dir, rawURL string, auth transport.AuthMethod, err error) (*git.Credentials, transport.AuthMethod) {
+	if auth != nil || err != transport.ErrAuthenticationRequired {
+		return nil, nil
+	}
+	u, perr := git.ParseRemoteURL(rawURL)
+	if perr != nil || (u.Scheme != git.AuthProtocolHTTP && u.Scheme != git.AuthProtocolHTTPS) {
+		return nil, nil
+	}
+	c := LookupCredentials(dir, rawURL)
+	if c == nil {
+		return nil, nil
+	}
+	return c, &http.BasicAuth{
+		Username: c.User,
+		Password: c.Password,
+	}
+}
+
+func sshAuthMethod(u *git.RemoteURL, c *git.Credentials) (transport.AuthMethod, error) {
+	// go-git refuses to connect without a known_hosts file, git can still
+	// ask for the host key or follow StrictHostKeyChecking
+	if _, err := gitssh.NewKnownHostsCallback(); err != nil {
+		return nil, errNoNativeAuth
+	}
+	name := sshUser(u, c)
+	if c != nil && len(c.KeyFile) > 0 {
+		auth, err := gitssh.NewPublicKeysFromFile(name, expandHome(c.KeyFile), c.Passphrase)
+		if err != nil {
+			return nil, keyError(err)
+		}
+		return auth, nil
+	}
+	signers := agentSigners()
+	encrypted := false
+	for _, file := range IdentityFiles(u) {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(b)
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			if c == nil || len(c.Passphrase) == 0 {
+				encrypted = true
+				continue
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(c.Passphrase))
+		}
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		if encrypted {
+			return nil, gerr.ErrPassphraseRequired
+		}
+		return nil, errNoNativeAuth
+	}
+	return &gitssh.PublicKeysCallback{
+		User: name,
+		Callback: func() ([]ssh.Signer, error) {
+			return signers, nil
+		},
+	}, nil
+}
+
+// sshAgent is the client of the ssh-agent shared by every operation, so that
+// a batch opens a single connection to the agent. The connection is kept open
+// since the agent signs on handshake
+var sshAgent = struct {
+	sync.Mutex
+	sock   string
+	conn   net.Conn
+	client agent.ExtendedAgent
+}{}
+
+// agentSigners returns the keys of the ssh-agent, the connection is opened on
+// the first call and reused as long as SSH_AUTH_SOCK stays the same
+func agentSigners() []ssh.Signer {
+	signers := make([]ssh.Signer, 0)
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	sshAgent.Lock()
+	defer sshAgent.Unlock()
+	if sshAgent.conn != nil && sshAgent.sock != sock {
+		closeAgent()
+	}
+	if len(sock) == 0 {
+		return signers
+	}
+	if sshAgent.conn == nil {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return signers
+		}
+		sshAgent.sock, sshAgent.conn, sshAgent.client = sock, conn, agent.NewClient(conn)
+	}
+	s, err := sshAgent.client.Signers()
+	if err != nil {
+		// the agent may be restarted, it is connected again on the next call
+		closeAgent()
+		return signers
+	}
+	return append(signers, s...)
+}
+
+// closeAgent closes the connection to the ssh-agent, the lock of sshAgent
+// must be held
+func closeAgent() {
+	_ = sshAgent.conn.Close()
+	sshAgent.sock, sshAgent.conn, sshAgent.client = "", nil, nil
+}
+
+// IdentityFiles returns the existing private keys for the host of the remote,
+// the one configured in ~/.ssh/config comes first
+func IdentityFiles(u *git.RemoteURL) []string {
+	candidates := make([]string, 0, len(defaultIdentityFiles)+1)
+	if gitssh.DefaultSSHConfig != nil {
+		if file := gitssh.DefaultSSHConfig.Get(u.Host, "IdentityFile"); len(file) > 0 {
+			candidates = append(candidates, expandHome(file))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range defaultIdentityFiles {
+			candidates = append(candidates, filepath.Join(home, ".ssh", name))
+		}
+	}
+	files := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, file := range candidates {
+		if _, err := os.Stat(file); err != nil || seen[file] {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	return files
+}
+
+// sshUser returns the user of the credentials, the url, the ssh config or the
+// current user in order
+func sshUser(u *git.RemoteURL, c *git.Credentials) string {
+	if c != nil && len(c.User) > 0 {
+		return c.User
+	}
+	if len(u.User) > 0 {
+		return u.User
+	}
+	if gitssh.DefaultSSHConfig != nil {
+		if name := gitssh.DefaultSSHConfig.Get(u.Host, "User"); len(name) > 0 {
+			return name
+		}
+	}
+	if cu, err := user.Current(); err == nil {
+		return cu.Username
+	}
+	return os.Getenv("USER")
+}
+
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// keyError converts the errors of a missing or a wrong passphrase
+func keyError(err error) error {
+	if _, ok := err.(*ssh.PassphraseMissingError); ok || errors.Is(err, x509.IncorrectPasswordError) ||
+		strings.Contains(err.Error(), "passphrase") {
+		return gerr.ErrPassphraseRequired
+	}
+	return err
+}
+
+// authError maps the authentication errors of a native operation, nil is
+// returned if the error is not about authentication
+func authError(err error) error {
+	switch err {
+	case transport.ErrAuthenticationRequired:
+		return gerr.ErrAuthenticationRequired
+	case transport.ErrAuthorizationFailed:
+		// the credentials are refused, they are asked again
+		return gerr.ErrAuthorizationFailed
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "knownhosts: key mismatch"):
+		return gerr.ErrHostKeyMismatch
+	case strings.Contains(msg, "knownhosts: key is unknown"):
+		return gerr.ErrUnknownHostKey
+	case strings.Contains(msg, "ssh: unable to authenticate"):
+		return gerr.ErrAuthenticationRequired
+	}
+	return nil
+}
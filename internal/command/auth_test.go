@@ -0,0 +1,234 @@
+package command
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// serveTestSSH starts an in-process ssh server that runs the git commands of
+// the clients with the given public key, the address of it is returned
+func serveTestSSH(t *testing.T, hostKey ssh.Signer, authorized ssh.PublicKey) string {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, gerr.ErrPermissionDenied
+		},
+	}
+	config.AddHostKey(hostKey)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				var payload struct{ Command string }
+				if req.Type != "exec" || ssh.Unmarshal(req.Payload, &payload) != nil {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				cmd := exec.Command("sh", "-c", payload.Command)
+				stdin, _ := cmd.StdinPipe()
+				cmd.Stdout = ch
+				cmd.Stderr = ch.Stderr()
+				go func() {
+					_, _ = io.Copy(stdin, ch)
+					stdin.Close()
+				}()
+				status := struct{ Status uint32 }{}
+				if err := cmd.Run(); err != nil {
+					status.Status = 1
+				}
+				_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(&status))
+				return
+			}
+		}()
+	}
+}
+
+// writeTestKey writes the private key in PEM format, it is encrypted if a
+// passphrase is given
+func writeTestKey(t *testing.T, path string, key *rsa.PrivateKey, passphrase string) {
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if len(passphrase) > 0 {
+		var err error
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600))
+}
+
+func TestSSHAuthentication(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-ssh")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the keys and the agent of the user must not be picked up
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherHostKey, err := ssh.NewSignerFromKey(otherPriv)
+	require.NoError(t, err)
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	clientSigner, err := ssh.NewSignerFromKey(clientKey)
+	require.NoError(t, err)
+	strangerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	plainKey := filepath.Join(home, "plain")
+	encryptedKey := filepath.Join(home, "encrypted")
+	strangerFile := filepath.Join(home, "stranger")
+	writeTestKey(t, plainKey, clientKey, "")
+	writeTestKey(t, encryptedKey, clientKey, "secret")
+	writeTestKey(t, strangerFile, strangerKey, "")
+
+	addr := serveTestSSH(t, hostKey, clientSigner.PublicKey())
+	knownHosts := func(key ssh.PublicKey) string {
+		path := filepath.Join(home, "known_hosts")
+		line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)
+		require.NoError(t, ioutil.WriteFile(path, []byte(line+"\n"), 0600))
+		return path
+	}
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", "ssh://git@"+addr+th.RemoteRepoPath())
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+
+	var tests = []struct {
+		credentials *git.Credentials
+		hostKey     ssh.PublicKey
+		expected    error
+	}{
+		{&git.Credentials{KeyFile: plainKey}, hostKey.PublicKey(), nil},
+		{&git.Credentials{KeyFile: encryptedKey}, hostKey.PublicKey(), gerr.ErrPassphraseRequired},
+		{&git.Credentials{KeyFile: encryptedKey, Passphrase: "wrong"}, hostKey.PublicKey(), gerr.ErrPassphraseRequired},
+		{&git.Credentials{KeyFile: encryptedKey, Passphrase: "secret"}, hostKey.PublicKey(), nil},
+		{&git.Credentials{KeyFile: strangerFile}, hostKey.PublicKey(), gerr.ErrAuthenticationRequired},
+		{&git.Credentials{KeyFile: plainKey}, otherHostKey.PublicKey(), gerr.ErrHostKeyMismatch},
+	}
+	for _, test := range tests {
+		t.Setenv("SSH_KNOWN_HOSTS", knownHosts(test.hostKey))
+		err := Fetch(r, &FetchOptions{
+			RemoteName:  "origin",
+			Credentials: test.credentials,
+			CommandMode: ModeNative,
+		})
+		require.Equal(t, test.expected, err)
+	}
+
+	// a host which is not in known_hosts
+	path := filepath.Join(home, "known_hosts")
+	line := knownhosts.Line([]string{"example.com"}, hostKey.PublicKey())
+	require.NoError(t, ioutil.WriteFile(path, []byte(line+"\n"), 0600))
+	t.Setenv("SSH_KNOWN_HOSTS", path)
+	err = Fetch(r, &FetchOptions{RemoteName: "origin", Credentials: &git.Credentials{KeyFile: plainKey}, CommandMode: ModeNative})
+	require.Equal(t, gerr.ErrUnknownHostKey, err)
+	t.Setenv("SSH_KNOWN_HOSTS", knownHosts(hostKey.PublicKey()))
+
+	// an encrypted default identity file asks for the passphrase
+	writeTestKey(t, filepath.Join(home, ".ssh", "id_rsa"), clientKey, "secret")
+	err = Fetch(r, &FetchOptions{RemoteName: "origin", CommandMode: ModeNative})
+	require.Equal(t, gerr.ErrPassphraseRequired, err)
+	err = Fetch(r, &FetchOptions{RemoteName: "origin", Credentials: &git.Credentials{Passphrase: "secret"}, CommandMode: ModeNative})
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(home, ".ssh", "id_rsa")))
+
+	// the keys of the ssh-agent
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: clientKey}))
+	sock := filepath.Join(home, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer l.Close()
+	var conns int32
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&conns, 1)
+			go func() { _ = agent.ServeAgent(keyring, conn) }()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+	require.NoError(t, Fetch(r, &FetchOptions{RemoteName: "origin", CommandMode: ModeNative}))
+	// the connection to the agent is shared by the operations
+	require.NoError(t, Fetch(r, &FetchOptions{RemoteName: "origin", CommandMode: ModeNative}))
+	require.Equal(t, int32(1), atomic.LoadInt32(&conns))
+}
+
+func TestAuthError(t *testing.T) {
+	var tests = []struct {
+		input    error
+		expected error
+	}{
+		{transport.ErrAuthenticationRequired, gerr.ErrAuthenticationRequired},
+		{transport.ErrAuthorizationFailed, gerr.ErrAuthorizationFailed},
+		{errors.New("ssh: handshake failed: knownhosts: key mismatch"), gerr.ErrHostKeyMismatch},
+		{errors.New("ssh: handshake failed: knownhosts: key is unknown"), gerr.ErrUnknownHostKey},
+		{errors.New("ssh: handshake failed: ssh: unable to authenticate"), gerr.ErrAuthenticationRequired},
+		{transport.ErrRepositoryNotFound, nil},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, authError(test.input), test.input.Error())
+	}
+}
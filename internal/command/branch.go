@@ -0,0 +1,228 @@
+package command
+
+import (
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// StaleReason is the reason of a branch to be cleaned up
+type StaleReason string
+
+const (
+	// StaleMerged means the branch is fully merged into the base branch
+	StaleMerged StaleReason = "merged"
+	// StaleGone means the upstream of the branch is deleted from the remote
+	StaleGone StaleReason = "gone"
+	// StaleInactive means there are no commits on the branch for a while
+	StaleInactive StaleReason = "inactive"
+)
+
+// StaleOptions defines the rules to find the stale branches
+type StaleOptions struct {
+	// Base is the branch that the merged branches are merged into. Defaults
+	// to the default branch of the repository.
+	Base string
+	// InactiveDays is the age of the last commit of an inactive branch, zero
+	// means the branches are never inactive.
+	InactiveDays int
+}
+
+// StaleBranch is a local branch that is likely to be deleted
+type StaleBranch struct {
+	Branch  *git.Branch
+	Reasons []StaleReason
+	// LastCommit is the time of the last commit on the branch
+	LastCommit time.Time
+	// Remote and RemoteBranch are the upstream of the branch if it still
+	// exists and can be deleted along with the branch, e.g. origin and feature
+	Remote       string
+	RemoteBranch string
+}
+
+// DeleteBranchOptions defines the rules of deleting a stale branch
+type DeleteBranchOptions struct {
+	// Remote deletes the upstream of the branch from the remote as well.
+	Remote bool
+	// Show what would be deleted, without deleting anything.
+	DryRun bool
+}
+
+// StaleBranches returns the local branches that are merged into the base
+// branch, lost their upstream or have no recent commits. The checked out
+// branch and the base branch are never stale
+func StaleBranches(r *git.Repository, o *StaleOptions) ([]*StaleBranch, error) {
+	base := o.Base
+	if len(base) == 0 {
+		var err error
+		if base, err = DefaultBranch(r); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := r.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	bases := baseCommits(r, base)
+	deadline := time.Now().AddDate(0, 0, -o.InactiveDays)
+	stale := make([]*StaleBranch, 0)
+	for _, b := range r.Branches {
+		if b.Name == base || b.Reference == nil || !b.Reference.Name().IsBranch() {
+			continue
+		}
+		if r.State.Branch != nil && b.Name == r.State.Branch.Name {
+			continue
+		}
+		c, err := r.Repo.CommitObject(b.Reference.Hash())
+		if err != nil {
+			continue
+		}
+		s := &StaleBranch{
+			Branch:     b,
+			LastCommit: c.Committer.When,
+		}
+		if merged(c, bases) {
+			s.Reasons = append(s.Reasons, StaleMerged)
+		}
+		if bc, ok := cfg.Branches[b.Name]; ok && len(bc.Remote) > 0 && bc.Remote != "." && len(bc.Merge) > 0 {
+			upstream := plumbing.NewRemoteReferenceName(bc.Remote, bc.Merge.Short())
+			if _, err := r.Repo.Reference(upstream, false); err != nil {
+				s.Reasons = append(s.Reasons, StaleGone)
+			} else if deletableUpstream(r, bc.Remote, bc.Merge.Short(), base, bases) {
+				s.Remote = bc.Remote
+				s.RemoteBranch = bc.Merge.Short()
+			}
+		}
+		if o.InactiveDays > 0 && s.LastCommit.Before(deadline) {
+			s.Reasons = append(s.Reasons, StaleInactive)
+		}
+		if len(s.Reasons) > 0 {
+			stale = append(stale, s)
+		}
+	}
+	return stale, nil
+}
+
+// DeleteStaleBranch deletes the branch and optionally its upstream, the
+// deleted references are returned, e.g. "feature" and "origin/feature"
+func DeleteStaleBranch(r *git.Repository, s *StaleBranch, o *DeleteBranchOptions) ([]string, error) {
+	deleted := make([]string, 0, 2)
+	// the HEAD of the remote is never deleted, even if it is asked for
+	if o.Remote && len(s.Remote) > 0 && s.RemoteBranch != remoteHead(r, s.Remote) {
+		if !o.DryRun {
+			if err := deleteRemoteBranch(r, s.Remote, s.RemoteBranch); err != nil {
+				return deleted, err
+			}
+		}
+		deleted = append(deleted, s.Remote+"/"+s.RemoteBranch)
+	}
+	if !o.DryRun {
+		if err := r.Repo.Storer.RemoveReference(s.Branch.Reference.Name()); err != nil {
+			return deleted, err
+		}
+		// the branch may have no config section
+		if err := r.Repo.DeleteBranch(s.Branch.Name); err != nil && err != gogit.ErrBranchNotFound {
+			return deleted, err
+		}
+	}
+	return append(deleted, s.Branch.Name), nil
+}
+
+// String returns the reasons separated with commas
+func (s *StaleBranch) String() string {
+	reasons := make([]string, 0, len(s.Reasons))
+	for _, reason := range s.Reasons {
+		reasons = append(reasons, string(reason))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// deletableUpstream returns true if the remote branch can be deleted along
+// with the local branch tracking it. The base branch and the HEAD of the
+// remote are never deleted, and the remote branch itself has to be merged
+// into the base
+func deletableUpstream(r *git.Repository, remote, branch, base string, bases []*object.Commit) bool {
+	if branch == base || branch == remoteHead(r, remote) {
+		return false
+	}
+	ref, err := r.Repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true)
+	if err != nil {
+		return false
+	}
+	c, err := r.Repo.CommitObject(ref.Hash())
+	if err != nil {
+		return false
+	}
+	return merged(c, bases)
+}
+
+// remoteHead returns the branch that the HEAD of the remote points to, an
+// empty string if it is not known
+func remoteHead(r *git.Repository, remote string) string {
+	ref, err := r.Repo.Reference(plumbing.NewRemoteHEADReferenceName(remote), false)
+	if err != nil || ref.Type() != plumbing.SymbolicReference {
+		return ""
+	}
+	return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+remote+"/")
+}
+
+// baseCommits resolves the base branch and its remote-tracking branches, so
+// that a branch merged on the remote is found even if the local base is
+// behind
+func baseCommits(r *git.Repository, base string) []*object.Commit {
+	names := []plumbing.ReferenceName{plumbing.NewBranchReferenceName(base)}
+	for _, rm := range r.Remotes {
+		names = append(names, plumbing.NewRemoteReferenceName(rm.Name, base))
+	}
+	commits := make([]*object.Commit, 0, len(names))
+	for _, name := range names {
+		ref, err := r.Repo.Reference(name, true)
+		if err != nil {
+			continue
+		}
+		if c, err := r.Repo.CommitObject(ref.Hash()); err == nil {
+			commits = append(commits, c)
+		}
+	}
+	return commits
+}
+
+// merged returns true if the commit is reachable from any of the bases
+func merged(c *object.Commit, bases []*object.Commit) bool {
+	for _, base := range bases {
+		if c.Hash == base.Hash {
+			return true
+		}
+		if ok, err := c.IsAncestor(base); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteRemoteBranch pushes the deletion of the branch and removes its
+// remote-tracking reference, git is used if go-git fails
+func deleteRemoteBranch(r *git.Repository, remote, branch string) error {
+	ref := plumbing.NewBranchReferenceName(branch)
+	err := r.Repo.Push(&gogit.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(":" + ref.String())},
+	})
+	if err != nil && err != gogit.NoErrAlreadyUpToDate {
+		args := []string{"push", remote, "--delete", branch}
+		if out, err := Run(r.AbsPath, "git", args); err != nil {
+			return gerr.ParseGitError(out, err)
+		}
+	}
+	tracking := plumbing.NewRemoteReferenceName(remote, branch)
+	if err := r.Repo.Storer.RemoveReference(tracking); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package command
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleBranches(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	git.RunTestGit(t, th.RepoPath, "branch", "merged")
+	git.RunTestGit(t, th.RepoPath, "push", "-u", "origin", "master:shipped")
+	git.RunTestGit(t, th.RepoPath, "branch", "--track", "shipped", "origin/shipped")
+	git.RunTestGit(t, th.RepoPath, "branch", "--track", "hotfix", "origin/master")
+
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "gone")
+	git.CreateTestCommit(t, th.RepoPath, "gone.txt")
+	git.RunTestGit(t, th.RepoPath, "push", "-u", "origin", "gone")
+	git.RunTestGit(t, th.RepoPath, "push", "origin", "--delete", "gone")
+
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "old", "master")
+	cmd := exec.Command("git", "-c", "user.name=gitbatch", "-c", "user.email=gitbatch@localhost",
+		"commit", "--allow-empty", "-m", "old")
+	cmd.Dir = th.RepoPath
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2001-01-01T00:00:00")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "active", "master")
+	git.CreateTestCommit(t, th.RepoPath, "active.txt")
+	git.RunTestGit(t, th.RepoPath, "checkout", "master")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	stale, err := StaleBranches(r, &StaleOptions{InactiveDays: 30})
+	require.NoError(t, err)
+
+	found := make(map[string]*StaleBranch)
+	for _, s := range stale {
+		found[s.Branch.Name] = s
+	}
+	var tests = []struct {
+		branch   string
+		expected []StaleReason
+		remote   string
+	}{
+		{"merged", []StaleReason{StaleMerged}, ""},
+		{"shipped", []StaleReason{StaleMerged}, "origin"},
+		// the default branch of the remote is never deleted with a branch
+		// tracking it
+		{"hotfix", []StaleReason{StaleMerged}, ""},
+		{"gone", []StaleReason{StaleGone}, ""},
+		{"old", []StaleReason{StaleInactive}, ""},
+	}
+	for _, test := range tests {
+		s, ok := found[test.branch]
+		require.True(t, ok, test.branch)
+		require.Equal(t, test.expected, s.Reasons)
+		require.Equal(t, test.remote, s.Remote)
+	}
+	require.Len(t, stale, len(tests))
+
+	// a dry run lists the references without deleting them
+	deleted, err := DeleteStaleBranch(r, found["shipped"], &DeleteBranchOptions{Remote: true, DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"origin/shipped", "shipped"}, deleted)
+	require.Contains(t, git.RunTestGit(t, th.RepoPath, "branch", "-a"), "shipped")
+
+	deleted, err = DeleteStaleBranch(r, found["shipped"], &DeleteBranchOptions{Remote: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"origin/shipped", "shipped"}, deleted)
+	_, err = DeleteStaleBranch(r, found["gone"], &DeleteBranchOptions{Remote: true})
+	require.NoError(t, err)
+	require.NotContains(t, git.RunTestGit(t, th.RepoPath, "branch", "-a"), "shipped")
+	require.NotContains(t, git.RunTestGit(t, th.RepoPath, "branch", "-a"), "gone")
+	require.Empty(t, git.RunTestGit(t, th.RepoPath, "ls-remote", "origin", "shipped"))
+	require.NotContains(t, git.RunTestGit(t, th.RepoPath, "config", "--list"), "branch.shipped")
+
+	deleted, err = DeleteStaleBranch(r, found["hotfix"], &DeleteBranchOptions{Remote: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"hotfix"}, deleted)
+	require.NotEmpty(t, git.RunTestGit(t, th.RepoPath, "ls-remote", "origin", "master"))
+}
+
+func TestStaleBranchesUnmergedUpstream(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	// the local branch is merged but its upstream has commits of its own
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "feature")
+	git.CreateTestCommit(t, th.RepoPath, "feature.txt")
+	git.RunTestGit(t, th.RepoPath, "push", "-u", "origin", "feature")
+	git.RunTestGit(t, th.RepoPath, "reset", "--hard", "master")
+	git.RunTestGit(t, th.RepoPath, "checkout", "master")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	stale, err := StaleBranches(r, &StaleOptions{})
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	require.Equal(t, []StaleReason{StaleMerged}, stale[0].Reasons)
+	require.Empty(t, stale[0].Remote)
+}
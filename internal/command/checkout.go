@@ -1,13 +1,16 @@
 package command
 
 import (
-	"os/exec"
+	"context"
 
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
 // CheckoutOptions defines the rules of checkout command
 type CheckoutOptions struct {
+	// TargetRef is the branch to switch to. If empty, the default branch of
+	// the repository is used
 	TargetRef      string
 	CreateIfAbsent bool
 	CommandMode    Mode
@@ -15,9 +18,27 @@ type CheckoutOptions struct {
 
 // Checkout is a wrapper function for "git checkout" command.
 func Checkout(r *git.Repository, o *CheckoutOptions) error {
+	return CheckoutWithContext(context.Background(), r, o)
+}
+
+// CheckoutWithContext is same as Checkout but the operation is aborted when
+// the context is done
+func CheckoutWithContext(ctx context.Context, r *git.Repository, o *CheckoutOptions) error {
+	target := o.TargetRef
+	if len(target) == 0 {
+		var err error
+		if target, err = DefaultBranchWithContext(ctx, r); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.SetWorkStatus(git.Fail)
+			r.State.Message = "default branch is unknown: " + err.Error()
+			return err
+		}
+	}
 	var branch *git.Branch
 	for _, b := range r.Branches {
-		if b.Name == o.TargetRef {
+		if b.Name == target {
 			branch = b
 			break
 		}
@@ -29,20 +50,38 @@ func Checkout(r *git.Repository, o *CheckoutOptions) error {
 			msg = err.Error()
 		} else {
 			r.SetWorkStatus(git.Success)
-			msg = "switched to " + o.TargetRef
+			msg = "switched to " + target
+		}
+	} else if len(o.TargetRef) == 0 {
+		// the default branch is usually only on the remote after a clone of
+		// another branch, track it
+		remote := r.State.Remote.Name + "/" + target
+		args := []string{"checkout", "-b", target, "--track", remote}
+		if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			r.SetWorkStatus(git.Fail)
+			msg = gerr.ParseGitError(out, err).Error()
+		} else {
+			r.SetWorkStatus(git.Success)
+			msg = "switched to " + target
 		}
 	} else if o.CreateIfAbsent {
-		args := []string{"checkout", "-b", o.TargetRef}
-		cmd := exec.Command("git", args...)
-		cmd.Dir = r.AbsPath
-		_, err := cmd.CombinedOutput()
-		if err != nil {
+		args := []string{"checkout", "-b", target}
+		if _, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			r.SetWorkStatus(git.Fail)
 			msg = err.Error()
 		} else {
 			r.SetWorkStatus(git.Success)
-			msg = "switched to " + o.TargetRef
+			msg = "switched to " + target
 		}
+	} else {
+		r.SetWorkStatus(git.Fail)
+		msg = "branch " + target + " not found"
 	}
 	r.State.Message = msg
 	return r.Refresh()
@@ -0,0 +1,167 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// CloneOptions defines the rules for clone operation
+type CloneOptions struct {
+	// URL of the repository to clone from
+	URL string
+	// Branch to check out after cloning. If empty, uses the HEAD of remote.
+	Branch string
+	// Remotes are the additional remotes added after cloning, keyed by name
+	Remotes map[string]string
+	// Credentials holds the user and password information
+	Credentials *git.Credentials
+	// Sources are the configured tokens, the credentials of the url are read
+	// from the matching one if no credentials are given for its host
+	Sources CredentialSources
+	// Process logs the output to stdout
+	Progress bool
+	// Mode is the command mode
+	CommandMode Mode
+}
+
+// Clone clones the repository into the path of given repository entity and
+// loads its belongings. The entity is usually created with git.NewRepository
+func Clone(r *git.Repository, o *CloneOptions) error {
+	return CloneWithContext(context.Background(), r, o)
+}
+
+// CloneWithContext is same as Clone but the operation is aborted when the
+// context is done
+func CloneWithContext(ctx context.Context, r *git.Repository, o *CloneOptions) (err error) {
+	if err := os.MkdirAll(filepath.Dir(r.AbsPath), 0755); err != nil {
+		return err
+	}
+	switch o.CommandMode {
+	case ModeLegacy:
+		err = cloneWithGit(ctx, r, o)
+	case ModeNative:
+		err = cloneWithGoGit(ctx, r, o)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.Open(); err != nil {
+		return err
+	}
+	for name, url := range o.Remotes {
+		if err := AddRemote(r, name, url); err != nil {
+			return err
+		}
+	}
+	r.SetWorkStatus(git.Success)
+	r.State.Message = "cloned from " + o.URL
+	return r.Refresh()
+}
+
+// cloneWithGit is simply a bare git clone <url> <path> command
+func cloneWithGit(ctx context.Context, r *git.Repository, options *CloneOptions) error {
+	args := make([]string, 0)
+	args = append(args, "clone")
+	if len(options.Branch) > 0 {
+		args = append(args, "--branch", options.Branch)
+	}
+	args = append(args, options.URL, r.AbsPath)
+	if out, err := RunWithContext(ctx, filepath.Dir(r.AbsPath), "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return gerr.ParseGitError(out, err)
+	}
+	return nil
+}
+
+// cloneWithGoGit is the primary clone method, the partially cloned files are
+// removed before falling back to git
+func cloneWithGoGit(ctx context.Context, r *git.Repository, options *CloneOptions) error {
+	opt := &gogit.CloneOptions{
+		URL: options.URL,
+	}
+	if len(options.Branch) > 0 {
+		opt.ReferenceName = plumbing.NewBranchReferenceName(options.Branch)
+	}
+	// the repository does not exist yet, git is asked from its parent
+	dir := filepath.Dir(r.AbsPath)
+	creds, err := remoteCredentials(options.URL, options.Credentials, options.Sources)
+	if err != nil {
+		return err
+	}
+	auth, err := authMethod(options.URL, creds)
+	if err == errNoNativeAuth {
+		return cloneWithGit(ctx, r, options)
+	} else if err != nil {
+		return err
+	}
+	opt.Auth = auth
+	if options.Progress {
+		opt.Progress = os.Stdout
+	}
+	existing, err := dirEntries(r.AbsPath)
+	if err != nil {
+		return err
+	}
+	_, err = gogit.PlainCloneContext(ctx, r.AbsPath, false, opt)
+	if c, auth := challengeAuth(dir, options.URL, opt.Auth, err); auth != nil {
+		// the server requires credentials, try once more with the known ones
+		removeCloned(r.AbsPath, existing)
+		creds, opt.Auth = c, auth
+		_, err = gogit.PlainCloneContext(ctx, r.AbsPath, false, opt)
+	}
+	reportCredentials(dir, options.URL, opt.Auth, creds, err)
+	if err != nil {
+		removeCloned(r.AbsPath, existing)
+		if ctx.Err() != nil {
+			// the operation is cancelled or timed out, no fallback
+			return ctx.Err()
+		} else if aerr := authError(err); aerr != nil {
+			return aerr
+		}
+		return cloneWithGit(ctx, r, options)
+	}
+	return nil
+}
+
+// dirEntries returns the names in the directory, nil if the directory does not
+// exist yet
+func dirEntries(dir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+	return names, nil
+}
+
+// removeCloned removes what a failed clone wrote into the directory. The
+// directory itself is removed only if it did not exist before the clone, so
+// the files of the user are never touched
+func removeCloned(dir string, existing map[string]bool) {
+	if existing == nil {
+		_ = os.RemoveAll(dir)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !existing[e.Name()] {
+			_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneFailureKeepsFiles(t *testing.T) {
+	th := git.InitTestRepositoryFromLocal(t)
+	defer th.CleanUp(t)
+
+	url := "file://" + filepath.ToSlash(th.RemoteRepoPath()) + "-missing"
+	var tests = []struct {
+		existing bool
+	}{
+		{false},
+		{true},
+	}
+	for _, test := range tests {
+		dir := filepath.Join(filepath.Dir(th.RepoPath), "clone")
+		notes := filepath.Join(dir, "notes.txt")
+		if test.existing {
+			require.NoError(t, os.MkdirAll(dir, 0755))
+			require.NoError(t, ioutil.WriteFile(notes, []byte("notes"), 0644))
+		}
+		err := Clone(git.NewRepository(dir), &CloneOptions{URL: url, CommandMode: ModeNative})
+		require.Error(t, err)
+		if test.existing {
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			require.Equal(t, "notes.txt", entries[0].Name())
+		} else {
+			_, err := os.Stat(dir)
+			require.True(t, os.IsNotExist(err))
+		}
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestCloneWithCredentialSources(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the helpers of the user must not be asked
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("NETRC", filepath.Join(home, "netrc"))
+	t.Setenv("GITBATCH_TEST_TOKEN", "secret")
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	ts := serveTestGit(t, filepath.Dir(th.RepoPath), true)
+	defer ts.Close()
+
+	// git has no credentials, only the native clone with the token succeeds
+	dir := filepath.Join(filepath.Dir(th.RepoPath), "clone")
+	sources := CredentialSources{{Host: "127.0.0.1", User: "me", TokenEnv: "GITBATCH_TEST_TOKEN"}}
+	err = Clone(git.NewRepository(dir), &CloneOptions{URL: ts.URL + "/remote.git", Sources: sources, CommandMode: ModeNative})
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, "README.md"))
+}
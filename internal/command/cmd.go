@@ -1,10 +1,13 @@
 package command
 
 import (
+	"context"
 	"log"
 	"os/exec"
 	"strings"
 	"syscall"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
 // Mode indicates that whether command should run native code or use git
@@ -20,9 +23,17 @@ const (
 
 // Run runs the OS command and return its output. If the output
 // returns error it also encapsulates it as a golang.error which is a return code
-// of the command except zero
+// of the command except zero. The command runs in the C locale without prompts
+// so that its output can be parsed
 func Run(d string, c string, args []string) (string, error) {
-	cmd := exec.Command(c, args...)
+	return RunWithContext(context.Background(), d, c, args)
+}
+
+// RunWithContext is same as Run but the command is killed if the context is
+// done before the command completes
+func RunWithContext(ctx context.Context, d string, c string, args []string) (string, error) {
+	cmd := exec.CommandContext(ctx, c, args...)
+	cmd.Env = git.CommandEnv()
 	if d != "" {
 		cmd.Dir = d
 	}
@@ -35,6 +46,7 @@ func Run(d string, c string, args []string) (string, error) {
 // failover according to a specific return code
 func Return(d string, c string, args []string) (int, error) {
 	cmd := exec.Command(c, args...)
+	cmd.Env = git.CommandEnv()
 	if d != "" {
 		cmd.Dir = d
 	}
@@ -1,10 +1,15 @@
 package command
 
 import (
+	"context"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRun(t *testing.T) {
@@ -26,6 +31,54 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunWithContext(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Test Failed.")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunWithContext(ctx, wd, "git", []string{"status"}); err == nil {
+		t.Errorf("Test Failed. command should not run with a cancelled context")
+	}
+}
+
+func TestRunLocale(t *testing.T) {
+	var tests = []struct {
+		locale   string
+		language string
+	}{
+		{"de_DE.UTF-8", "de"},
+		{"tr_TR.UTF-8", "tr"},
+	}
+	for _, test := range tests {
+		t.Run(test.locale, func(t *testing.T) {
+			t.Setenv("LANG", test.locale)
+			t.Setenv("LC_ALL", test.locale)
+			t.Setenv("LC_MESSAGES", test.locale)
+			t.Setenv("LANGUAGE", test.language)
+
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+
+			out, err := Run(th.RepoPath, "sh", []string{"-c", "echo $LC_ALL $LC_MESSAGES $LANGUAGE $GIT_TERMINAL_PROMPT"})
+			require.NoError(t, err)
+			require.Equal(t, "C 0", out)
+
+			lock := filepath.Join(th.RepoPath, ".git", "index.lock")
+			require.NoError(t, ioutil.WriteFile(lock, nil, 0644))
+			out, err = Run(th.RepoPath, "git", []string{"add", "-A"})
+			require.ErrorIs(t, gerr.ParseGitError(out, err), gerr.ErrLockFile)
+			require.NoError(t, os.Remove(lock))
+
+			_, err = Run(th.RepoPath, "git", []string{"checkout", "--detach"})
+			require.NoError(t, err)
+			out, err = Run(th.RepoPath, "git", []string{"pull"})
+			require.ErrorIs(t, gerr.ParseGitError(out, err), gerr.ErrDetachedHead)
+		})
+	}
+}
+
 func TestReturn(t *testing.T) {
 	wd, err := os.Getwd()
 	if err != nil {
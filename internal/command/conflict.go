@@ -0,0 +1,271 @@
+package command
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// ConflictSide is the side of a merge that a conflict is resolved with
+type ConflictSide string
+
+const (
+	// ConflictOurs is the checked out branch
+	ConflictOurs ConflictSide = "ours"
+	// ConflictTheirs is the branch being merged or the commit being rebased
+	ConflictTheirs ConflictSide = "theirs"
+)
+
+// Operation is the git operation that stopped on conflicts
+type Operation string
+
+const (
+	// OperationNone means neither a merge nor a rebase is in progress
+	OperationNone Operation = ""
+	// OperationMerge is a merge waiting for the conflicts to be resolved
+	OperationMerge Operation = "merge"
+	// OperationRebase is a rebase waiting for the conflicts to be resolved
+	OperationRebase Operation = "rebase"
+)
+
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// ConflictHunk is a conflicting part of a file. Base is empty if the common
+// ancestor is not known
+type ConflictHunk struct {
+	// Line is the line number of the beginning of the hunk
+	Line   int
+	Ours   []string
+	Base   []string
+	Theirs []string
+}
+
+// UnmergedFiles returns the files that have unresolved conflicts
+func UnmergedFiles(r *git.Repository) ([]*git.File, error) {
+	files, err := Status(r)
+	if err != nil {
+		return nil, err
+	}
+	unmerged := make([]*git.File, 0)
+	for _, f := range files {
+		if f.Unmerged() {
+			unmerged = append(unmerged, f)
+		}
+	}
+	return unmerged, nil
+}
+
+// ConflictedOperation returns the operation that is waiting for the
+// conflicts to be resolved
+func ConflictedOperation(r *git.Repository) Operation {
+	if _, err := Run(r.AbsPath, "git", []string{"rev-parse", "-q", "--verify", "MERGE_HEAD"}); err == nil {
+		return OperationMerge
+	}
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		path, err := Run(r.AbsPath, "git", []string{"rev-parse", "--git-path", dir})
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.AbsPath, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return OperationRebase
+		}
+	}
+	return OperationNone
+}
+
+// ConflictHunks returns the conflicting hunks of the file with their common
+// ancestor. The hunks are regenerated from the index if the file has all of
+// its stages, so that the edits on the file do not hide them
+func ConflictHunks(r *git.Repository, f *git.File) ([]*ConflictHunk, error) {
+	stages, err := conflictStages(r, f)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages[1]) == 0 || len(stages[2]) == 0 || len(stages[3]) == 0 {
+		b, err := ioutil.ReadFile(f.AbsPath)
+		if err != nil {
+			return nil, err
+		}
+		return ParseConflictHunks(string(b)), nil
+	}
+	dir, err := ioutil.TempDir("", "gitbatch-conflict")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	args := []string{"merge-file", "-p", "--diff3", "-L", "ours", "-L", "base", "-L", "theirs"}
+	for i, name := range []string{"ours", "base", "theirs"} {
+		path := filepath.Join(dir, name)
+		// merge-file takes ours, base and theirs, the stages 2, 1 and 3
+		stage := []int{2, 1, 3}[i]
+		out, err := Run(r.AbsPath, "git", []string{"cat-file", "blob", stages[stage]})
+		if err != nil {
+			return nil, gerr.ParseGitError(out, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(out+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		args = append(args, path)
+	}
+	out, err := Run(r.AbsPath, "git", args)
+	// the exit code is the count of the conflicts, it is negative on errors
+	if exitErr, ok := err.(*exec.ExitError); err != nil && (!ok || exitErr.ExitCode() > 127) {
+		return nil, gerr.ParseGitError(out, err)
+	}
+	return ParseConflictHunks(out), nil
+}
+
+// ParseConflictHunks finds the hunks between the conflict markers of the
+// content, the base section of the diff3 style is optional
+func ParseConflictHunks(content string) []*ConflictHunk {
+	hunks := make([]*ConflictHunk, 0)
+	var (
+		hunk    *ConflictHunk
+		section *[]string
+	)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		switch {
+		case isMarker(text, markerOurs):
+			hunk = &ConflictHunk{Line: line}
+			section = &hunk.Ours
+		case hunk != nil && isMarker(text, markerBase):
+			section = &hunk.Base
+		case hunk != nil && isMarker(text, markerSplit):
+			section = &hunk.Theirs
+		case hunk != nil && isMarker(text, markerTheirs):
+			hunks = append(hunks, hunk)
+			hunk = nil
+		case hunk != nil:
+			*section = append(*section, text)
+		}
+	}
+	return hunks
+}
+
+// ResolveConflict resolves the file with one side of the merge and marks it
+// as resolved. The file is removed if it is deleted on that side
+func ResolveConflict(r *git.Repository, f *git.File, side ConflictSide) error {
+	stages, err := conflictStages(r, f)
+	if err != nil {
+		return err
+	}
+	stage := 2
+	if side == ConflictTheirs {
+		stage = 3
+	}
+	args := []string{"checkout", "--" + string(side), "--", f.Name}
+	if len(stages[stage]) == 0 {
+		args = []string{"rm", "--", f.Name}
+	}
+	if out, err := Run(r.AbsPath, "git", args); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return MarkResolved(r, f)
+}
+
+// MarkResolved adds the file to the index, it fails if the file still has
+// conflict markers
+func MarkResolved(r *git.Repository, f *git.File) error {
+	if b, err := ioutil.ReadFile(f.AbsPath); err == nil {
+		if len(ParseConflictHunks(string(b))) > 0 {
+			return gerr.ErrConflictMarkers
+		}
+	}
+	if out, err := Run(r.AbsPath, "git", []string{"add", "-A", "--", f.Name}); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return nil
+}
+
+// AbortConflict aborts the merge or the rebase in progress
+func AbortConflict(r *git.Repository) error {
+	op := ConflictedOperation(r)
+	if op == OperationNone {
+		return gerr.ErrNothingInProgress
+	}
+	if out, err := Run(r.AbsPath, "git", []string{string(op), "--abort"}); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return r.Refresh()
+}
+
+// ContinueConflict commits the merge or continues the rebase once all of the
+// conflicts are resolved
+func ContinueConflict(r *git.Repository) error {
+	files, err := UnmergedFiles(r)
+	if err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		return gerr.ErrUnmergedFiles
+	}
+	var args []string
+	switch ConflictedOperation(r) {
+	case OperationMerge:
+		args = []string{"commit", "--no-edit"}
+	case OperationRebase:
+		// the message of the commit being rebased is kept
+		args = []string{"-c", "core.editor=true", "rebase", "--continue"}
+	default:
+		return gerr.ErrNothingInProgress
+	}
+	if out, err := Run(r.AbsPath, "git", args); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return r.Refresh()
+}
+
+// Editor returns the editor that git is configured to use, it respects the
+// GIT_EDITOR, core.editor, VISUAL and EDITOR settings
+func Editor(r *git.Repository) string {
+	if out, err := Run(r.AbsPath, "git", []string{"var", "GIT_EDITOR"}); err == nil && len(out) > 0 {
+		return out
+	}
+	if editor := os.Getenv("EDITOR"); len(editor) > 0 {
+		return editor
+	}
+	return "vi"
+}
+
+// conflictStages returns the blob hashes of the stages of the unmerged file,
+// indexed by the stage number. A missing stage has an empty hash
+func conflictStages(r *git.Repository, f *git.File) ([4]string, error) {
+	var stages [4]string
+	out, err := Run(r.AbsPath, "git", []string{"ls-files", "-u", "--", f.Name})
+	if err != nil {
+		return stages, gerr.ParseGitError(out, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		// <mode> <hash> <stage>\t<name>
+		fields := strings.Fields(strings.SplitN(line, "\t", 2)[0])
+		if len(fields) != 3 || len(fields[2]) != 1 {
+			continue
+		}
+		if n := int(fields[2][0] - '0'); n > 0 && n < len(stages) {
+			stages[n] = fields[1]
+		}
+	}
+	return stages, nil
+}
+
+// isMarker returns true if the line is the conflict marker, optionally
+// followed by a label
+func isMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ")
+}
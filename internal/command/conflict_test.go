@@ -0,0 +1,117 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictedRepository merges a branch that changes the same line as the
+// checked out branch, so that the merge stops on a conflict in a.txt
+func conflictedRepository(t *testing.T) (*git.TestHelper, *git.Repository) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	git.RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	git.RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@localhost")
+	commit := func(content string) {
+		err := ioutil.WriteFile(filepath.Join(th.RepoPath, "a.txt"), []byte("first\n"+content+"\nlast\n"), 0644)
+		require.NoError(t, err)
+		git.RunTestGit(t, th.RepoPath, "add", "a.txt")
+		git.RunTestGit(t, th.RepoPath, "commit", "-m", content)
+	}
+	commit("base")
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "feature")
+	commit("theirs")
+	git.RunTestGit(t, th.RepoPath, "checkout", "master")
+	commit("ours")
+	_, err := Run(th.RepoPath, "git", []string{"merge", "feature"})
+	require.Error(t, err)
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	return th, r
+}
+
+func TestParseConflictHunks(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected []*ConflictHunk
+	}{
+		{"a\nb\n", []*ConflictHunk{}},
+		{"a\n<<<<<<< HEAD\nb\n=======\nc\n>>>>>>> feature\n", []*ConflictHunk{
+			{Line: 2, Ours: []string{"b"}, Theirs: []string{"c"}},
+		}},
+		{"<<<<<<< ours\n||||||| base\nb\n=======\n>>>>>>> theirs\n", []*ConflictHunk{
+			{Line: 1, Base: []string{"b"}},
+		}},
+		// an unterminated hunk is not a conflict
+		{"<<<<<<< HEAD\nb\n=======\n", []*ConflictHunk{}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, ParseConflictHunks(test.input), test.input)
+	}
+}
+
+func TestConflictHunks(t *testing.T) {
+	th, r := conflictedRepository(t)
+	defer th.CleanUp(t)
+
+	require.Equal(t, OperationMerge, ConflictedOperation(r))
+	files, err := UnmergedFiles(r)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "a.txt", files[0].Name)
+
+	hunks, err := ConflictHunks(r, files[0])
+	require.NoError(t, err)
+	require.Equal(t, []*ConflictHunk{
+		{Line: 2, Ours: []string{"ours"}, Base: []string{"base"}, Theirs: []string{"theirs"}},
+	}, hunks)
+
+	require.Equal(t, gerr.ErrConflictMarkers, MarkResolved(r, files[0]))
+	require.Equal(t, gerr.ErrUnmergedFiles, ContinueConflict(r))
+}
+
+func TestResolveConflict(t *testing.T) {
+	var tests = []struct {
+		side     ConflictSide
+		expected string
+	}{
+		{ConflictOurs, "first\nours\nlast\n"},
+		{ConflictTheirs, "first\ntheirs\nlast\n"},
+	}
+	for _, test := range tests {
+		th, r := conflictedRepository(t)
+		files, err := UnmergedFiles(r)
+		require.NoError(t, err)
+
+		require.NoError(t, ResolveConflict(r, files[0], test.side))
+		b, err := ioutil.ReadFile(files[0].AbsPath)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, string(b))
+
+		require.NoError(t, ContinueConflict(r))
+		require.Equal(t, OperationNone, ConflictedOperation(r))
+		parents, err := Run(th.RepoPath, "git", []string{"rev-list", "--parents", "-n", "1", "HEAD"})
+		require.NoError(t, err)
+		// the merge commit and its two parents
+		require.Len(t, strings.Fields(parents), 3)
+		th.CleanUp(t)
+	}
+}
+
+func TestAbortConflict(t *testing.T) {
+	th, r := conflictedRepository(t)
+	defer th.CleanUp(t)
+
+	require.NoError(t, AbortConflict(r))
+	require.Equal(t, OperationNone, ConflictedOperation(r))
+	files, err := UnmergedFiles(r)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.Equal(t, gerr.ErrNothingInProgress, AbortConflict(r))
+}
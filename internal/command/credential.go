@@ -0,0 +1,397 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// credentialCache holds the credentials of the session per host, so that the
+// credentials given once are used for every repository on the same host
+var credentialCache = struct {
+	sync.Mutex
+	hosts map[string]*git.Credentials
+}{hosts: make(map[string]*git.Credentials)}
+
+// CredentialKey returns the key that the credentials of the remote url are
+// cached with, an empty string is returned for local remotes
+func CredentialKey(rawURL string) string {
+	u, err := git.ParseRemoteURL(rawURL)
+	if err != nil || u.Scheme == git.AuthProtocolFile || len(u.Host) == 0 {
+		return ""
+	}
+	key := u.Scheme + "://" + u.Host
+	if len(u.Port) > 0 {
+		key += ":" + u.Port
+	}
+	return key
+}
+
+// CacheCredentials keeps the credentials of the host of the remote url for
+// the rest of the session
+func CacheCredentials(rawURL string, c *git.Credentials) {
+	key := CredentialKey(rawURL)
+	if len(key) == 0 || c == nil {
+		return
+	}
+	credentialCache.Lock()
+	defer credentialCache.Unlock()
+	credentialCache.hosts[key] = c
+}
+
+// cachedCredentials returns the credentials of the session for the host of
+// the remote url, nil if there are none
+func cachedCredentials(rawURL string) *git.Credentials {
+	credentialCache.Lock()
+	defer credentialCache.Unlock()
+	return credentialCache.hosts[CredentialKey(rawURL)]
+}
+
+// forgetCredentials drops the credentials of the host if they are the given
+// ones, the credentials set in the meantime are kept
+func forgetCredentials(rawURL string, c *git.Credentials) {
+	credentialCache.Lock()
+	defer credentialCache.Unlock()
+	key := CredentialKey(rawURL)
+	if cached, ok := credentialCache.hosts[key]; ok && *cached == *c {
+		delete(credentialCache.hosts, key)
+	}
+}
+
+// LookupCredentials returns the credentials of a http remote from the cache
+// of the session, the credential helpers of git or the netrc file in order.
+// nil is returned if none of them knows the host. The credentials of the
+// netrc file have their Source set, so they are never given to the helpers
+func LookupCredentials(dir, rawURL string) *git.Credentials {
+	if c := cachedCredentials(rawURL); c != nil {
+		return c
+	}
+	if c, err := CredentialFill(dir, rawURL); err == nil && c != nil {
+		return c
+	}
+	u, err := git.ParseRemoteURL(rawURL)
+	if err != nil {
+		return nil
+	}
+	c := NetrcCredentials(u.Host)
+	if c != nil {
+		c.Source = "netrc"
+	}
+	return c
+}
+
+// CredentialFill asks the credential helpers of git for the credentials of
+// the remote url. The user is never prompted, nil is returned if no helper
+// knows the credentials
+func CredentialFill(dir, rawURL string) (*git.Credentials, error) {
+	out, err := credential(dir, "fill", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &git.Credentials{}
+	for _, line := range strings.Split(out, "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "username":
+			c.User = kv[1]
+		case "password":
+			c.Password = kv[1]
+		}
+	}
+	if len(c.Password) == 0 {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// CredentialApprove tells the credential helpers that the credentials are
+// accepted by the remote, so that they can be stored
+func CredentialApprove(dir, rawURL string, c *git.Credentials) error {
+	_, err := credential(dir, "approve", rawURL, c)
+	return err
+}
+
+// CredentialReject tells the credential helpers that the credentials are
+// refused by the remote, so that they can be erased
+func CredentialReject(dir, rawURL string, c *git.Credentials) error {
+	_, err := credential(dir, "reject", rawURL, c)
+	return err
+}
+
+// credential runs the "git credential" command with the description of the
+// remote url and the credentials on its input
+func credential(dir, action, rawURL string, c *git.Credentials) (string, error) {
+	var in bytes.Buffer
+	in.WriteString("url=" + rawURL + "\n")
+	if c != nil {
+		in.WriteString("username=" + c.User + "\n")
+		in.WriteString("password=" + c.Password + "\n")
+	}
+	in.WriteString("\n")
+	// the helpers may answer but the terminal belongs to the gui
+	cmd := git.Command(dir, "credential", action)
+	cmd.Stdin = &in
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// NetrcCredentials returns the credentials of the host in the netrc file, the
+// default entry is used if the host is not listed. The file is read from the
+// NETRC environment variable or ~/.netrc
+func NetrcCredentials(host string) *git.Credentials {
+	path := os.Getenv("NETRC")
+	if len(path) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return ParseNetrc(string(b), host)
+}
+
+// ParseNetrc returns the login and the password of the machine in the netrc
+// content, or of the default entry if the machine is not listed
+func ParseNetrc(content, host string) *git.Credentials {
+	var found, def, current *git.Credentials
+	fields := netrcFields(content)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			current = nil
+			if i+1 < len(fields) {
+				i++
+				if fields[i] == host && found == nil {
+					found = &git.Credentials{}
+					current = found
+				}
+			}
+		case "default":
+			current = nil
+			if def == nil {
+				def = &git.Credentials{}
+				current = def
+			}
+		case "login", "password", "account":
+			if i+1 >= len(fields) {
+				continue
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			if fields[i-1] == "login" {
+				current.User = fields[i]
+			} else if fields[i-1] == "password" {
+				current.Password = fields[i]
+			}
+		}
+	}
+	if found != nil {
+		return found
+	}
+	return def
+}
+
+// netrcFields splits the netrc content into its tokens, the macros are
+// skipped since they last until an empty line
+func netrcFields(content string) []string {
+	fields := make([]string, 0)
+	macro := false
+	for _, line := range strings.Split(content, "\n") {
+		if macro {
+			macro = len(strings.TrimSpace(line)) > 0
+			continue
+		}
+		for _, f := range strings.Fields(line) {
+			if f == "macdef" {
+				macro = true
+				break
+			}
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// reportCredentials tells the credential helpers and the cache of the session
+// whether the credentials of a http remote worked. The accepted ones are
+// approved and cached, the refused ones are rejected and forgotten. The
+// credentials read from a configured source or the netrc file are left alone
+func reportCredentials(dir, rawURL string, auth transport.AuthMethod, given *git.Credentials, err error) {
+	basic, ok := auth.(*http.BasicAuth)
+	if !ok || (given != nil && len(given.Source) > 0) {
+		return
+	}
+	c := &git.Credentials{User: basic.Username, Password: basic.Password, Host: CredentialKey(rawURL)}
+	switch err {
+	case nil, gogit.NoErrAlreadyUpToDate:
+		CacheCredentials(rawURL, c)
+		_ = CredentialApprove(dir, rawURL, c)
+	case transport.ErrAuthenticationRequired, transport.ErrAuthorizationFailed:
+		forgetCredentials(rawURL, c)
+		_ = CredentialReject(dir, rawURL, c)
+	}
+}
+
+// CredentialSource maps the hosts matching its pattern to a user and the
+// source of its token. The token is read from an environment variable, a
+// file or the output of a command, so it is never written into the config
+type CredentialSource struct {
+	// Host is a glob pattern of the host names, e.g. "*.example.com"
+	Host string
+	// User is sent along with the token
+	User string
+	// TokenEnv, TokenFile and TokenCommand are the sources of the token,
+	// only one of them should be set
+	TokenEnv     string
+	TokenFile    string
+	TokenCommand string
+
+	mutex sync.Mutex
+	token string
+}
+
+// CredentialSources are matched in order, the first one matching the host
+// of a remote is used
+type CredentialSources []*CredentialSource
+
+// Validate returns an error if the pattern is malformed or the count of the
+// token sources is not one
+func (s *CredentialSource) Validate() error {
+	if len(s.Host) == 0 {
+		return fmt.Errorf("credentials: host is required")
+	}
+	if _, err := path.Match(s.Host, ""); err != nil {
+		return fmt.Errorf("credentials of %s: %v", s.Host, err)
+	}
+	if len(s.User) == 0 {
+		return fmt.Errorf("credentials of %s: username is required", s.Host)
+	}
+	count := 0
+	for _, src := range []string{s.TokenEnv, s.TokenFile, s.TokenCommand} {
+		if len(src) > 0 {
+			count++
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("credentials of %s: one of token_env, token_file or token_command is required", s.Host)
+	}
+	return nil
+}
+
+// String describes where the token is read from without revealing it
+func (s *CredentialSource) String() string {
+	switch {
+	case len(s.TokenEnv) > 0:
+		return "env " + s.TokenEnv
+	case len(s.TokenFile) > 0:
+		return "file " + s.TokenFile
+	case len(s.TokenCommand) > 0:
+		return "command " + s.TokenCommand
+	}
+	return "none"
+}
+
+// Match returns true if the host matches the pattern of the source
+func (s *CredentialSource) Match(host string) bool {
+	ok, err := path.Match(strings.ToLower(s.Host), strings.ToLower(host))
+	return err == nil && ok
+}
+
+// Token reads the token from its source, it is kept for the session once it
+// is read successfully
+func (s *CredentialSource) Token() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.token) > 0 {
+		return s.token, nil
+	}
+	var token string
+	switch {
+	case len(s.TokenEnv) > 0:
+		token = os.Getenv(s.TokenEnv)
+	case len(s.TokenFile) > 0:
+		b, err := ioutil.ReadFile(expandHome(s.TokenFile))
+		if err != nil {
+			return "", fmt.Errorf("token of %s: %v", s.Host, err)
+		}
+		token = string(b)
+	case len(s.TokenCommand) > 0:
+		cmd := exec.Command("sh", "-c", s.TokenCommand)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("token of %s: %v %s", s.Host, err, strings.TrimSpace(stderr.String()))
+		}
+		token = string(out)
+	}
+	token = strings.TrimSpace(token)
+	if len(token) == 0 {
+		return "", fmt.Errorf("token of %s: %s is empty", s.Host, s.String())
+	}
+	s.token = token
+	return token, nil
+}
+
+// Match returns the source of the http remote url, nil if there is none
+func (ss CredentialSources) Match(rawURL string) *CredentialSource {
+	u, err := git.ParseRemoteURL(rawURL)
+	if err != nil || (u.Scheme != git.AuthProtocolHTTP && u.Scheme != git.AuthProtocolHTTPS) {
+		return nil
+	}
+	for _, s := range ss {
+		if s.Match(u.Host) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Credentials returns the credentials of the remote url from the matching
+// source, nil is returned if no source matches
+func (ss CredentialSources) Credentials(rawURL string) (*git.Credentials, error) {
+	s := ss.Match(rawURL)
+	if s == nil {
+		return nil, nil
+	}
+	token, err := s.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &git.Credentials{
+		User:     s.User,
+		Password: token,
+		Source:   s.String(),
+		Host:     CredentialKey(rawURL),
+	}, nil
+}
+
+// remoteCredentials returns the credentials to authenticate to the remote
+// url. The given credentials are used only for the host they are issued for,
+// the matching source is asked otherwise
+func remoteCredentials(rawURL string, c *git.Credentials, sources CredentialSources) (*git.Credentials, error) {
+	if c != nil && (len(c.Host) == 0 || c.Host == CredentialKey(rawURL)) {
+		return c, nil
+	}
+	return sources.Credentials(rawURL)
+}
@@ -0,0 +1,184 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialKey(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"https://github.com/isacikgoz/gitbatch.git", "https://github.com"},
+		{"https://user@example.com:8443/a/b.git", "https://example.com:8443"},
+		{"git@github.com:isacikgoz/gitbatch.git", "ssh://github.com"},
+		{"/srv/git/gitbatch.git", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, CredentialKey(test.input), test.input)
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	content := `machine example.com
+	login alice
+	password secret
+
+macdef init
+machine evil.com login mallory password nope
+
+machine other.com login bob password hunter2 account x
+default login anonymous password guest
+`
+	var tests = []struct {
+		host     string
+		expected *git.Credentials
+	}{
+		{"example.com", &git.Credentials{User: "alice", Password: "secret"}},
+		{"other.com", &git.Credentials{User: "bob", Password: "hunter2"}},
+		// the macro is not a machine entry
+		{"evil.com", &git.Credentials{User: "anonymous", Password: "guest"}},
+		{"unknown.com", &git.Credentials{User: "anonymous", Password: "guest"}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, ParseNetrc(content, test.host), test.host)
+	}
+	require.Nil(t, ParseNetrc("machine example.com login alice password secret", "other.com"))
+}
+
+func TestLookupCredentials(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the helpers of the user must not be asked
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("NETRC", filepath.Join(home, "netrc"))
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	store := filepath.Join(home, "store")
+	git.RunTestGit(t, th.RepoPath, "config", "credential.helper", "store --file="+store)
+
+	url := "https://lookup.example.com/a/b.git"
+	c, err := CredentialFill(th.RepoPath, url)
+	require.Error(t, err)
+	require.Nil(t, c)
+	require.Nil(t, LookupCredentials(th.RepoPath, url))
+
+	// the netrc file is the last resort
+	netrc := "machine lookup.example.com login netrc password fromnetrc\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(home, "netrc"), []byte(netrc), 0600))
+	require.Equal(t, &git.Credentials{User: "netrc", Password: "fromnetrc", Source: "netrc"}, LookupCredentials(th.RepoPath, url))
+
+	// the helper is asked before the netrc file
+	helper := &git.Credentials{User: "helper", Password: "fromhelper"}
+	require.NoError(t, CredentialApprove(th.RepoPath, url, helper))
+	c, err = CredentialFill(th.RepoPath, url)
+	require.NoError(t, err)
+	require.Equal(t, helper, c)
+	require.Equal(t, helper, LookupCredentials(th.RepoPath, url))
+
+	// the cache of the session is asked first
+	cached := &git.Credentials{User: "cached", Password: "fromcache"}
+	CacheCredentials("https://lookup.example.com/other.git", cached)
+	require.Equal(t, cached, LookupCredentials(th.RepoPath, url))
+	forgetCredentials(url, cached)
+	require.Equal(t, helper, LookupCredentials(th.RepoPath, url))
+
+	require.NoError(t, CredentialReject(th.RepoPath, url, helper))
+	b, err := ioutil.ReadFile(store)
+	require.NoError(t, err)
+	require.Empty(t, b)
+	require.Equal(t, &git.Credentials{User: "netrc", Password: "fromnetrc", Source: "netrc"}, LookupCredentials(th.RepoPath, url))
+}
+
+func TestCredentialSource(t *testing.T) {
+	var tests = []struct {
+		source *CredentialSource
+		valid  bool
+	}{
+		{&CredentialSource{Host: "github.com", User: "me", TokenEnv: "GITHUB_TOKEN"}, true},
+		{&CredentialSource{Host: "*.example.com", User: "oauth2", TokenCommand: "echo token"}, true},
+		{&CredentialSource{Host: "github.com", TokenEnv: "GITHUB_TOKEN"}, false},
+		{&CredentialSource{User: "me", TokenEnv: "GITHUB_TOKEN"}, false},
+		{&CredentialSource{Host: "[", User: "me", TokenEnv: "GITHUB_TOKEN"}, false},
+		{&CredentialSource{Host: "github.com", User: "me"}, false},
+		{&CredentialSource{Host: "github.com", User: "me", TokenEnv: "A", TokenFile: "b"}, false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.valid, test.source.Validate() == nil, test.source.Host)
+	}
+
+	dir, err := ioutil.TempDir("", "gitbatch-token")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(file, []byte("from-file\n"), 0600))
+	t.Setenv("GITBATCH_TEST_TOKEN", "from-env")
+
+	sources := CredentialSources{
+		{Host: "github.com", User: "env", TokenEnv: "GITBATCH_TEST_TOKEN"},
+		{Host: "*.example.com", User: "file", TokenFile: file},
+		{Host: "gitlab.com", User: "command", TokenCommand: "echo from-command"},
+		{Host: "broken.com", User: "command", TokenCommand: "exit 1"},
+		{Host: "empty.com", User: "env", TokenEnv: "GITBATCH_TEST_UNSET"},
+	}
+	var lookups = []struct {
+		url      string
+		expected *git.Credentials
+	}{
+		{"https://github.com/a/b.git", &git.Credentials{User: "env", Password: "from-env", Source: "env GITBATCH_TEST_TOKEN", Host: "https://github.com"}},
+		{"https://git.example.com/a/b.git", &git.Credentials{User: "file", Password: "from-file", Source: "file " + file, Host: "https://git.example.com"}},
+		{"https://GitLab.com/a/b.git", &git.Credentials{User: "command", Password: "from-command", Source: "command echo from-command", Host: CredentialKey("https://GitLab.com/a/b.git")}},
+		// tokens are only sent over http
+		{"git@github.com:a/b.git", nil},
+		{"https://example.com/a/b.git", nil},
+	}
+	for _, test := range lookups {
+		c, err := sources.Credentials(test.url)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, c, test.url)
+	}
+	for _, url := range []string{"https://broken.com/a.git", "https://empty.com/a.git"} {
+		_, err := sources.Credentials(url)
+		require.Error(t, err, url)
+	}
+}
+
+func TestRemoteCredentials(t *testing.T) {
+	t.Setenv("GITBATCH_TEST_TOKEN", "from-env")
+	sources := CredentialSources{
+		{Host: "upstream.com", User: "env", TokenEnv: "GITBATCH_TEST_TOKEN"},
+	}
+	origin := &git.Credentials{User: "me", Password: "secret", Host: "https://origin.com"}
+	any := &git.Credentials{User: "me", Password: "secret"}
+	var tests = []struct {
+		url      string
+		given    *git.Credentials
+		expected *git.Credentials
+	}{
+		{"https://origin.com/a.git", origin, origin},
+		// the credentials of a host are never sent to another one
+		{"https://upstream.com/a.git", origin, &git.Credentials{
+			User: "env", Password: "from-env", Source: "env GITBATCH_TEST_TOKEN", Host: "https://upstream.com",
+		}},
+		{"https://other.com/a.git", origin, nil},
+		{"http://origin.com/a.git", origin, nil},
+		{"https://other.com/a.git", any, any},
+		{"https://other.com/a.git", nil, nil},
+	}
+	for _, test := range tests {
+		c, err := remoteCredentials(test.url, test.given, sources)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, c, test.url)
+	}
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// DefaultBranch returns the default branch of the repository. If it is not
+// known locally, the HEAD of the remote is queried and recorded as
+// refs/remotes/<remote>/HEAD so that the next lookups are local
+func DefaultBranch(r *git.Repository) (string, error) {
+	return DefaultBranchWithContext(context.Background(), r)
+}
+
+// DefaultBranchWithContext is same as DefaultBranch but the remote query is
+// aborted when the context is done
+func DefaultBranchWithContext(ctx context.Context, r *git.Repository) (string, error) {
+	if len(r.DefaultBranch) > 0 {
+		return r.DefaultBranch, nil
+	}
+	if r.State.Remote == nil {
+		return "", gerr.ErrRemoteNotFound
+	}
+	remote := r.State.Remote.Name
+	branch, err := remoteHeadWithGoGit(ctx, r, remote)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if branch, err = remoteHeadWithGit(ctx, r, remote); err != nil {
+			return "", err
+		}
+	}
+	// the branch is known even if it cannot be recorded
+	if err := r.SetDefaultBranch(remote, branch); err != nil {
+		r.DefaultBranch = branch
+	}
+	return branch, nil
+}
+
+// remoteHeadWithGoGit lists the references of the remote and returns the
+// branch that its HEAD points to
+func remoteHeadWithGoGit(ctx context.Context, r *git.Repository, remote string) (string, error) {
+	rm, err := r.Repo.Remote(remote)
+	if err != nil {
+		return "", err
+	}
+	refs, err := rm.ListContext(ctx, &gogit.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference && ref.Target().IsBranch() {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", fmt.Errorf("HEAD of %s is not a branch", remote)
+}
+
+// remoteHeadWithGit parses the output of "git ls-remote --symref <remote> HEAD"
+// which starts with a line like "ref: refs/heads/main	HEAD"
+func remoteHeadWithGit(ctx context.Context, r *git.Repository, remote string) (string, error) {
+	args := []string{"ls-remote", "--symref", remote, "HEAD"}
+	out, err := RunWithContext(ctx, r.AbsPath, "git", args)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", gerr.ParseGitError(out, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "ref:" && fields[2] == "HEAD" {
+			return plumbing.ReferenceName(fields[1]).Short(), nil
+		}
+	}
+	return "", fmt.Errorf("HEAD of %s is not a branch", remote)
+}
@@ -0,0 +1,51 @@
+package command
+
+import (
+	"context"
+
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	// the remote defaults to develop which is not checked out locally
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "develop")
+	git.CreateTestCommit(t, th.RepoPath, "develop.txt")
+	git.RunTestGit(t, th.RepoPath, "push", "origin", "develop")
+	git.RunTestGit(t, th.RepoPath, "checkout", "master")
+	git.RunTestGit(t, th.RepoPath, "branch", "-D", "develop")
+	git.RunTestGit(t, th.RemoteRepoPath(), "symbolic-ref", "HEAD", "refs/heads/develop")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Empty(t, r.DefaultBranch)
+
+	branch, err := DefaultBranch(r)
+	require.NoError(t, err)
+	require.Equal(t, "develop", branch)
+
+	// the queried branch is recorded as the remote HEAD
+	r, err = git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Equal(t, "develop", r.DefaultBranch)
+
+	// checkout without a target switches to the default branch
+	require.NoError(t, Checkout(r, &CheckoutOptions{CommandMode: ModeNative}))
+	require.Equal(t, git.Success, r.WorkStatus())
+	require.Equal(t, "develop", r.State.Branch.Name)
+	require.NotNil(t, r.State.Branch.Upstream)
+}
+
+func TestRemoteHeadWithGit(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	branch, err := remoteHeadWithGit(context.Background(), th.Repository, "origin")
+	require.NoError(t, err)
+	require.Equal(t, "master", branch)
+}
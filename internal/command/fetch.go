@@ -1,29 +1,34 @@
 package command
 
 import (
+	"context"
 	"os"
-	"regexp"
+	"sort"
 	"strings"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
-var (
-	fetchTryCount int
-	fetchMaxTry   = 1
-)
-
 // FetchOptions defines the rules for fetch operation
 type FetchOptions struct {
 	// Name of the remote to fetch from. Defaults to origin.
 	RemoteName string
+	// AllRemotes fetches every remote of the repository, RemoteName is
+	// ignored if set.
+	AllRemotes bool
+	// Tags fetches all of the tags of the remote, otherwise only the tags
+	// pointing into the fetched histories are fetched.
+	Tags bool
 	// Credentials holds the user and password information
 	Credentials *git.Credentials
+	// Sources are the configured tokens, the credentials of a remote are
+	// read from the matching one if no credentials are given for its host
+	Sources CredentialSources
 	// Before fetching, remove any remote-tracking references that no longer
 	// exist on the remote.
 	Prune bool
@@ -36,65 +41,111 @@ type FetchOptions struct {
 	Force bool
 	// Mode is the command mode
 	CommandMode Mode
-	// There should be more room for authentication, tags and progress
 }
 
 // Fetch branches refs from one or more other repositories, along with the
 // objects necessary to complete their histories
 func Fetch(r *git.Repository, o *FetchOptions) (err error) {
+	return FetchWithContext(context.Background(), r, o)
+}
+
+// FetchWithContext is same as Fetch but the operation is aborted when the
+// context is done. The remotes are fetched one by one and the references
+// that each of them changed are kept in the state of the repository
+func FetchWithContext(ctx context.Context, r *git.Repository, o *FetchOptions) (err error) {
+	remotes, err := fetchRemotes(r, o)
+	if err != nil {
+		return err
+	}
 	// here we configure fetch operation
 	// default mode is go-git (this may be configured)
 	mode := o.CommandMode
-	fetchTryCount = 0
-	// prune and dry run is not supported from go-git yet, rely on old friend
-	if o.Prune || o.DryRun {
+	// dry run is not supported from go-git yet, rely on old friend
+	if o.DryRun {
 		mode = ModeLegacy
 	}
-	switch mode {
-	case ModeLegacy:
-		err = fetchWithGit(r, o)
-		return err
-	case ModeNative:
-		// this should be the refspec as default, let's give it a try
-		// TODO: Fix for quick mode, maybe better read config file
-		var refspec string
-		if r.State.Branch == nil {
-			refspec = "+refs/heads/*:refs/remotes/origin/*"
-		} else {
-			refspec = "+" + "refs/heads/" + r.State.Branch.Name + ":" + "/refs/remotes/" + r.State.Remote.Name + "/" + r.State.Branch.Name
+	changes := make([]*git.RefChanges, 0, len(remotes))
+	for _, rm := range remotes {
+		before := trackingRefs(r, rm.Name)
+		switch mode {
+		case ModeLegacy:
+			err = fetchWithGit(ctx, r, o, rm.Name)
+		case ModeNative:
+			err = fetchWithGoGit(ctx, r, o, rm)
 		}
-		err = fetchWithGoGit(r, o, refspec)
-		return err
+		if err != nil {
+			return err
+		}
+		changes = append(changes, diffRefs(rm.Name, before, trackingRefs(r, rm.Name)))
 	}
-	return nil
+	r.SetWorkStatus(git.Success)
+	r.State.Fetched = changes
+	r.State.Message = fetchMessage(changes)
+	if o.DryRun {
+		r.State.Fetched = nil
+		r.State.Message = ""
+	}
+	// till this step everything should be ok
+	return r.Refresh()
+}
+
+// fetchRemotes returns the remotes to be fetched in the order of their names,
+// the selected remote of the repository is used if no remote is given
+func fetchRemotes(r *git.Repository, o *FetchOptions) ([]*git.Remote, error) {
+	if o.AllRemotes {
+		if len(r.Remotes) == 0 {
+			return nil, gerr.ErrRemoteNotFound
+		}
+		remotes := append([]*git.Remote{}, r.Remotes...)
+		sort.Slice(remotes, func(i, j int) bool {
+			return remotes[i].Name < remotes[j].Name
+		})
+		return remotes, nil
+	}
+	for _, rm := range r.Remotes {
+		if rm.Name == o.RemoteName {
+			return []*git.Remote{rm}, nil
+		}
+	}
+	if len(o.RemoteName) == 0 {
+		if r.State.Remote == nil {
+			return nil, gerr.ErrRemoteNotFound
+		}
+		return []*git.Remote{r.State.Remote}, nil
+	}
+	// the remote may not be loaded, e.g. it has no branches yet
+	return []*git.Remote{{Name: o.RemoteName}}, nil
 }
 
 // fetchWithGit is simply a bare git fetch <remote> command which is flexible
 // for complex operations, but on the other hand, it ties the app to another
 // tool. To avoid that, using native implementation is preferred.
-func fetchWithGit(r *git.Repository, options *FetchOptions) (err error) {
+func fetchWithGit(ctx context.Context, r *git.Repository, options *FetchOptions, remote string) (err error) {
 	args := make([]string, 0)
 	args = append(args, "fetch")
 	// parse options to command line arguments
-	if len(options.RemoteName) > 0 {
-		args = append(args, options.RemoteName)
+	if len(remote) > 0 {
+		args = append(args, remote)
 	}
 	if options.Prune {
 		args = append(args, "-p")
 	}
+	if options.Tags {
+		args = append(args, "--tags")
+	}
 	if options.Force {
 		args = append(args, "-f")
 	}
 	if options.DryRun {
 		args = append(args, "--dry-run")
 	}
-	if out, err := Run(r.AbsPath, "git", args); err != nil {
+	if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return gerr.ParseGitError(out, err)
 	}
-	r.SetWorkStatus(git.Success)
-	r.State.Message = ""
-	// till this step everything should be ok
-	return r.Refresh()
+	return nil
 }
 
 // fetchWithGoGit is the primary fetch method and refspec is the main feature.
@@ -102,89 +153,163 @@ func fetchWithGit(r *git.Repository, options *FetchOptions) (err error) {
 // the refspec is an optional +, followed by <src>:<dst>, where <src> is the
 // pattern for references on the remote side and <dst> is where those references
 // will be written locally. The + tells Git to update the reference even if it
-// isn’t a fast-forward.
-func fetchWithGoGit(r *git.Repository, options *FetchOptions, refspec string) (err error) {
+// isn’t a fast-forward. The refspecs configured for the remote are used.
+func fetchWithGoGit(ctx context.Context, r *git.Repository, options *FetchOptions, remote *git.Remote) (err error) {
+	refspecs := make([]config.RefSpec, 0, len(remote.RefSpecs))
+	for _, rs := range remote.RefSpecs {
+		refspecs = append(refspecs, config.RefSpec(rs))
+	}
+	if len(refspecs) == 0 {
+		refspecs = append(refspecs, config.RefSpec("+refs/heads/*:refs/remotes/"+remote.Name+"/*"))
+	}
 	opt := &gogit.FetchOptions{
-		RemoteName: options.RemoteName,
-		RefSpecs:   []config.RefSpec{config.RefSpec(refspec)},
+		RemoteName: remote.Name,
+		RefSpecs:   refspecs,
 		Force:      options.Force,
+		Tags:       gogit.TagFollowing,
 	}
-	// if any credential is given, let's add it to the git.FetchOptions
-	if options.Credentials != nil {
-		protocol, err := git.AuthProtocol(r.State.Remote)
-		if err != nil {
-			return err
-		}
-		if protocol == git.AuthProtocolHTTP || protocol == git.AuthProtocolHTTPS {
-			opt.Auth = &http.BasicAuth{
-				Username: options.Credentials.User,
-				Password: options.Credentials.Password,
-			}
-		} else {
-			return gerr.ErrInvalidAuthMethod
-		}
+	if options.Tags {
+		opt.Tags = gogit.AllTags
 	}
+	// the credentials are looked up if not given, ssh remotes may use the
+	// agent or the identity files as well
+	rawURL := remoteURL(r, remote.Name)
+	creds, err := remoteCredentials(rawURL, options.Credentials, options.Sources)
+	if err != nil {
+		return err
+	}
+	auth, err := authMethod(rawURL, creds)
+	if err == errNoNativeAuth {
+		return fetchWithGit(ctx, r, options, remote.Name)
+	} else if err != nil {
+		return err
+	}
+	opt.Auth = auth
 	if options.Progress {
 		opt.Progress = os.Stdout
 	}
-	if err := r.Repo.Fetch(opt); err != nil {
-		if err == gogit.NoErrAlreadyUpToDate {
+	err = r.Repo.FetchContext(ctx, opt)
+	if c, auth := challengeAuth(r.AbsPath, rawURL, opt.Auth, err); auth != nil {
+		// the server requires credentials, try once more with the known ones
+		creds, opt.Auth = c, auth
+		err = r.Repo.FetchContext(ctx, opt)
+	}
+	reportCredentials(r.AbsPath, rawURL, opt.Auth, creds, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			// the operation is cancelled or timed out, no fallback
+			return ctx.Err()
+		} else if err == gogit.NoErrAlreadyUpToDate {
 			// Already up-to-date
 			// TODO: submit a PR for this kind of error, this type of catch is lame
-		} else if strings.Contains(err.Error(), "couldn't find remote ref") {
-			// we don't have remote ref, so lets pull other things.. maybe it'd be useful
-			rp := r.State.Remote.RefSpecs[0]
-			if fetchTryCount < fetchMaxTry {
-				fetchTryCount++
-				_ = fetchWithGoGit(r, options, rp)
-			} else {
-				return err
-			}
-			// TODO: submit a PR for this kind of error, this type of catch is lame
-		} else if strings.Contains(err.Error(), "SSH_AUTH_SOCK") {
-			// The env variable SSH_AUTH_SOCK is not defined, maybe git can handle this
-			return fetchWithGit(r, options)
-		} else if err == transport.ErrAuthenticationRequired {
-			return gerr.ErrAuthenticationRequired
+		} else if aerr := authError(err); aerr != nil {
+			return aerr
 		} else {
-			return fetchWithGit(r, options)
+			return fetchWithGit(ctx, r, options, remote.Name)
 		}
 	}
-	r.SetWorkStatus(git.Success)
+	if options.Prune {
+		return pruneWithGoGit(ctx, r, remote.Name, refspecs, opt.Auth)
+	}
+	return nil
+}
 
-	ref, _ := r.Repo.Head()
-	// TODO: fix this, refresh two times not cool
-	_ = r.Refresh()
-	uRef := "origin/HEAD"
-	if r.State.Branch != nil && r.State.Branch.Upstream != nil {
-		uRef = r.State.Branch.Upstream.Reference.Hash().String()[:7]
+// pruneWithGoGit removes the remote-tracking references that the refspecs map
+// to a reference which no longer exists on the remote, the same way as
+// "git fetch --prune" does
+func pruneWithGoGit(ctx context.Context, r *git.Repository, remote string, refspecs []config.RefSpec, auth transport.AuthMethod) error {
+	rm, err := r.Repo.Remote(remote)
+	if err != nil {
+		return err
+	}
+	advertised, err := rm.ListContext(ctx, &gogit.ListOptions{Auth: auth})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	alive := make(map[plumbing.ReferenceName]bool)
+	for _, ref := range advertised {
+		for _, rs := range refspecs {
+			if rs.Match(ref.Name()) {
+				alive[rs.Dst(ref.Name())] = true
+			}
+		}
 	}
+	refs, err := r.Repo.References()
+	if err != nil {
+		return err
+	}
+	stale := make([]plumbing.ReferenceName, 0)
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.SymbolicReference || alive[ref.Name()] {
+			return nil
+		}
+		for _, rs := range refspecs {
+			if rs.Reverse().Match(ref.Name()) {
+				stale = append(stale, ref.Name())
+				break
+			}
+		}
+		return nil
+	})
+	for _, name := range stale {
+		if err := r.Repo.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	msg, err := getFetchMessage(r, ref.Hash().String()[:7], uRef)
+// trackingRefs returns the hashes of the remote-tracking references of the
+// remote and the tags, keyed by their short names
+func trackingRefs(r *git.Repository, remote string) map[string]plumbing.Hash {
+	hashes := make(map[string]plumbing.Hash)
+	refs, err := r.Repo.References()
 	if err != nil {
-		msg = "couldn't get stat"
+		return hashes
 	}
-	r.State.Message = msg
-	// till this step everything should be ok
-	return r.Refresh()
+	prefix := "refs/remotes/" + remote + "/"
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if n := ref.Name(); n.IsTag() || strings.HasPrefix(n.String(), prefix) {
+			hashes[n.Short()] = ref.Hash()
+		}
+		return nil
+	})
+	return hashes
 }
 
-func getFetchMessage(r *git.Repository, ref1, ref2 string) (string, error) {
-	msg := ref1 + ".." + ref2 + " "
-	if ref1 == ref2 {
-		msg = msg + "already up-to-date"
-	} else {
-		out, err := DiffStatRefs(r, ref1, ref2)
-		if err != nil {
-			return "", err
+// diffRefs compares the references before and after fetching the remote
+func diffRefs(remote string, before, after map[string]plumbing.Hash) *git.RefChanges {
+	c := &git.RefChanges{Remote: remote}
+	for name, hash := range after {
+		if old, ok := before[name]; !ok {
+			c.New = append(c.New, name)
+		} else if old != hash {
+			c.Updated = append(c.Updated, name)
 		}
-		re := regexp.MustCompile(`\r?\n`)
-		lines := re.Split(out, -1)
-		last := lines[len(lines)-1]
-		if len(last) > 0 {
-			changes := strings.Split(last, ",")
-			msg = msg + changes[0][1:]
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			c.Pruned = append(c.Pruned, name)
 		}
 	}
-	return msg, nil
+	sort.Strings(c.New)
+	sort.Strings(c.Updated)
+	sort.Strings(c.Pruned)
+	return c
+}
+
+// fetchMessage joins the summaries of the remotes, e.g.
+// "origin: up-to-date; upstream: 2 new, 1 pruned"
+func fetchMessage(changes []*git.RefChanges) string {
+	msgs := make([]string, 0, len(changes))
+	for _, c := range changes {
+		msgs = append(msgs, c.String())
+	}
+	return strings.Join(msgs, "; ")
 }
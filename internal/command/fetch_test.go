@@ -1,8 +1,18 @@
 package command
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/stretchr/testify/require"
 )
@@ -41,7 +51,7 @@ func TestFetchWithGit(t *testing.T) {
 		{th.Repository, testFetchopts3},
 	}
 	for _, test := range tests {
-		err := fetchWithGit(test.inp1, test.inp2)
+		err := fetchWithGit(context.Background(), test.inp1, test.inp2, test.inp2.RemoteName)
 		require.NoError(t, err)
 	}
 }
@@ -50,17 +60,188 @@ func TestFetchWithGoGit(t *testing.T) {
 	th := git.InitTestRepositoryFromLocal(t)
 	defer th.CleanUp(t)
 
-	refspec := "+" + "refs/heads/" + th.Repository.State.Branch.Name + ":" + "/refs/remotes/" + th.Repository.State.Branch.Name
 	var tests = []struct {
 		inp1 *git.Repository
 		inp2 *FetchOptions
-		inp3 string
+		inp3 *git.Remote
 	}{
-		{th.Repository, testFetchopts1, refspec},
-		{th.Repository, testFetchopts4, refspec},
+		{th.Repository, testFetchopts1, th.Repository.State.Remote},
+		{th.Repository, testFetchopts2, th.Repository.State.Remote},
+		{th.Repository, testFetchopts4, th.Repository.State.Remote},
 	}
 	for _, test := range tests {
-		err := fetchWithGoGit(test.inp1, test.inp2, test.inp3)
+		err := fetchWithGoGit(context.Background(), test.inp1, test.inp2, test.inp3)
 		require.NoError(t, err)
 	}
 }
+
+func TestFetchRemotes(t *testing.T) {
+	var tests = []struct {
+		mode Mode
+	}{
+		{ModeNative},
+		{ModeLegacy},
+	}
+	for _, test := range tests {
+		th := git.InitTestRepositoryWithLocalRemote(t)
+		dir := filepath.Dir(th.RepoPath)
+		upstream := filepath.Join(dir, "upstream.git")
+		other := filepath.Join(dir, "other")
+		git.RunTestGit(t, dir, "clone", "--bare", th.RemoteRepoPath(), upstream)
+		git.RunTestGit(t, th.RepoPath, "remote", "add", "upstream", upstream)
+		git.RunTestGit(t, th.RepoPath, "push", "origin", "master:stale")
+		git.RunTestGit(t, th.RepoPath, "fetch", "--all")
+
+		// the remotes move on without the local repository
+		git.RunTestGit(t, dir, "clone", th.RemoteRepoPath(), other)
+		git.CreateTestCommit(t, other, "new.txt")
+		git.RunTestGit(t, other, "push", "origin", "master", "master:feature", ":stale")
+		git.RunTestGit(t, other, "tag", "v1.0", "HEAD~1")
+		git.RunTestGit(t, other, "push", upstream, "v1.0")
+
+		r, err := git.InitializeRepo(th.RepoPath)
+		require.NoError(t, err)
+		err = Fetch(r, &FetchOptions{
+			AllRemotes:  true,
+			Prune:       true,
+			Tags:        true,
+			CommandMode: test.mode,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*git.RefChanges{
+			{
+				Remote:  "origin",
+				New:     []string{"origin/feature"},
+				Updated: []string{"origin/master"},
+				Pruned:  []string{"origin/stale"},
+			},
+			{
+				Remote: "upstream",
+				New:    []string{"v1.0"},
+			},
+		}, r.State.Fetched)
+		require.Equal(t, "origin: 1 new, 1 updated, 1 pruned; upstream: 1 new", r.State.Message)
+		th.CleanUp(t)
+	}
+}
+
+func TestFetchCredentialsPerRemote(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the helpers of the user must not be asked
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("NETRC", filepath.Join(home, "netrc"))
+
+	var mutex sync.Mutex
+	users := make(map[string]string)
+	serve := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _, _ := r.BasicAuth()
+			mutex.Lock()
+			users[name] = user
+			mutex.Unlock()
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+	}
+	origin, upstream := serve("origin"), serve("upstream")
+	defer origin.Close()
+	defer upstream.Close()
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", origin.URL+"/a.git")
+	git.RunTestGit(t, th.RepoPath, "remote", "add", "upstream", upstream.URL+"/a.git")
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+
+	// the credentials of the origin are never sent to the upstream
+	creds := &git.Credentials{User: "me", Password: "secret", Host: CredentialKey(origin.URL)}
+	err = Fetch(r, &FetchOptions{RemoteName: "upstream", Credentials: creds, CommandMode: ModeNative})
+	require.Error(t, err)
+	err = Fetch(r, &FetchOptions{RemoteName: "origin", Credentials: creds, CommandMode: ModeNative})
+	require.Error(t, err)
+	require.Equal(t, map[string]string{"origin": "me", "upstream": ""}, users)
+}
+
+func TestFetchWithGitAuthenticationRequired(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the helpers of the user must not be asked
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_ASKPASS", "")
+	t.Setenv("SSH_ASKPASS", "")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", ts.URL+"/a.git")
+
+	// git can not prompt for the credentials, the gui asks for them instead
+	err = Fetch(th.Repository, &FetchOptions{RemoteName: "origin", CommandMode: ModeLegacy})
+	require.ErrorIs(t, err, gerr.ErrAuthenticationRequired)
+}
+
+// serveTestGit serves the repositories in the directory with git http-backend,
+// the requests without credentials are refused if it is private
+func serveTestGit(t *testing.T, root string, private bool) *httptest.Server {
+	exec := strings.TrimSpace(git.RunTestGit(t, root, "--exec-path"))
+	backend := &cgi.Handler{
+		Path: filepath.Join(exec, "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); private && !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+}
+
+func TestFetchCredentialsOnChallenge(t *testing.T) {
+	home, err := ioutil.TempDir("", "gitbatch-credential")
+	require.NoError(t, err)
+	defer os.RemoveAll(home)
+	// the helpers of the user must not be asked
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("NETRC", filepath.Join(home, "netrc"))
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	// the helper only writes down the actions it is asked for
+	calls := filepath.Join(home, "calls")
+	git.RunTestGit(t, th.RepoPath, "config", "credential.helper", "!f() { echo $1 >> "+calls+"; }; f")
+
+	public := serveTestGit(t, filepath.Dir(th.RepoPath), false)
+	defer public.Close()
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", public.URL+"/remote.git")
+	err = Fetch(th.Repository, &FetchOptions{RemoteName: "origin", CommandMode: ModeNative})
+	require.NoError(t, err)
+	require.NoFileExists(t, calls)
+
+	// the credentials of the netrc file are never given to the helper
+	private := serveTestGit(t, filepath.Dir(th.RepoPath), true)
+	defer private.Close()
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", private.URL+"/remote.git")
+	netrc := "machine 127.0.0.1 login me password secret\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(home, "netrc"), []byte(netrc), 0600))
+	err = Fetch(th.Repository, &FetchOptions{RemoteName: "origin", CommandMode: ModeNative})
+	require.NoError(t, err)
+	b, err := ioutil.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, "get\n", string(b))
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"regexp"
 
 	gerr "github.com/isacikgoz/gitbatch/internal/errors"
@@ -15,6 +16,9 @@ type MergeOptions struct {
 	Verbose bool
 	// With true do not show a diffstat at the end of the merge.
 	NoStat bool
+	// Strategy defines how the branch is incorporated, rebase strategies
+	// rebase the current branch onto it instead of merging
+	Strategy Strategy
 	// Mode is the command mode
 	CommandMode Mode
 }
@@ -22,21 +26,29 @@ type MergeOptions struct {
 // Merge incorporates changes from the named commits or branches into the
 // current branch
 func Merge(r *git.Repository, options *MergeOptions) error {
+	return MergeWithContext(context.Background(), r, options)
+}
 
-	args := make([]string, 0)
-	args = append(args, "merge")
-	if len(options.BranchName) > 0 {
-		args = append(args, options.BranchName)
-	}
+// MergeWithContext is same as Merge but the operation is aborted when the
+// context is done
+func MergeWithContext(ctx context.Context, r *git.Repository, options *MergeOptions) error {
+
+	args := options.Strategy.mergeArgs()
 	if options.Verbose {
 		args = append(args, "-v")
 	}
 	if options.NoStat {
 		args = append(args, "-n")
 	}
+	if len(options.BranchName) > 0 {
+		args = append(args, options.BranchName)
+	}
 
 	ref, _ := r.Repo.Head()
-	if out, err := Run(r.AbsPath, "git", args); err != nil {
+	if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return gerr.ParseGitError(out, err)
 	}
 
@@ -0,0 +1,253 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// PatchAction is the way the selected lines of a diff are applied
+type PatchAction string
+
+const (
+	// PatchStage adds the selected lines of the worktree to the index
+	PatchStage PatchAction = "stage"
+	// PatchUnstage removes the selected lines of the index
+	PatchUnstage PatchAction = "unstage"
+	// PatchDiscard reverts the selected lines of the worktree
+	PatchDiscard PatchAction = "discard"
+)
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// FileDiff is the diff of a single file split into its hunks
+type FileDiff struct {
+	// Header is the lines before the first hunk, e.g. "--- a/file"
+	Header []string
+	Hunks  []*Hunk
+}
+
+// Hunk is a part of a diff, the lines keep their leading " ", "+", "-" or
+// "\" characters
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	// Section is the text after the range, usually the enclosing function
+	Section string
+	Lines   []string
+}
+
+// LineSelection holds the selected lines of a diff, indexed by the hunk and
+// the line in the hunk
+type LineSelection map[int]map[int]bool
+
+// Toggle selects the line if it is not selected, deselects otherwise
+func (s LineSelection) Toggle(hunk, line int) {
+	if s[hunk] == nil {
+		s[hunk] = make(map[int]bool)
+	}
+	s[hunk][line] = !s[hunk][line]
+	if !s[hunk][line] {
+		delete(s[hunk], line)
+	}
+}
+
+// Selected returns true if the line is selected
+func (s LineSelection) Selected(hunk, line int) bool {
+	return s[hunk][line]
+}
+
+// Empty returns true if no line is selected
+func (s LineSelection) Empty() bool {
+	for _, lines := range s {
+		if len(lines) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsChange returns true if the line is an added or a removed line
+func (h *Hunk) IsChange(line int) bool {
+	return len(h.Lines[line]) > 0 && (h.Lines[line][0] == '+' || h.Lines[line][0] == '-')
+}
+
+// SelectHunk selects all of the changed lines of the hunk, or deselects them
+// if they are already selected
+func (s LineSelection) SelectHunk(d *FileDiff, hunk int) {
+	h := d.Hunks[hunk]
+	all := true
+	for i := range h.Lines {
+		if h.IsChange(i) && !s.Selected(hunk, i) {
+			all = false
+		}
+	}
+	s[hunk] = make(map[int]bool)
+	for i := range h.Lines {
+		if h.IsChange(i) && !all {
+			s[hunk][i] = true
+		}
+	}
+}
+
+// FileHunks returns the diff of the file between the worktree and the index,
+// or between the index and HEAD if staged is true
+func FileHunks(r *git.Repository, f *git.File, staged bool) (*FileDiff, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	args = append(args, "--", f.Name)
+	out, err := Run(r.AbsPath, "git", args)
+	if err != nil {
+		return nil, gerr.ParseGitError(out, err)
+	}
+	return ParseFileDiff(out), nil
+}
+
+// ParseFileDiff splits the diff of a single file into its hunks
+func ParseFileDiff(diff string) *FileDiff {
+	d := &FileDiff{}
+	var hunk *Hunk
+	for _, line := range strings.Split(diff, "\n") {
+		if m := hunkHeaderRegex.FindStringSubmatch(line); m != nil {
+			hunk = &Hunk{
+				OldStart: atoi(m[1], 0),
+				OldLines: atoi(m[2], 1),
+				NewStart: atoi(m[3], 0),
+				NewLines: atoi(m[4], 1),
+				Section:  m[5],
+			}
+			d.Hunks = append(d.Hunks, hunk)
+			continue
+		}
+		if hunk == nil {
+			d.Header = append(d.Header, line)
+			continue
+		}
+		if len(line) == 0 {
+			// only the trailing new line of the diff is empty
+			continue
+		}
+		hunk.Lines = append(hunk.Lines, line)
+	}
+	return d
+}
+
+// Patch builds a patch of the selected lines. The patch is meant to be
+// applied in reverse if reverse is true, so the unselected removals are
+// dropped instead of the unselected additions. An empty string is returned
+// if nothing is selected
+func (d *FileDiff) Patch(s LineSelection, reverse bool) string {
+	var (
+		b     strings.Builder
+		delta int
+	)
+	// the unselected lines of this kind are dropped, the others are context
+	drop := byte('+')
+	if reverse {
+		drop = '-'
+	}
+	for hi, h := range d.Hunks {
+		if len(s[hi]) == 0 {
+			continue
+		}
+		lines := make([]string, 0, len(h.Lines))
+		oldLines, newLines := 0, 0
+		kept := true
+		for li, line := range h.Lines {
+			switch line[0] {
+			case '\\':
+				// the no new line marker belongs to the previous line
+				if kept {
+					lines = append(lines, line)
+				}
+				continue
+			case '+', '-':
+				if !s.Selected(hi, li) {
+					if line[0] == drop {
+						kept = false
+						continue
+					}
+					line = " " + line[1:]
+				}
+			}
+			kept = true
+			lines = append(lines, line)
+			switch line[0] {
+			case '+':
+				newLines++
+			case '-':
+				oldLines++
+			default:
+				oldLines++
+				newLines++
+			}
+		}
+		oldStart, newStart := h.OldStart, h.OldStart+delta
+		if reverse {
+			// the new side is the one that the reversed patch is applied to
+			oldStart, newStart = h.NewStart-delta, h.NewStart
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@%s\n", oldStart, oldLines, newStart, newLines, h.Section)
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+		delta += newLines - oldLines
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return strings.Join(d.Header, "\n") + "\n" + b.String()
+}
+
+// ApplyPatch stages, unstages or discards the selected lines of the diff.
+// The diff should be of the worktree for stage and discard, and of the index
+// for unstage
+func ApplyPatch(r *git.Repository, d *FileDiff, s LineSelection, action PatchAction) error {
+	args := []string{"apply", "--recount", "--whitespace=nowarn"}
+	switch action {
+	case PatchStage:
+		args = append(args, "--cached")
+	case PatchUnstage:
+		args = append(args, "--cached", "--reverse")
+	case PatchDiscard:
+		args = append(args, "--reverse")
+	}
+	patch := d.Patch(s, action != PatchStage)
+	if len(patch) == 0 {
+		return nil
+	}
+	file, err := ioutil.TempFile("", "gitbatch-patch")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(patch); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if out, err := Run(r.AbsPath, "git", append(args, file.Name())); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return nil
+}
+
+// atoi returns the default value if the string is not a number
+func atoi(s string, def int) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+// numberedLines returns the lines from 1 to n, the lines in replaced are
+// replaced with their values
+func numberedLines(n int, replaced map[int]string) string {
+	lines := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		if s, ok := replaced[i]; ok {
+			lines = append(lines, s)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestParseFileDiff(t *testing.T) {
+	diff := "diff --git a/a.txt b/a.txt\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1,2 +1,2 @@ func main\n" +
+		" a\n" +
+		"-b\n" +
+		"+c\n" +
+		"@@ -10 +10,0 @@\n" +
+		"-d\n" +
+		"\\ No newline at end of file\n"
+	d := ParseFileDiff(diff)
+	require.Equal(t, []string{"diff --git a/a.txt b/a.txt", "--- a/a.txt", "+++ b/a.txt"}, d.Header)
+	require.Equal(t, []*Hunk{
+		{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2, Section: " func main", Lines: []string{" a", "-b", "+c"}},
+		{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 0, Lines: []string{"-d", "\\ No newline at end of file"}},
+	}, d.Hunks)
+
+	var tests = []struct {
+		selection LineSelection
+		reverse   bool
+		expected  string
+	}{
+		{LineSelection{}, false, ""},
+		{LineSelection{0: {1: true}}, false, "@@ -1,2 +1,1 @@ func main\n a\n-b\n"},
+		{LineSelection{0: {2: true}}, false, "@@ -1,2 +1,3 @@ func main\n a\n b\n+c\n"},
+		{LineSelection{0: {2: true}}, true, "@@ -1,1 +1,2 @@ func main\n a\n+c\n"},
+		{LineSelection{1: {0: true}}, false, "@@ -10,1 +10,0 @@\n-d\n\\ No newline at end of file\n"},
+	}
+	for _, test := range tests {
+		patch := d.Patch(test.selection, test.reverse)
+		if len(test.expected) > 0 {
+			test.expected = strings.Join(d.Header, "\n") + "\n" + test.expected
+		}
+		require.Equal(t, test.expected, patch)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	path := filepath.Join(th.RepoPath, "a.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte(numberedLines(20, nil)), 0644))
+	git.RunTestGit(t, th.RepoPath, "add", "a.txt")
+	git.RunTestGit(t, th.RepoPath, "commit", "-m", "add a.txt")
+	changed := numberedLines(20, map[int]string{2: "changed 2", 15: "changed 15"})
+	require.NoError(t, ioutil.WriteFile(path, []byte(changed), 0644))
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	f := &git.File{Name: "a.txt", AbsPath: path}
+
+	d, err := FileHunks(r, f, false)
+	require.NoError(t, err)
+	require.Len(t, d.Hunks, 2)
+
+	// stage only the second hunk
+	s := LineSelection{}
+	s.SelectHunk(d, 1)
+	require.NoError(t, ApplyPatch(r, d, s, PatchStage))
+	staged, err := FileHunks(r, f, true)
+	require.NoError(t, err)
+	require.Len(t, staged.Hunks, 1)
+	require.Contains(t, staged.Hunks[0].Lines, "+changed 15")
+
+	// stage the removal of the first hunk without the addition
+	d, err = FileHunks(r, f, false)
+	require.NoError(t, err)
+	require.Len(t, d.Hunks, 1)
+	require.NoError(t, ApplyPatch(r, d, LineSelection{0: {1: true}}, PatchStage))
+	index, err := Run(th.RepoPath, "git", []string{"show", ":a.txt"})
+	require.NoError(t, err)
+	expected := strings.Replace(numberedLines(20, map[int]string{15: "changed 15"}), "line 2\n", "", 1)
+	require.Equal(t, expected, index+"\n")
+
+	// unstage the second hunk
+	staged, err = FileHunks(r, f, true)
+	require.NoError(t, err)
+	require.Len(t, staged.Hunks, 2)
+	s = LineSelection{}
+	s.SelectHunk(staged, 1)
+	require.NoError(t, ApplyPatch(r, staged, s, PatchUnstage))
+	index, err = Run(th.RepoPath, "git", []string{"show", ":a.txt"})
+	require.NoError(t, err)
+	require.NotContains(t, index, "changed 15")
+
+	// discard the second hunk from the worktree
+	d, err = FileHunks(r, f, false)
+	require.NoError(t, err)
+	s = LineSelection{}
+	s.SelectHunk(d, len(d.Hunks)-1)
+	require.NoError(t, ApplyPatch(r, d, s, PatchDiscard))
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, numberedLines(20, map[int]string{2: "changed 2"}), string(b))
+}
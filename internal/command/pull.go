@@ -1,23 +1,16 @@
 package command
 
 import (
+	"context"
 	"os"
-	"strings"
 
 	gogit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage"
 	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
-var (
-	pullTryCount int
-	pullMaxTry   = 1
-)
-
 // PullOptions defines the rules for pull operation
 type PullOptions struct {
 	// Name of the remote to fetch from. Defaults to origin.
@@ -28,32 +21,46 @@ type PullOptions struct {
 	SingleBranch bool
 	// Credentials holds the user and password information
 	Credentials *git.Credentials
+	// Sources are the configured tokens, the credentials of a remote are
+	// read from the matching one if no credentials are given for its host
+	Sources CredentialSources
 	// Process logs the output to stdout
 	Progress bool
 	// Force allows the pull to update a local branch even when the remote
 	// branch does not descend from it.
 	Force bool
+	// Strategy defines how the changes are incorporated into the branch
+	Strategy Strategy
 	// Mode is the command mode
 	CommandMode Mode
 }
 
 // Pull incorporates changes from a remote repository into the current branch.
 func Pull(r *git.Repository, o *PullOptions) (err error) {
-	pullTryCount = 0
+	return PullWithContext(context.Background(), r, o)
+}
 
+// PullWithContext is same as Pull but the operation is aborted when the
+// context is done
+func PullWithContext(ctx context.Context, r *git.Repository, o *PullOptions) (err error) {
 	// here we configure pull operation
-	switch o.CommandMode {
+	mode := o.CommandMode
+	// go-git can only fast-forward, rely on git for the other strategies
+	if o.Strategy != StrategyDefault && o.Strategy != StrategyFastForward {
+		mode = ModeLegacy
+	}
+	switch mode {
 	case ModeLegacy:
-		err = pullWithGit(r, o)
+		err = pullWithGit(ctx, r, o)
 		return err
 	case ModeNative:
-		err = pullWithGoGit(r, o)
+		err = pullWithGoGit(ctx, r, o)
 		return err
 	}
 	return nil
 }
 
-func pullWithGit(r *git.Repository, options *PullOptions) (err error) {
+func pullWithGit(ctx context.Context, r *git.Repository, options *PullOptions) (err error) {
 	args := make([]string, 0)
 	args = append(args, "pull")
 	// parse options to command line arguments
@@ -63,8 +70,12 @@ func pullWithGit(r *git.Repository, options *PullOptions) (err error) {
 	if options.Force {
 		args = append(args, "-f")
 	}
+	args = append(args, options.Strategy.pullArgs()...)
 	ref, _ := r.Repo.Head()
-	if out, err := Run(r.AbsPath, "git", args); err != nil {
+	if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return gerr.ParseGitError(out, err)
 	}
 	newref, _ := r.Repo.Head()
@@ -77,7 +88,7 @@ func pullWithGit(r *git.Repository, options *PullOptions) (err error) {
 	return r.Refresh()
 }
 
-func pullWithGoGit(r *git.Repository, options *PullOptions) (err error) {
+func pullWithGoGit(ctx context.Context, r *git.Repository, options *PullOptions) (err error) {
 	opt := &gogit.PullOptions{
 		RemoteName:   options.RemoteName,
 		SingleBranch: options.SingleBranch,
@@ -87,21 +98,20 @@ func pullWithGoGit(r *git.Repository, options *PullOptions) (err error) {
 		ref := plumbing.NewRemoteReferenceName(options.RemoteName, options.ReferenceName)
 		opt.ReferenceName = ref
 	}
-	// if any credential is given, let's add it to the git.PullOptions
-	if options.Credentials != nil {
-		protocol, err := git.AuthProtocol(r.State.Remote)
-		if err != nil {
-			return err
-		}
-		if protocol == git.AuthProtocolHTTP || protocol == git.AuthProtocolHTTPS {
-			opt.Auth = &http.BasicAuth{
-				Username: options.Credentials.User,
-				Password: options.Credentials.Password,
-			}
-		} else {
-			return gerr.ErrInvalidAuthMethod
-		}
+	// the credentials are looked up if not given, ssh remotes may use the
+	// agent or the identity files as well
+	rawURL := remoteURL(r, options.RemoteName)
+	creds, err := remoteCredentials(rawURL, options.Credentials, options.Sources)
+	if err != nil {
+		return err
+	}
+	auth, err := authMethod(rawURL, creds)
+	if err == errNoNativeAuth {
+		return pullWithGit(ctx, r, options)
+	} else if err != nil {
+		return err
 	}
+	opt.Auth = auth
 	if options.Progress {
 		opt.Progress = os.Stdout
 	}
@@ -110,26 +120,41 @@ func pullWithGoGit(r *git.Repository, options *PullOptions) (err error) {
 		return err
 	}
 	ref, _ := r.Repo.Head()
-	if err = w.Pull(opt); err != nil {
-		if err == gogit.NoErrAlreadyUpToDate {
+	err = w.PullContext(ctx, opt)
+	if c, auth := challengeAuth(r.AbsPath, rawURL, opt.Auth, err); auth != nil {
+		// the server requires credentials, try once more with the known ones
+		creds, opt.Auth = c, auth
+		err = w.PullContext(ctx, opt)
+	}
+	reportCredentials(r.AbsPath, rawURL, opt.Auth, creds, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			// the operation is cancelled or timed out, no fallback
+			return ctx.Err()
+		} else if err == gogit.NoErrAlreadyUpToDate {
 			// log.Error("error: " + err.Error())
 			// Already up-to-date
 			// TODO: submit a PR for this kind of error, this type of catch is lame
-		} else if err == storage.ErrReferenceHasChanged && pullTryCount < pullMaxTry {
-			pullTryCount++
-			if err := Fetch(r, &FetchOptions{
+		} else if err == storage.ErrReferenceHasChanged {
+			// the reference is updated while pulling, fetch and give it one more
+			// try before falling back to git
+			if err := FetchWithContext(ctx, r, &FetchOptions{
 				RemoteName: options.RemoteName,
 			}); err != nil {
 				return err
 			}
-			return Pull(r, options)
-		} else if strings.Contains(err.Error(), "SSH_AUTH_SOCK") {
-			// The env variable SSH_AUTH_SOCK is not defined, maybe git can handle this
-			return pullWithGit(r, options)
-		} else if err == transport.ErrAuthenticationRequired {
-			return gerr.ErrAuthenticationRequired
+			if err := w.PullContext(ctx, opt); err != nil && err != gogit.NoErrAlreadyUpToDate {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return pullWithGit(ctx, r, options)
+			}
+		} else if aerr := authError(err); aerr != nil {
+			return aerr
+		} else if err == gogit.ErrNonFastForwardUpdate && options.Strategy == StrategyFastForward {
+			return gerr.ErrDiverged
 		} else {
-			return pullWithGit(r, options)
+			return pullWithGit(ctx, r, options)
 		}
 	}
 	newref, _ := r.Repo.Head()
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"testing"
 
 	"github.com/isacikgoz/gitbatch/internal/git"
@@ -35,7 +36,7 @@ func TestPullWithGit(t *testing.T) {
 		{th.Repository, testPullopts2},
 	}
 	for _, test := range tests {
-		err := pullWithGit(test.inp1, test.inp2)
+		err := pullWithGit(context.Background(), test.inp1, test.inp2)
 		require.NoError(t, err)
 	}
 }
@@ -52,7 +53,7 @@ func TestPullWithGoGit(t *testing.T) {
 		{th.Repository, testPullopts3},
 	}
 	for _, test := range tests {
-		err := pullWithGoGit(test.inp1, test.inp2)
+		err := pullWithGoGit(context.Background(), test.inp1, test.inp2)
 		require.NoError(t, err)
 	}
 }
@@ -0,0 +1,234 @@
+package command
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// PushOptions defines the rules for push operation
+type PushOptions struct {
+	// Name of the remote to push to. Defaults to origin.
+	RemoteName string
+	// RefSpec is the mapping of local ref to the remote one. If empty, the
+	// current branch is pushed to the branch with same name on the remote.
+	RefSpec string
+	// Credentials holds the user and password information
+	Credentials *git.Credentials
+	// Sources are the configured tokens, the credentials of a remote are
+	// read from the matching one if no credentials are given for its host
+	Sources CredentialSources
+	// ForceWithLease allows the push to overwrite the remote branch only if
+	// it still points to the commit that we know of.
+	ForceWithLease bool
+	// SetUpstream adds the upstream reference for the pushed branch.
+	SetUpstream bool
+	// Tags pushes all the local tags along with the refspec.
+	Tags bool
+	// Process logs the output to stdout
+	Progress bool
+	// Mode is the command mode
+	CommandMode Mode
+}
+
+// Push updates remote refs using local refs, while sending objects necessary
+// to complete the given refs.
+func Push(r *git.Repository, o *PushOptions) (err error) {
+	return PushWithContext(context.Background(), r, o)
+}
+
+// PushWithContext is same as Push but the operation is aborted when the
+// context is done
+func PushWithContext(ctx context.Context, r *git.Repository, o *PushOptions) (err error) {
+	// here we configure push operation
+	switch o.CommandMode {
+	case ModeLegacy:
+		err = pushWithGit(ctx, r, o)
+		return err
+	case ModeNative:
+		err = pushWithGoGit(ctx, r, o)
+		return err
+	}
+	return nil
+}
+
+// pushWithGit is simply a bare git push <remote> <refspec> command
+func pushWithGit(ctx context.Context, r *git.Repository, options *PushOptions) (err error) {
+	args := make([]string, 0)
+	args = append(args, "push")
+	// parse options to command line arguments
+	if options.ForceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	if options.SetUpstream {
+		args = append(args, "-u")
+	}
+	if options.Tags {
+		args = append(args, "--tags")
+	}
+	if len(options.RemoteName) > 0 {
+		refspec, err := pushRefSpec(r, options)
+		if err != nil {
+			return err
+		}
+		args = append(args, options.RemoteName, refspec)
+	}
+	old := upstreamHash(r)
+	if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return gerr.ParseGitError(out, err)
+	}
+	return pushSuccess(r, options, old)
+}
+
+// pushWithGoGit is the primary push method. go-git is not able to set the
+// upstream of a branch while pushing, so it is written into the config right
+// after the push succeeds.
+func pushWithGoGit(ctx context.Context, r *git.Repository, options *PushOptions) (err error) {
+	refspec, err := pushRefSpec(r, options)
+	if err != nil {
+		return err
+	}
+	opt := &gogit.PushOptions{
+		RemoteName: options.RemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(refspec)},
+	}
+	if options.Tags {
+		opt.RefSpecs = append(opt.RefSpecs, config.RefSpec("refs/tags/*:refs/tags/*"))
+	}
+	if options.ForceWithLease {
+		opt.ForceWithLease = &gogit.ForceWithLease{}
+	}
+	// the credentials are looked up if not given, ssh remotes may use the
+	// agent or the identity files as well
+	rawURL := remoteURL(r, options.RemoteName)
+	creds, err := remoteCredentials(rawURL, options.Credentials, options.Sources)
+	if err != nil {
+		return err
+	}
+	auth, err := authMethod(rawURL, creds)
+	if err == errNoNativeAuth {
+		return pushWithGit(ctx, r, options)
+	} else if err != nil {
+		return err
+	}
+	opt.Auth = auth
+	if options.Progress {
+		opt.Progress = os.Stdout
+	}
+	old := upstreamHash(r)
+	err = r.Repo.PushContext(ctx, opt)
+	if c, auth := challengeAuth(r.AbsPath, rawURL, opt.Auth, err); auth != nil {
+		// the server requires credentials, try once more with the known ones
+		creds, opt.Auth = c, auth
+		err = r.Repo.PushContext(ctx, opt)
+	}
+	reportCredentials(r.AbsPath, rawURL, opt.Auth, creds, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			// the operation is cancelled or timed out, no fallback
+			return ctx.Err()
+		} else if err == gogit.NoErrAlreadyUpToDate {
+			// Already up-to-date
+		} else if aerr := authError(err); aerr != nil {
+			return aerr
+		} else if unsupportedPush(err) {
+			return pushWithGit(ctx, r, options)
+		} else if err == gogit.ErrForceNeeded || strings.Contains(err.Error(), "non-fast-forward update") {
+			// a rejected lease is reported the same way
+			return &gerr.Error{Category: gerr.ErrNonFastForward, ExitCode: -1, Output: err.Error(), Err: err}
+		} else {
+			return gerr.ParseGitError(err.Error(), err)
+		}
+	}
+	// a detached HEAD has no branch to track the upstream
+	if head, err := r.Repo.Head(); err == nil && options.SetUpstream && head.Name().IsBranch() {
+		if err := setUpstream(r, options.RemoteName, head.Name().Short()); err != nil {
+			return err
+		}
+	}
+	return pushSuccess(r, options, old)
+}
+
+// unsupportedPush returns true if the push failed since go-git lacks a feature
+// that the remote requires, only then the push is tried with git. The other
+// failures, e.g. a rejected update, are real and reported as they are
+func unsupportedPush(err error) bool {
+	switch err {
+	case gogit.ErrDeleteRefNotSupported, gogit.ErrPackedObjectsNotSupported, object.ErrUnsupportedObject:
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "unsupported") || strings.Contains(msg, "not supported")
+}
+
+// pushRefSpec returns the refspec of the push operation, if nothing is
+// specified it maps the current branch to the same named remote branch. There
+// is no branch to push if the HEAD is detached
+func pushRefSpec(r *git.Repository, options *PushOptions) (string, error) {
+	if len(options.RefSpec) > 0 {
+		return options.RefSpec, nil
+	}
+	head, err := r.Repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		return "", gerr.ErrDetachedHead
+	}
+	return head.Name().String() + ":" + head.Name().String(), nil
+}
+
+// setUpstream writes the branch.<name>.remote and branch.<name>.merge entries
+func setUpstream(r *git.Repository, remote, branch string) error {
+	cfg, err := r.Repo.Config()
+	if err != nil {
+		return err
+	}
+	cfg.Branches[branch] = &config.Branch{
+		Name:   branch,
+		Remote: remote,
+		Merge:  plumbing.NewBranchReferenceName(branch),
+	}
+	return r.Repo.Storer.SetConfig(cfg)
+}
+
+// upstreamHash returns the hash of the upstream of current branch, if there
+// is no upstream it returns an empty string
+func upstreamHash(r *git.Repository) string {
+	if r.State.Branch == nil || r.State.Branch.Upstream == nil {
+		return ""
+	}
+	return r.State.Branch.Upstream.Reference.Hash().String()
+}
+
+// pushSuccess sets the state of the repository after a successful push
+func pushSuccess(r *git.Repository, options *PushOptions, old string) error {
+	r.SetWorkStatus(git.Success)
+	r.State.Message = getPushMessage(r, options.RemoteName, old)
+	return r.Refresh()
+}
+
+func getPushMessage(r *git.Repository, remote, old string) string {
+	ref, err := r.Repo.Head()
+	if err != nil {
+		return "couldn't get stat"
+	}
+	head := ref.Hash().String()
+	if old == head {
+		return "already up-to-date"
+	}
+	if len(old) == 0 {
+		return "pushed " + head[:7] + " to " + remote
+	}
+	return old[:7] + ".." + head[:7] + " pushed to " + remote
+}
@@ -0,0 +1,187 @@
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testPushopts1 = &PushOptions{
+		RemoteName: "origin",
+	}
+
+	testPushopts2 = &PushOptions{
+		RemoteName:     "origin",
+		ForceWithLease: true,
+		Tags:           true,
+	}
+)
+
+func TestPushWithGit(t *testing.T) {
+	var tests = []struct {
+		input *PushOptions
+	}{
+		{testPushopts1},
+		{testPushopts2},
+	}
+	for _, test := range tests {
+		func() {
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+			git.CreateTestCommit(t, th.RepoPath, "push.txt")
+
+			err := pushWithGit(context.Background(), th.Repository, test.input)
+			require.NoError(t, err)
+			requireRemoteHead(t, th)
+		}()
+	}
+}
+
+func TestPushWithGoGit(t *testing.T) {
+	var tests = []struct {
+		input *PushOptions
+	}{
+		{testPushopts1},
+		{testPushopts2},
+	}
+	for _, test := range tests {
+		func() {
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+			git.CreateTestCommit(t, th.RepoPath, "push.txt")
+
+			err := pushWithGoGit(context.Background(), th.Repository, test.input)
+			require.NoError(t, err)
+			requireRemoteHead(t, th)
+		}()
+	}
+}
+
+func TestPush(t *testing.T) {
+	var tests = []struct {
+		mode Mode
+		tags bool
+	}{
+		{ModeLegacy, false},
+		{ModeLegacy, true},
+		{ModeNative, false},
+		{ModeNative, true},
+	}
+	for _, test := range tests {
+		func() {
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+			git.RunTestGit(t, th.RepoPath, "tag", "light")
+			git.CreateTestCommit(t, th.RepoPath, "push.txt")
+			git.RunTestGit(t, th.RepoPath, "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+			require.NoError(t, th.Repository.Refresh())
+
+			err := Push(th.Repository, &PushOptions{RemoteName: "origin", Tags: test.tags, CommandMode: test.mode})
+			require.NoError(t, err)
+			requireRemoteHead(t, th)
+			require.Equal(t, git.Success, th.Repository.WorkStatus())
+
+			// every local tag is pushed, annotated or not
+			tags := strings.Fields(git.RunTestGit(t, th.RemoteRepoPath(), "tag"))
+			if test.tags {
+				require.ElementsMatch(t, []string{"light", "v1.0.0"}, tags)
+			} else {
+				require.Empty(t, tags)
+			}
+		}()
+	}
+}
+
+func TestPushRejected(t *testing.T) {
+	var tests = []struct {
+		mode Mode
+	}{
+		{ModeLegacy},
+		{ModeNative},
+	}
+	for _, test := range tests {
+		func() {
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+
+			// the remote moves on without the knowledge of the repository
+			other := filepath.Join(filepath.Dir(th.RepoPath), "other")
+			git.RunTestGit(t, filepath.Dir(th.RepoPath), "clone", th.RemoteRepoPath(), other)
+			git.CreateTestCommit(t, other, "other.txt")
+			git.RunTestGit(t, other, "push", "origin", "master")
+			remote := git.RunTestGit(t, th.RemoteRepoPath(), "rev-parse", "master")
+
+			git.CreateTestCommit(t, th.RepoPath, "push.txt")
+			require.NoError(t, th.Repository.Refresh())
+			var opts = []*PushOptions{
+				{RemoteName: "origin", CommandMode: test.mode},
+				{RemoteName: "origin", ForceWithLease: true, CommandMode: test.mode},
+			}
+			for _, o := range opts {
+				err := Push(th.Repository, o)
+				require.ErrorIs(t, err, gerr.ErrNonFastForward)
+				require.Equal(t, remote, git.RunTestGit(t, th.RemoteRepoPath(), "rev-parse", "master"))
+			}
+		}()
+	}
+}
+
+func TestPushSetUpstream(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "feature")
+	require.NoError(t, th.Repository.Refresh())
+	require.Nil(t, th.Repository.State.Branch.Upstream)
+
+	err := Push(th.Repository, &PushOptions{
+		RemoteName:  "origin",
+		SetUpstream: true,
+		CommandMode: ModeNative,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, th.Repository.State.Branch.Upstream)
+	require.Equal(t, "origin/feature", th.Repository.State.Branch.Upstream.Name)
+}
+
+func TestPushDetachedHead(t *testing.T) {
+	var tests = []struct {
+		mode Mode
+	}{
+		{ModeLegacy},
+		{ModeNative},
+	}
+	for _, test := range tests {
+		func() {
+			th := git.InitTestRepositoryWithLocalRemote(t)
+			defer th.CleanUp(t)
+
+			git.RunTestGit(t, th.RepoPath, "checkout", "--detach")
+			require.NoError(t, th.Repository.Refresh())
+			err := Push(th.Repository, &PushOptions{
+				RemoteName:  "origin",
+				SetUpstream: true,
+				CommandMode: test.mode,
+			})
+			require.ErrorIs(t, err, gerr.ErrDetachedHead)
+			// the hash of the commit is never taken for a branch
+			hash := strings.TrimSpace(git.RunTestGit(t, th.RepoPath, "rev-parse", "HEAD"))
+			require.Empty(t, git.RunTestGit(t, th.RemoteRepoPath(), "branch", "--list", hash))
+			cfg, err := th.Repository.Repo.Config()
+			require.NoError(t, err)
+			require.NotContains(t, cfg.Branches, hash)
+		}()
+	}
+}
+
+func requireRemoteHead(t *testing.T, th *git.TestHelper) {
+	local := git.RunTestGit(t, th.RepoPath, "rev-parse", "HEAD")
+	remote := git.RunTestGit(t, th.RemoteRepoPath(), "rev-parse", "master")
+	require.Equal(t, strings.TrimSpace(local), strings.TrimSpace(remote))
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"sort"
+
+	"github.com/go-git/go-git/v5/config"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// AddRemote adds a remote with the default fetch refspec to the repository,
+// it is the equivalent of "git remote add <name> <url>"
+func AddRemote(r *git.Repository, name, url string) error {
+	_, err := r.Repo.CreateRemote(&config.RemoteConfig{
+		Name: name,
+		URLs: []string{url},
+	})
+	return err
+}
+
+// MissingRemotes returns the names of the given remotes that the repository
+// does not have yet
+func MissingRemotes(r *git.Repository, remotes map[string]string) ([]string, error) {
+	cfg, err := r.Repo.Config()
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0)
+	for name := range remotes {
+		if _, ok := cfg.Remotes[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
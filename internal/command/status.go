@@ -10,16 +10,40 @@ import (
 	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
-func shortStatus(r *git.Repository, option string) string {
+// statusFields is the count of the fields of the porcelain v2 entries, the
+// path is the last field and it may contain spaces
+var statusFields = map[byte]int{'1': 9, '2': 10, 'u': 11}
+
+// shortStatus returns the status in the porcelain v2 format, the entries are
+// separated with NUL so that the paths are never quoted
+func shortStatus(r *git.Repository, option string) (string, error) {
 	args := make([]string, 0)
 	args = append(args, "status")
 	args = append(args, option)
-	args = append(args, "--short")
-	out, err := Run(r.AbsPath, "git", args)
-	if err != nil {
-		return "?"
+	args = append(args, "--porcelain=v2", "-z")
+	return Run(r.AbsPath, "git", args)
+}
+
+// parseStatusEntry returns the staged and the worktree states and the path of
+// a porcelain v2 entry, ok is false for the entries that are not files
+func parseStatusEntry(entry string) (x, y git.FileStatus, path string, ok bool) {
+	if len(entry) < 3 {
+		return x, y, path, false
+	}
+	if entry[0] == '?' || entry[0] == '!' {
+		return git.FileStatus(entry[0]), git.FileStatus(entry[0]), entry[2:], true
 	}
-	return out
+	n, known := statusFields[entry[0]]
+	if !known {
+		return x, y, path, false
+	}
+	fields := strings.SplitN(entry, " ", n)
+	if len(fields) != n || len(fields[1]) != 2 {
+		return x, y, path, false
+	}
+	// unchanged states are written as dots instead of spaces
+	xy := strings.ReplaceAll(fields[1], ".", " ")
+	return git.FileStatus(xy[0]), git.FileStatus(xy[1]), fields[n-1], true
 }
 
 // Status returns the dirty files
@@ -53,22 +77,25 @@ func PlainStatus(r *git.Repository) (string, error) {
 // structured way
 func statusWithGit(r *git.Repository) ([]*git.File, error) {
 	files := make([]*git.File, 0)
-	output := shortStatus(r, "--untracked-files=all")
-	if len(output) == 0 {
-		return files, nil
+	output, err := shortStatus(r, "--untracked-files=all")
+	if err != nil {
+		return files, err
 	}
-	fileslist := strings.Split(output, "\n")
-	for _, file := range fileslist {
-		x := byte(file[0])
-		y := byte(file[1])
-		relativePathRegex := regexp.MustCompile(`[(\w|/|.|\-)]+`)
-		path := relativePathRegex.FindString(file[2:])
-
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		x, y, path, ok := parseStatusEntry(entries[i])
+		if !ok {
+			continue
+		}
+		// the original path of a renamed file is the next entry
+		if entries[i][0] == '2' {
+			i++
+		}
 		files = append(files, &git.File{
 			Name:    path,
 			AbsPath: r.AbsPath + string(os.PathSeparator) + path,
-			X:       git.FileStatus(x),
-			Y:       git.FileStatus(y),
+			X:       x,
+			Y:       y,
 		})
 	}
 	sort.Sort(git.FilesAlphabetical(files))
@@ -26,6 +26,45 @@ func TestStatusWithGit(t *testing.T) {
 	}
 }
 
+func TestParseStatusEntry(t *testing.T) {
+	var tests = []struct {
+		input string
+		x     git.FileStatus
+		y     git.FileStatus
+		path  string
+		ok    bool
+	}{
+		{"1 .M N... 100644 100644 100644 3b18e51 3b18e51 a file.txt", git.StatusNotupdated, git.StatusModified, "a file.txt", true},
+		{"1 A. N... 000000 100644 100644 0000000 3b18e51 new.txt", git.StatusAdded, git.StatusNotupdated, "new.txt", true},
+		{"2 R. N... 100644 100644 100644 3b18e51 3b18e51 R100 new name.txt", git.StatusRenamed, git.StatusNotupdated, "new name.txt", true},
+		{"u UU N... 100644 100644 100644 100644 3b18e51 3b18e51 3b18e51 a.txt", git.StatusUpdated, git.StatusUpdated, "a.txt", true},
+		{"? untracked file", git.StatusUntracked, git.StatusUntracked, "untracked file", true},
+		{"# branch.oid 3b18e51", 0, 0, "", false},
+		{"", 0, 0, "", false},
+	}
+	for _, test := range tests {
+		x, y, path, ok := parseStatusEntry(test.input)
+		require.Equal(t, test.ok, ok, test.input)
+		require.Equal(t, test.x, x, test.input)
+		require.Equal(t, test.y, y, test.input)
+		require.Equal(t, test.path, path, test.input)
+	}
+}
+
+func TestStatusWithGitPaths(t *testing.T) {
+	th := git.InitTestRepositoryFromLocal(t)
+	defer th.CleanUp(t)
+
+	_, err := testFile(th.RepoPath, "file with spaces")
+	require.NoError(t, err)
+
+	files, err := statusWithGit(th.Repository)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "file with spaces", files[0].Name)
+	require.Equal(t, git.StatusUntracked, files[0].X)
+}
+
 func TestStatusWithGoGit(t *testing.T) {
 	th := git.InitTestRepositoryFromLocal(t)
 	defer th.CleanUp(t)
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+)
+
+// Strategy defines how the changes of the upstream are incorporated into the
+// current branch by pull and merge
+type Strategy string
+
+const (
+	// StrategyDefault fast-forwards natively if possible and lets git decide
+	// otherwise
+	StrategyDefault Strategy = ""
+	// StrategyFastForward only updates the branch if it has not diverged from
+	// its upstream
+	StrategyFastForward Strategy = "ff-only"
+	// StrategyRebase rebases the local commits onto the upstream
+	StrategyRebase Strategy = "rebase"
+	// StrategyRebaseAutostash is same as StrategyRebase but the local changes
+	// are stashed before and applied after the rebase
+	StrategyRebaseAutostash Strategy = "rebase-autostash"
+	// StrategyMerge creates a merge commit if the branch has diverged
+	StrategyMerge Strategy = "merge"
+)
+
+// Strategies is the list of the strategies in the order they are offered
+var Strategies = []Strategy{
+	StrategyDefault,
+	StrategyFastForward,
+	StrategyRebase,
+	StrategyRebaseAutostash,
+	StrategyMerge,
+}
+
+// ParseStrategy returns the strategy of the given name
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return StrategyDefault, fmt.Errorf("unrecognized strategy: %s", name)
+}
+
+// Next returns the strategy that follows s, it wraps around
+func (s Strategy) Next() Strategy {
+	for i, strategy := range Strategies {
+		if strategy == s {
+			return Strategies[(i+1)%len(Strategies)]
+		}
+	}
+	return StrategyDefault
+}
+
+// pullArgs are the arguments of "git pull" for the strategy
+func (s Strategy) pullArgs() []string {
+	switch s {
+	case StrategyFastForward:
+		return []string{"--ff-only"}
+	case StrategyRebase:
+		return []string{"--rebase"}
+	case StrategyRebaseAutostash:
+		return []string{"--rebase", "--autostash"}
+	case StrategyMerge:
+		return []string{"--no-rebase"}
+	}
+	return nil
+}
+
+// mergeArgs are the git command and its arguments to incorporate a branch
+// into the current one, rebasing is done with "git rebase" instead of merge
+func (s Strategy) mergeArgs() []string {
+	switch s {
+	case StrategyFastForward:
+		return []string{"merge", "--ff-only"}
+	case StrategyRebase:
+		return []string{"rebase"}
+	case StrategyRebaseAutostash:
+		return []string{"rebase", "--autostash"}
+	}
+	return []string{"merge"}
+}
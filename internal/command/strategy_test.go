@@ -0,0 +1,98 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+// divergedRepository pushes a commit to the remote from another clone and
+// commits locally, so that the branch diverges from its upstream
+func divergedRepository(t *testing.T) *git.TestHelper {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	other := filepath.Join(filepath.Dir(th.RepoPath), "other")
+	git.RunTestGit(t, filepath.Dir(th.RepoPath), "clone", th.RemoteRepoPath(), other)
+	git.CreateTestCommit(t, other, "remote.txt")
+	git.RunTestGit(t, other, "push", "origin", "master")
+	git.CreateTestCommit(t, th.RepoPath, "local.txt")
+	// rebase and merge commits are created by the commands under test
+	git.RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	git.RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@localhost")
+	return th
+}
+
+func TestParseStrategy(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Strategy
+		valid    bool
+	}{
+		{"", StrategyDefault, true},
+		{"ff-only", StrategyFastForward, true},
+		{"rebase-autostash", StrategyRebaseAutostash, true},
+		{"squash", StrategyDefault, false},
+	}
+	for _, test := range tests {
+		s, err := ParseStrategy(test.input)
+		require.Equal(t, test.valid, err == nil)
+		require.Equal(t, test.expected, s)
+	}
+	require.Equal(t, StrategyDefault, StrategyMerge.Next())
+}
+
+func TestPullStrategies(t *testing.T) {
+	var tests = []struct {
+		strategy Strategy
+		mode     Mode
+		expected error
+	}{
+		{StrategyFastForward, ModeNative, gerr.ErrDiverged},
+		{StrategyFastForward, ModeLegacy, gerr.ErrDiverged},
+		{StrategyRebase, ModeNative, nil},
+		{StrategyMerge, ModeLegacy, nil},
+	}
+	for _, test := range tests {
+		th := divergedRepository(t)
+		r, err := git.InitializeRepo(th.RepoPath)
+		require.NoError(t, err)
+		err = Pull(r, &PullOptions{
+			RemoteName:  "origin",
+			Strategy:    test.strategy,
+			CommandMode: test.mode,
+		})
+		require.ErrorIs(t, err, test.expected)
+		parents := strings.Fields(git.RunTestGit(t, th.RepoPath, "log", "-1", "--format=%P"))
+		switch test.strategy {
+		case StrategyRebase:
+			// the history is linear
+			require.Len(t, parents, 1)
+			require.Contains(t, git.RunTestGit(t, th.RepoPath, "log", "--format=%s"), "add remote.txt")
+		case StrategyMerge:
+			require.Len(t, parents, 2)
+		}
+		th.CleanUp(t)
+	}
+}
+
+func TestMergeStrategies(t *testing.T) {
+	th := divergedRepository(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "fetch", "origin")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	err = Merge(r, &MergeOptions{BranchName: "origin/master", Strategy: StrategyFastForward})
+	require.ErrorIs(t, err, gerr.ErrDiverged)
+
+	// local changes are stashed around the rebase
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, "local.txt"), []byte("changed"), 0644))
+	err = Merge(r, &MergeOptions{BranchName: "origin/master", Strategy: StrategyRebaseAutostash})
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(git.RunTestGit(t, th.RepoPath, "log", "-1", "--format=%P")), 1)
+	require.Contains(t, git.RunTestGit(t, th.RepoPath, "status", "--porcelain"), "local.txt")
+}
@@ -0,0 +1,293 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+)
+
+// TagOptions defines the rules for tag operation
+type TagOptions struct {
+	// Name of the tag
+	Name string
+	// Message makes the tag annotated, a lightweight tag is created if it is
+	// empty and the tag is not signed.
+	Message string
+	// Target is the branch to be tagged. Defaults to HEAD.
+	Target string
+	// Sign creates a GPG-signed tag with the default key of the user.
+	Sign bool
+	// Push sends the tag to the remote after it is created.
+	Push bool
+	// Name of the remote to push to. Defaults to origin.
+	RemoteName string
+	// Credentials holds the user and password information
+	Credentials *git.Credentials
+	// Sources are the configured tokens, the credentials of a remote are
+	// read from the matching one if no credentials are given for its host
+	Sources CredentialSources
+	// Mode is the command mode
+	CommandMode Mode
+}
+
+// Tag creates a tag on the target branch of the repository
+func Tag(r *git.Repository, o *TagOptions) error {
+	return TagWithContext(context.Background(), r, o)
+}
+
+// TagWithContext is same as Tag but the push is aborted when the context is
+// done
+func TagWithContext(ctx context.Context, r *git.Repository, o *TagOptions) (err error) {
+	mode := o.CommandMode
+	// go-git needs the private key to sign, rely on git and its gpg setup
+	if o.Sign {
+		mode = ModeLegacy
+	}
+	switch mode {
+	case ModeLegacy:
+		err = tagWithGit(r, o)
+	case ModeNative:
+		err = tagWithGoGit(r, o)
+	}
+	// a retried job finds the tag it created before its push failed, only
+	// the push is left to do then
+	if errors.Is(err, gerr.ErrTagExists) && o.Push && tagOnTarget(r, o) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	msg := "tagged " + o.Name
+	if o.Push {
+		if err := pushTag(ctx, r, o); err != nil {
+			return err
+		}
+		msg = msg + " and pushed to " + o.RemoteName
+	}
+	r.SetWorkStatus(git.Success)
+	r.State.Message = msg
+	return r.Refresh()
+}
+
+// TagBlocker returns the reason that the target branch of the repository
+// should not be tagged, nil if it is clean and not behind its upstream. The
+// checked out branch is the target if it is empty
+func TagBlocker(r *git.Repository, target string) error {
+	b := r.State.Branch
+	if b == nil {
+		return gerr.ErrReferenceBroken
+	}
+	if len(target) > 0 && target != b.Name {
+		// the uncommitted changes are never on a branch that is not
+		// checked out
+		behind, err := branchBehind(r, target)
+		if err != nil {
+			return gerr.ErrReferenceBroken
+		}
+		if behind {
+			return gerr.ErrBehindUpstream
+		}
+		return nil
+	}
+	if !b.Clean {
+		return gerr.ErrUncommittedChanges
+	}
+	if n, err := strconv.Atoi(b.Pullables); err == nil && n > 0 {
+		return gerr.ErrBehindUpstream
+	}
+	return nil
+}
+
+// branchBehind returns true if the upstream of the local branch has commits
+// that the branch does not have, a branch without upstream is never behind
+func branchBehind(r *git.Repository, branch string) (bool, error) {
+	ref, err := r.Repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return false, err
+	}
+	cfg, err := r.Repo.Config()
+	if err != nil {
+		return false, err
+	}
+	bc, ok := cfg.Branches[branch]
+	if !ok || len(bc.Remote) == 0 || bc.Remote == "." || len(bc.Merge) == 0 {
+		return false, nil
+	}
+	upstream, err := r.Repo.Reference(plumbing.NewRemoteReferenceName(bc.Remote, bc.Merge.Short()), true)
+	if err != nil || upstream.Hash() == ref.Hash() {
+		return false, nil
+	}
+	local, err := r.Repo.CommitObject(ref.Hash())
+	if err != nil {
+		return false, err
+	}
+	remote, err := r.Repo.CommitObject(upstream.Hash())
+	if err != nil {
+		return false, err
+	}
+	contained, err := remote.IsAncestor(local)
+	if err != nil {
+		return false, err
+	}
+	return !contained, nil
+}
+
+// tagOnTarget returns true if the tag already points to the commit of the
+// target branch
+func tagOnTarget(r *git.Repository, o *TagOptions) bool {
+	ref, err := r.Repo.Reference(plumbing.NewTagReferenceName(o.Name), true)
+	if err != nil {
+		return false
+	}
+	tag := ref.Hash()
+	// the annotated tags point to the tag object
+	if t, err := r.Repo.TagObject(tag); err == nil {
+		tag = t.Target
+	}
+	target := plumbing.Revision("HEAD")
+	if len(o.Target) > 0 {
+		target = plumbing.Revision(plumbing.NewBranchReferenceName(o.Target).String())
+	}
+	commit, err := r.Repo.ResolveRevision(target)
+	return err == nil && tag == *commit
+}
+
+// tagWithGit is simply a git tag [-a|-s] [-m <msg>] <name> [<target>]
+func tagWithGit(r *git.Repository, o *TagOptions) error {
+	args := []string{"tag"}
+	if o.Sign {
+		args = append(args, "-s")
+	} else if len(o.Message) > 0 {
+		args = append(args, "-a")
+	}
+	if len(o.Message) > 0 {
+		args = append(args, "-m", o.Message)
+	} else if o.Sign {
+		args = append(args, "-m", o.Name)
+	}
+	args = append(args, o.Name)
+	if len(o.Target) > 0 {
+		args = append(args, o.Target)
+	}
+	if out, err := Run(r.AbsPath, "git", args); err != nil {
+		return gerr.ParseGitError(out, err)
+	}
+	return nil
+}
+
+// tagWithGoGit creates the tag natively, git is used if the tagger cannot be
+// resolved from the config
+func tagWithGoGit(r *git.Repository, o *TagOptions) error {
+	ref, err := r.Repo.Head()
+	if len(o.Target) > 0 {
+		ref, err = r.Repo.Reference(plumbing.NewBranchReferenceName(o.Target), true)
+	}
+	if err != nil {
+		return gerr.ErrReferenceBroken
+	}
+	var opts *gogit.CreateTagOptions
+	if len(o.Message) > 0 {
+		opts = &gogit.CreateTagOptions{
+			Message: o.Message,
+		}
+	}
+	if _, err := r.Repo.CreateTag(o.Name, ref.Hash(), opts); err != nil {
+		if err == gogit.ErrTagExists {
+			return gerr.ErrTagExists
+		}
+		return tagWithGit(r, o)
+	}
+	return nil
+}
+
+// pushTag pushes only the tag to the remote, the remote is authenticated the
+// same way as the push operation does
+func pushTag(ctx context.Context, r *git.Repository, o *TagOptions) error {
+	if o.CommandMode == ModeLegacy {
+		return pushTagWithGit(ctx, r, o)
+	}
+	ref := plumbing.NewTagReferenceName(o.Name).String()
+	opt := &gogit.PushOptions{
+		RemoteName: o.RemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(ref + ":" + ref)},
+	}
+	rawURL := remoteURL(r, o.RemoteName)
+	creds, err := remoteCredentials(rawURL, o.Credentials, o.Sources)
+	if err != nil {
+		return err
+	}
+	auth, err := authMethod(rawURL, creds)
+	if err == errNoNativeAuth {
+		return pushTagWithGit(ctx, r, o)
+	} else if err != nil {
+		return err
+	}
+	opt.Auth = auth
+	err = pushNewTag(ctx, r, opt)
+	if c, auth := challengeAuth(r.AbsPath, rawURL, opt.Auth, err); auth != nil {
+		// the server requires credentials, try once more with the known ones
+		creds, opt.Auth = c, auth
+		err = pushNewTag(ctx, r, opt)
+	}
+	reportCredentials(r.AbsPath, rawURL, opt.Auth, creds, err)
+	if err == nil || err == gogit.NoErrAlreadyUpToDate {
+		return nil
+	} else if ctx.Err() != nil {
+		return ctx.Err()
+	} else if aerr := authError(err); aerr != nil {
+		return aerr
+	} else if unsupportedPush(err) {
+		return pushTagWithGit(ctx, r, o)
+	} else if err == gogit.ErrForceNeeded || strings.Contains(err.Error(), "non-fast-forward update") {
+		// the tag of the remote points to another commit
+		return &gerr.Error{Category: gerr.ErrTagExists, ExitCode: -1, Output: err.Error(), Err: err}
+	}
+	return gerr.ParseGitError(err.Error(), err)
+}
+
+// pushNewTag pushes the tag of the refspec unless the remote has it already.
+// go-git moves a tag of the remote to a descendant commit, git refuses to
+func pushNewTag(ctx context.Context, r *git.Repository, opt *gogit.PushOptions) error {
+	name := plumbing.ReferenceName(opt.RefSpecs[0].Src())
+	local, err := r.Repo.Reference(name, false)
+	if err != nil {
+		return err
+	}
+	rm, err := r.Repo.Remote(opt.RemoteName)
+	if err != nil {
+		return err
+	}
+	refs, err := rm.ListContext(ctx, &gogit.ListOptions{Auth: opt.Auth})
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if ref.Name() != name {
+			continue
+		} else if ref.Hash() == local.Hash() {
+			return gogit.NoErrAlreadyUpToDate
+		}
+		return gogit.ErrForceNeeded
+	}
+	return r.Repo.PushContext(ctx, opt)
+}
+
+// pushTagWithGit is simply a bare git push <remote> <tag> command
+func pushTagWithGit(ctx context.Context, r *git.Repository, o *TagOptions) error {
+	ref := plumbing.NewTagReferenceName(o.Name).String()
+	args := []string{"push", o.RemoteName, ref}
+	if out, err := RunWithContext(ctx, r.AbsPath, "git", args); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return gerr.ParseGitError(out, err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTag(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	git.RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@localhost")
+	git.RunTestGit(t, th.RepoPath, "branch", "release")
+	git.CreateTestCommit(t, th.RepoPath, "next.txt")
+
+	var tests = []struct {
+		opts      *TagOptions
+		annotated bool
+	}{
+		{&TagOptions{Name: "v1.0.0", CommandMode: ModeNative}, false},
+		{&TagOptions{Name: "v1.0.1", Message: "patch", CommandMode: ModeNative}, true},
+		{&TagOptions{Name: "v1.0.2", Message: "patch", CommandMode: ModeLegacy}, true},
+		{&TagOptions{Name: "v1.0.3", Target: "release", Push: true, RemoteName: "origin", CommandMode: ModeNative}, false},
+	}
+	for _, test := range tests {
+		r, err := git.InitializeRepo(th.RepoPath)
+		require.NoError(t, err)
+		require.NoError(t, Tag(r, test.opts))
+
+		kind := git.RunTestGit(t, th.RepoPath, "cat-file", "-t", test.opts.Name)
+		if test.annotated {
+			require.Equal(t, "tag\n", kind)
+		} else {
+			require.Equal(t, "commit\n", kind)
+		}
+		target := "HEAD"
+		if len(test.opts.Target) > 0 {
+			target = test.opts.Target
+		}
+		require.Equal(t, git.RunTestGit(t, th.RepoPath, "rev-parse", target),
+			git.RunTestGit(t, th.RepoPath, "rev-parse", test.opts.Name+"^{commit}"))
+	}
+	require.Contains(t, git.RunTestGit(t, th.RemoteRepoPath(), "tag"), "v1.0.3")
+	require.NotContains(t, git.RunTestGit(t, th.RemoteRepoPath(), "tag"), "v1.0.0")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Equal(t, gerr.ErrTagExists, Tag(r, &TagOptions{Name: "v1.0.0", CommandMode: ModeNative}))
+
+	// a retry pushes the tag that is created by the failed attempt
+	require.NoError(t, Tag(r, &TagOptions{Name: "v1.0.1", Message: "patch", Push: true, RemoteName: "origin", CommandMode: ModeNative}))
+	require.Contains(t, git.RunTestGit(t, th.RemoteRepoPath(), "tag"), "v1.0.1")
+	// but a tag on another commit is never moved
+	err = Tag(r, &TagOptions{Name: "v1.0.3", Push: true, RemoteName: "origin", CommandMode: ModeNative})
+	require.ErrorIs(t, err, gerr.ErrTagExists)
+}
+
+func TestTagPushRejected(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	// the remote has the tag on another commit
+	git.RunTestGit(t, th.RepoPath, "tag", "v1.0.0")
+	git.RunTestGit(t, th.RepoPath, "push", "origin", "v1.0.0")
+	remote := git.RunTestGit(t, th.RemoteRepoPath(), "rev-parse", "v1.0.0")
+	git.RunTestGit(t, th.RepoPath, "tag", "-d", "v1.0.0")
+	git.CreateTestCommit(t, th.RepoPath, "tag.txt")
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	err = Tag(r, &TagOptions{Name: "v1.0.0", Push: true, RemoteName: "origin", CommandMode: ModeNative})
+	require.ErrorIs(t, err, gerr.ErrTagExists)
+	require.Equal(t, remote, git.RunTestGit(t, th.RemoteRepoPath(), "rev-parse", "v1.0.0"))
+}
+
+func TestTagBlocker(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	r, err := git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.NoError(t, TagBlocker(r, ""))
+
+	// the remote moves on
+	other := filepath.Join(filepath.Dir(th.RepoPath), "other")
+	git.RunTestGit(t, filepath.Dir(th.RepoPath), "clone", th.RemoteRepoPath(), other)
+	git.CreateTestCommit(t, other, "remote.txt")
+	git.RunTestGit(t, other, "push", "origin", "master")
+	git.RunTestGit(t, th.RepoPath, "fetch", "origin")
+	r, err = git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Equal(t, gerr.ErrBehindUpstream, TagBlocker(r, ""))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, "README.md"), []byte("changed"), 0644))
+	r, err = git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Equal(t, gerr.ErrUncommittedChanges, TagBlocker(r, ""))
+
+	// the target branch is checked instead of the checked out one
+	git.RunTestGit(t, th.RepoPath, "branch", "--track", "current", "origin/master")
+	git.RunTestGit(t, th.RepoPath, "branch", "--track", "stale", "master")
+	git.RunTestGit(t, th.RepoPath, "branch", "--set-upstream-to", "origin/master", "stale")
+	git.RunTestGit(t, th.RepoPath, "branch", "local")
+	r, err = git.InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	var tests = []struct {
+		target   string
+		expected error
+	}{
+		{"current", nil},
+		{"local", nil},
+		{"stale", gerr.ErrBehindUpstream},
+		{"master", gerr.ErrUncommittedChanges},
+		{"missing", gerr.ErrReferenceBroken},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, TagBlocker(r, test.target), test.target)
+	}
+}
@@ -1,7 +1,16 @@
 package errors
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
 	"strings"
+	"syscall"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
 // GitError is the errors from git package
@@ -39,15 +48,76 @@ const (
 	ErrConflictAfterMerge GitError = ("conflict while merging")
 	// ErrUnmergedFiles possibly occurs after a conflict
 	ErrUnmergedFiles GitError = ("unmerged files detected")
+	// ErrConflictMarkers is thrown when a file is marked as resolved while it
+	// still has conflict markers
+	ErrConflictMarkers GitError = ("conflict markers left in file")
+	// ErrNothingInProgress is thrown when there is no merge or rebase to be
+	// aborted or continued
+	ErrNothingInProgress GitError = ("no merge or rebase in progress")
 	// ErrReferenceBroken thrown when unable to resolve reference
 	ErrReferenceBroken GitError = ("unable to resolve reference")
 	// ErrPermissionDenied is thrown when ssh authentication occurs
 	ErrPermissionDenied GitError = ("permission denied")
+	// ErrPassphraseRequired is thrown when the ssh key is encrypted and no
+	// passphrase is given
+	ErrPassphraseRequired GitError = ("passphrase required for ssh key")
+	// ErrUnknownHostKey is thrown when the host key of a ssh remote is not in
+	// the known_hosts file
+	ErrUnknownHostKey GitError = ("host key is not known")
+	// ErrHostKeyMismatch is thrown when the host key of a ssh remote differs
+	// from the one in the known_hosts file
+	ErrHostKeyMismatch GitError = ("host key mismatch")
 	// ErrOverwrittenByMerge is the thrown when there is un-tracked files on working tree
 	ErrOverwrittenByMerge GitError = ("move or remove un-tracked files before merge")
 	// ErrUserEmailNotSet is thrown if there is no configured user email while
 	// commit command
 	ErrUserEmailNotSet GitError = ("user email not configured")
+	// ErrConnectionTimedOut is thrown when the remote does not respond in time
+	ErrConnectionTimedOut GitError = ("connection timed out")
+	// ErrConnectionReset is thrown when the connection to the remote is
+	// dropped in the middle of an operation
+	ErrConnectionReset GitError = ("connection reset by remote")
+	// ErrRemoteServerError is thrown when the remote answers with a HTTP 5xx
+	// status code
+	ErrRemoteServerError GitError = ("remote server error")
+	// ErrBareRepository is thrown when an operation requires a worktree but
+	// the repository is bare
+	ErrBareRepository GitError = ("bare repository has no worktree")
+	// ErrDiverged is thrown when a fast-forward only pull or merge cannot
+	// proceed because the branch and its upstream have diverged
+	ErrDiverged GitError = ("branch has diverged, cannot fast-forward")
+	// ErrConflictAfterRebase is thrown when a rebase stops at a conflicting
+	// commit
+	ErrConflictAfterRebase GitError = ("conflict while rebasing")
+	// ErrUncommittedChanges is thrown when an operation requires a clean
+	// worktree, e.g. tagging a release
+	ErrUncommittedChanges GitError = ("uncommitted changes")
+	// ErrBehindUpstream is thrown when the branch misses commits of its
+	// upstream
+	ErrBehindUpstream GitError = ("behind upstream")
+	// ErrTagExists is thrown when a tag with the same name already exists
+	ErrTagExists GitError = ("tag already exists")
+	// ErrNetworkUnreachable is thrown when the host of the remote cannot be
+	// resolved or reached
+	ErrNetworkUnreachable GitError = ("network unreachable")
+	// ErrNonFastForward is thrown when the remote rejects a push since the
+	// remote branch has commits that are not in the local branch
+	ErrNonFastForward GitError = ("rejected, non-fast-forward")
+	// ErrProtectedBranch is thrown when the remote rejects a push to a
+	// protected branch
+	ErrProtectedBranch GitError = ("protected branch")
+	// ErrLockFile is thrown when a lock file of another git process is in
+	// the way, e.g. index.lock
+	ErrLockFile GitError = ("locked by another git process")
+	// ErrReferenceChanged is thrown when a reference is updated by another
+	// process while git is updating it
+	ErrReferenceChanged GitError = ("reference changed by another process")
+	// ErrDetachedHead is thrown when an operation requires a branch but the
+	// HEAD is detached
+	ErrDetachedHead GitError = ("HEAD is detached")
+	// ErrShallowRepository is thrown when an operation is not possible since
+	// the repository is a shallow clone
+	ErrShallowRepository GitError = ("shallow repository")
 	// ErrUnclassified is unconsidered error type
 	ErrUnclassified GitError = ("unclassified error")
 	// NoErrIterationHalted is thrown for catching stops in interators
@@ -58,9 +128,121 @@ func (e GitError) Error() string {
 	return string(e)
 }
 
-// ParseGitError takes git output as an input and tries to find some meaningful
-// errors can be used by the app
+// Error is a classified error of a git command. The output of git is kept so
+// that it can be shown when the category is not enough
+type Error struct {
+	// Category is the class of the error, ErrUnclassified if the output is
+	// not recognized
+	Category GitError
+	// Repository and Operation tell where the error occurred, they are set
+	// by the job running the operation
+	Repository string
+	Operation  string
+	// ExitCode is the exit code of git, -1 if git did not exit by itself
+	ExitCode int
+	// Output is the stdout and the stderr of git, in the order they are
+	// written
+	Output string
+	// Err is the error of running the command
+	Err error
+}
+
+// Error returns the category, or the most relevant line of the output if the
+// error is not classified
+func (e *Error) Error() string {
+	if e.Category != ErrUnclassified {
+		return string(e.Category)
+	}
+	if line := relevantLine(e.Output); len(line) > 0 {
+		return line
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return string(e.Category)
+}
+
+// Is reports whether the category of the error is the target, so that
+// errors.Is(err, ErrAuthorizationFailed) works as before
+func (e *Error) Is(target error) bool {
+	return e.Category == target
+}
+
+// Unwrap returns the error of running the command
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Raw returns the full description of the error with the output of git
+func (e *Error) Raw() string {
+	var b strings.Builder
+	if len(e.Repository) > 0 {
+		b.WriteString(e.Repository + ": ")
+	}
+	if len(e.Operation) > 0 {
+		b.WriteString(e.Operation + " ")
+	}
+	b.WriteString(string(e.Category))
+	if e.ExitCode >= 0 {
+		fmt.Fprintf(&b, " (exit code %d)", e.ExitCode)
+	}
+	b.WriteString("\n")
+	if len(strings.TrimSpace(e.Output)) > 0 {
+		b.WriteString("\n" + strings.TrimRight(e.Output, "\n") + "\n")
+	} else if e.Err != nil {
+		b.WriteString("\n" + e.Err.Error() + "\n")
+	}
+	return b.String()
+}
+
+// relevantLine returns the first fatal or error line of the output, or its
+// last line if there is none
+func relevantLine(out string) string {
+	last := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "fatal:") || strings.HasPrefix(line, "error:") {
+			return line
+		}
+		if len(line) > 0 {
+			last = line
+		}
+	}
+	return last
+}
+
+// ParseGitError takes git output as an input and classifies it, the output
+// and the exit code are kept in the returned *Error
 func ParseGitError(out string, err error) error {
+	e := &Error{
+		Category: classifyOutput(out),
+		ExitCode: -1,
+		Output:   out,
+		Err:      err,
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+	}
+	return e
+}
+
+// CategoryOf returns the category of the error, ErrUnclassified if it is not
+// an error of git
+func CategoryOf(err error) GitError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Category
+	}
+	var category GitError
+	if errors.As(err, &category) {
+		return category
+	}
+	return ErrUnclassified
+}
+
+// classifyOutput finds the category of the error in the output of git
+func classifyOutput(out string) GitError {
 	if strings.Contains(out, "error: Your local changes to the following files would be overwritten by merge") {
 		return ErrMergeAbortedTryCommit
 	} else if strings.Contains(out, "ERROR: Repository not found") {
@@ -69,6 +251,16 @@ func ParseGitError(out string, err error) error {
 		return ErrRemoteBranchNotSpecified
 	} else if strings.Contains(out, "Automatic merge failed; fix conflicts and then commit the result") {
 		return ErrConflictAfterMerge
+	} else if strings.Contains(out, "Not possible to fast-forward") ||
+		strings.Contains(out, "Need to specify how to reconcile divergent branches") {
+		return ErrDiverged
+	} else if rebaseApplyRegex.MatchString(out) ||
+		strings.Contains(out, "Resolve all conflicts manually") {
+		return ErrConflictAfterRebase
+	} else if strings.Contains(out, "You have unstaged changes") {
+		return ErrMergeAbortedTryCommit
+	} else if strings.Contains(out, "fatal: tag '") && strings.Contains(out, "already exists") {
+		return ErrTagExists
 	} else if strings.Contains(out, "error: Pulling is not possible because you have unmerged files.") {
 		return ErrUnmergedFiles
 	} else if strings.Contains(out, "unable to resolve reference") {
@@ -77,8 +269,86 @@ func ParseGitError(out string, err error) error {
 		return ErrUserEmailNotSet
 	} else if strings.Contains(out, "Permission denied (publickey)") {
 		return ErrPermissionDenied
+	} else if strings.Contains(out, "protected branch") ||
+		strings.Contains(out, "Protected branch update failed") {
+		return ErrProtectedBranch
+	} else if strings.Contains(out, "Updates were rejected because the tag already exists") {
+		return ErrTagExists
+	} else if strings.Contains(out, "(non-fast-forward)") || strings.Contains(out, "(fetch first)") ||
+		strings.Contains(out, "(stale info)") ||
+		strings.Contains(out, "Updates were rejected because the tip of your current branch is behind") ||
+		strings.Contains(out, "Updates were rejected because a pushed branch tip is behind") ||
+		strings.Contains(out, "Updates were rejected because the remote contains work") {
+		return ErrNonFastForward
+	} else if strings.Contains(out, ".lock': File exists") {
+		return ErrLockFile
+	} else if strings.Contains(out, "cannot lock ref") && strings.Contains(out, "but expected") {
+		return ErrReferenceChanged
+	} else if strings.Contains(out, "You are not currently on a branch") {
+		return ErrDetachedHead
+	} else if strings.Contains(out, "shallow update not allowed") ||
+		strings.Contains(out, "shallow file has changed") ||
+		strings.Contains(out, "--unshallow on a complete repository") {
+		return ErrShallowRepository
+	} else if strings.Contains(out, "REMOTE HOST IDENTIFICATION HAS CHANGED") {
+		return ErrHostKeyMismatch
+	} else if strings.Contains(out, "Host key verification failed") {
+		return ErrUnknownHostKey
 	} else if strings.Contains(out, "would be overwritten by merge") {
 		return ErrOverwrittenByMerge
+	} else if strings.Contains(out, "Authentication failed") {
+		return ErrAuthorizationFailed
+	} else if strings.Contains(out, "terminal prompts disabled") ||
+		strings.Contains(out, "could not read Username") ||
+		strings.Contains(out, "could not read Password") {
+		// git asks for the credentials on the terminal, which is disabled
+		return ErrAuthenticationRequired
+	} else if strings.Contains(out, "Could not resolve host") ||
+		strings.Contains(out, "Network is unreachable") ||
+		strings.Contains(out, "No route to host") ||
+		strings.Contains(out, "Connection refused") {
+		return ErrNetworkUnreachable
+	} else if strings.Contains(out, "timed out") {
+		return ErrConnectionTimedOut
+	} else if strings.Contains(out, "Connection reset by peer") ||
+		strings.Contains(out, "early EOF") ||
+		strings.Contains(out, "the remote end hung up unexpectedly") {
+		return ErrConnectionReset
+	} else if serverErrorRegex.MatchString(out) {
+		return ErrRemoteServerError
 	}
 	return ErrUnclassified
 }
+
+// rebaseApplyRegex matches the commit that rebase stopped at, the conflicts
+// of stash apply are reported without one
+var rebaseApplyRegex = regexp.MustCompile(`error: could not apply [0-9a-f]{4,}\.\.\.`)
+
+// serverErrorRegex matches the HTTP 5xx errors reported by git
+var serverErrorRegex = regexp.MustCompile(`(returned error|HTTP|status code):? 5\d\d`)
+
+// IsTransient reports whether the error is likely to disappear if the same
+// operation is tried again later, such as timeouts, dropped connections or
+// server side errors. Authentication failures, conflicts etc. are permanent.
+// Cancellation of the operation is never considered as transient
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch CategoryOf(err) {
+	case ErrConnectionTimedOut, ErrConnectionReset, ErrRemoteServerError, ErrNetworkUnreachable:
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var httpErr *http.Err
+	if errors.As(err, &httpErr) && httpErr.Response != nil {
+		return httpErr.Response.StatusCode >= 500
+	}
+	return false
+}
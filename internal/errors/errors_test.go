@@ -1,7 +1,14 @@
 package errors
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"syscall"
 	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
 func TestParseGitError(t *testing.T) {
@@ -10,10 +17,96 @@ func TestParseGitError(t *testing.T) {
 		expected error
 	}{
 		{"", ErrUnclassified},
+		{"fatal: unable to access 'https://example.com/repo.git/': Failed to connect to example.com port 443 after 130 ms: Connection timed out", ErrConnectionTimedOut},
+		{"error: RPC failed; curl 56 Recv failure: Connection reset by peer", ErrConnectionReset},
+		{"fatal: the remote end hung up unexpectedly", ErrConnectionReset},
+		{"fatal: unable to access 'https://example.com/repo.git/': The requested URL returned error: 503", ErrRemoteServerError},
+		{" ! [rejected]        master -> master (stale info)", ErrNonFastForward},
+		{"fatal: Authentication failed for 'https://example.com/repo.git/'", ErrAuthorizationFailed},
+		{"fatal: could not read Username for 'https://example.com': terminal prompts disabled", ErrAuthenticationRequired},
+		{"fatal: could not read Password for 'https://me@example.com': No such device or address", ErrAuthenticationRequired},
+		{"Automatic merge failed; fix conflicts and then commit the result.", ErrConflictAfterMerge},
+		{"fatal: Not possible to fast-forward, aborting.", ErrDiverged},
+		{"hint: You have divergent branches and need to specify how to reconcile them.\nfatal: Need to specify how to reconcile divergent branches.", ErrDiverged},
+		{"error: could not apply 1a2b3c4... change\nhint: Resolve all conflicts manually", ErrConflictAfterRebase},
+		{"error: could not apply 1a2b3c4... change", ErrConflictAfterRebase},
+		{"Auto-merging file.txt\nCONFLICT (content): Merge conflict in file.txt\nerror: could not apply stash", ErrUnclassified},
+		{"error: cannot pull with rebase: You have unstaged changes.", ErrMergeAbortedTryCommit},
+		{"fatal: tag 'v1.0.0' already exists", ErrTagExists},
+		{"No ED25519 host key is known for example.com and you have requested strict checking.\nHost key verification failed.", ErrUnknownHostKey},
+		{"@    WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!     @\nHost key verification failed.", ErrHostKeyMismatch},
+		{"fatal: unable to access 'https://example.com/repo.git/': Could not resolve host: example.com", ErrNetworkUnreachable},
+		{"ssh: connect to host example.com port 22: Network is unreachable", ErrNetworkUnreachable},
+		{" ! [rejected]        master -> master (fetch first)\nerror: failed to push some refs", ErrNonFastForward},
+		{" ! [rejected]        master -> master (non-fast-forward)", ErrNonFastForward},
+		{"remote: error: GH006: Protected branch update failed for refs/heads/master.\n ! [remote rejected] master -> master (protected branch hook declined)", ErrProtectedBranch},
+		{"fatal: Unable to create '/repo/.git/index.lock': File exists.", ErrLockFile},
+		{"error: cannot lock ref 'refs/remotes/origin/master': Unable to create '/repo/.git/refs/remotes/origin/master.lock': File exists.", ErrLockFile},
+		{"error: cannot lock ref 'refs/remotes/origin/master': is at 1a2b but expected 3c4d", ErrReferenceChanged},
+		{" ! [rejected]        v1.0.0 -> v1.0.0 (already exists)\nhint: Updates were rejected because the tag already exists in the remote.", ErrTagExists},
+		{"hint: Updates were rejected because the tip of your current branch is behind\nhint: its remote counterpart.", ErrNonFastForward},
+		{"hint: Updates were rejected because the remote contains work that you do\nhint: not have locally.", ErrNonFastForward},
+		{"fatal: You are not currently on a branch.", ErrDetachedHead},
+		{" ! [remote rejected] master -> master (shallow update not allowed)", ErrShallowRepository},
 	}
 	for _, test := range tests {
-		if output := ParseGitError(test.input, nil); output != test.expected {
+		if output := ParseGitError(test.input, nil); !errors.Is(output, test.expected) {
 			t.Errorf("Test Failed. %s expected, output: %s", test.expected.Error(), output.Error())
 		}
 	}
 }
+
+func TestIsTransient(t *testing.T) {
+	var tests = []struct {
+		input    error
+		expected bool
+	}{
+		{nil, false},
+		{ErrConnectionTimedOut, true},
+		{ErrConnectionReset, true},
+		{ErrRemoteServerError, true},
+		{ErrAuthenticationRequired, false},
+		{ErrConflictAfterMerge, false},
+		{ErrUnclassified, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{&http.Err{Response: &nethttp.Response{StatusCode: 502}}, true},
+		{&http.Err{Response: &nethttp.Response{StatusCode: 400}}, false},
+	}
+	for _, test := range tests {
+		if output := IsTransient(test.input); output != test.expected {
+			t.Errorf("Test Failed. %v inputted, output: %t, expected: %t", test.input, output, test.expected)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	var tests = []struct {
+		output   string
+		expected string
+	}{
+		{"fatal: Not possible to fast-forward, aborting.", "branch has diverged, cannot fast-forward"},
+		{"hint: something\nfatal: bad revision 'x'\nmore", "fatal: bad revision 'x'"},
+		{"something went wrong\n", "something went wrong"},
+		{"", "unclassified error"},
+	}
+	for _, test := range tests {
+		err := ParseGitError(test.output, nil)
+		if err.Error() != test.expected {
+			t.Errorf("Test Failed. %s expected, output: %s", test.expected, err.Error())
+		}
+	}
+
+	e := &Error{Category: ErrLockFile, Repository: "repo", Operation: "fetch", ExitCode: 128, Output: "fatal: locked\n"}
+	if !errors.Is(fmt.Errorf("wrapped: %w", e), ErrLockFile) || errors.Is(e, ErrUnclassified) {
+		t.Errorf("Test Failed. the category of the error is not matched")
+	}
+	if CategoryOf(fmt.Errorf("wrapped: %w", e)) != ErrLockFile || CategoryOf(ErrTagExists) != ErrTagExists ||
+		CategoryOf(fmt.Errorf("other")) != ErrUnclassified {
+		t.Errorf("Test Failed. wrong category")
+	}
+	if raw := e.Raw(); raw != "repo: fetch locked by another git process (exit code 128)\n\nfatal: locked\n" {
+		t.Errorf("Test Failed. unexpected raw output: %q", raw)
+	}
+}
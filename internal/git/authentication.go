@@ -1,7 +1,9 @@
 package git
 
 import (
+	"errors"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
@@ -12,6 +14,18 @@ type Credentials struct {
 	User string
 	// Password is the secret information required for authentication
 	Password string
+	// KeyFile is the private key used for ssh remotes, the keys of the
+	// ssh-agent and the default keys are tried if it is empty
+	KeyFile string
+	// Passphrase decrypts the private key
+	Passphrase string
+	// Source describes where the credentials are read from if they are
+	// configured, e.g. "env GITHUB_TOKEN"
+	Source string
+	// Host is the scheme, host and port that the credentials are issued
+	// for, e.g. https://github.com. They are never sent to another host if
+	// it is set
+	Host string
 }
 
 // Schemes for authentication
@@ -19,18 +33,69 @@ const (
 	AuthProtocolHTTP  = "http"
 	AuthProtocolHTTPS = "https"
 	AuthProtocolSSH   = "ssh"
+	AuthProtocolGit   = "git"
+	AuthProtocolFile  = "file"
 )
 
+// RemoteURL is the parsed form of a remote's URL, scp-like addresses such as
+// "user@host:path" are reported with the ssh scheme
+type RemoteURL struct {
+	Scheme string
+	User   string
+	Host   string
+	Port   string
+	Path   string
+}
+
+var (
+	schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+	scpRegex    = regexp.MustCompile(`^(?:([^@/]+)@)?(\[[^\]]+\]|[^:/]+):(.*)$`)
+)
+
+// ParseRemoteURL parses the URL of a remote the same way as git does. A URL
+// without a scheme is an scp-like ssh address if there is a colon before the
+// first slash, otherwise it is a local path
+func ParseRemoteURL(raw string) (*RemoteURL, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("empty remote url")
+	}
+	if schemeRegex.MatchString(raw) {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		scheme := strings.ToLower(u.Scheme)
+		if scheme == "git+ssh" || scheme == "ssh+git" {
+			scheme = AuthProtocolSSH
+		}
+		return &RemoteURL{
+			Scheme: scheme,
+			User:   u.User.Username(),
+			Host:   u.Hostname(),
+			Port:   u.Port(),
+			Path:   u.Path,
+		}, nil
+	}
+	if m := scpRegex.FindStringSubmatch(raw); m != nil {
+		return &RemoteURL{
+			Scheme: AuthProtocolSSH,
+			User:   m[1],
+			Host:   strings.Trim(m[2], "[]"),
+			Path:   m[3],
+		}, nil
+	}
+	return &RemoteURL{Scheme: AuthProtocolFile, Path: raw}, nil
+}
+
 // AuthProtocol returns the type of protocol for given remote's URL
 // various auth protocols require different kind of authentication
 func AuthProtocol(r *Remote) (p string, err error) {
-	ur := r.URL[0]
-	if strings.HasPrefix(ur, "git@") {
-		return "ssh", nil
+	if len(r.URL) == 0 {
+		return p, errors.New("remote has no url")
 	}
-	u, err := url.Parse(ur)
+	u, err := ParseRemoteURL(r.URL[0])
 	if err != nil {
 		return p, err
 	}
-	return u.Scheme, err
+	return u.Scheme, nil
 }
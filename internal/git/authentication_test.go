@@ -20,6 +20,15 @@ func TestAuthProtocol(t *testing.T) {
 		{&Remote{
 			URL: []string{"git@gitlab.com:isacikgoz/dirty-repo.git", ""},
 		}, "ssh"},
+		{&Remote{
+			URL: []string{"deploy@gitlab.com:isacikgoz/dirty-repo.git", ""},
+		}, "ssh"},
+		{&Remote{
+			URL: []string{"ssh://git@gitlab.com:2222/isacikgoz/dirty-repo.git", ""},
+		}, "ssh"},
+		{&Remote{
+			URL: []string{"/srv/git/dirty-repo.git", ""},
+		}, "file"},
 	}
 	for _, test := range tests {
 		protocol, err := AuthProtocol(test.input)
@@ -27,3 +36,28 @@ func TestAuthProtocol(t *testing.T) {
 		require.Equal(t, test.expected, protocol)
 	}
 }
+
+func TestParseRemoteURL(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected *RemoteURL
+	}{
+		{"ssh://git@example.com:2222/a/b.git", &RemoteURL{Scheme: "ssh", User: "git", Host: "example.com", Port: "2222", Path: "/a/b.git"}},
+		{"git+ssh://example.com/a/b.git", &RemoteURL{Scheme: "ssh", Host: "example.com", Path: "/a/b.git"}},
+		{"git@example.com:a/b.git", &RemoteURL{Scheme: "ssh", User: "git", Host: "example.com", Path: "a/b.git"}},
+		{"example.com:/srv/b.git", &RemoteURL{Scheme: "ssh", Host: "example.com", Path: "/srv/b.git"}},
+		{"git@[::1]:a/b.git", &RemoteURL{Scheme: "ssh", User: "git", Host: "::1", Path: "a/b.git"}},
+		{"https://user@example.com/a/b.git", &RemoteURL{Scheme: "https", User: "user", Host: "example.com", Path: "/a/b.git"}},
+		{"file:///srv/b.git", &RemoteURL{Scheme: "file", Path: "/srv/b.git"}},
+		{"../b.git", &RemoteURL{Scheme: "file", Path: "../b.git"}},
+		// a colon after the first slash is a part of a local path
+		{"./a:b.git", &RemoteURL{Scheme: "file", Path: "./a:b.git"}},
+	}
+	for _, test := range tests {
+		u, err := ParseRemoteURL(test.input)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, u, test.input)
+	}
+	_, err := ParseRemoteURL("")
+	require.Error(t, err)
+}
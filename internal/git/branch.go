@@ -2,7 +2,6 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
@@ -132,28 +131,17 @@ func (r *Repository) Checkout(b *Branch) error {
 // I implemented this with go-git but it was incredibly slow and there is also
 // an issue about it: https://github.com/src-d/go-git/issues/844
 func (r *Repository) isClean() bool {
-	args := []string{"status"}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+	// there is nothing to change without a worktree
+	if !r.Kind.HasWorktree() {
+		return true
+	}
+	// the porcelain format is stable and lists nothing for a clean tree
+	cmd := Command(r.AbsPath, "status", "--porcelain")
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return false
 	}
-	s := string(out)
-	if strings.HasSuffix(s, "\n") {
-		s = s[:len(s)-1]
-	}
-	if len(s) >= 0 {
-		vs := strings.Split(s, "\n")
-		line := vs[len(vs)-1]
-		// earlier versions of git returns "working directory clean" instead of
-		//"working tree clean" message
-		if strings.Contains(line, "working tree clean") ||
-			strings.Contains(line, "working directory clean") {
-			return true
-		}
-	}
-	return false
+	return len(strings.TrimSpace(string(out))) == 0
 }
 
 // RevListOptions defines the rules of rev-list func
@@ -172,8 +160,7 @@ func RevList(r *Repository, options RevListOptions) ([]*object.Commit, error) {
 		arg1 := options.Ref1 + ".." + options.Ref2
 		args = append(args, arg1)
 	}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+	cmd := Command(r.AbsPath, args...)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, err
@@ -238,16 +225,14 @@ func (b *Branch) InitializeCommits(r *Repository) error {
 
 func getUpstream(r *Repository, branchName string) (*RemoteBranch, error) {
 	args := []string{"config", "--get", "branch." + branchName + ".remote"}
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+	cmd := Command(r.AbsPath, args...)
 	cr, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("upstream not found")
 	}
 
 	args = []string{"config", "--get", "branch." + branchName + ".merge"}
-	cmd = exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+	cmd = Command(r.AbsPath, args...)
 	cm, err := cmd.CombinedOutput()
 	if err != nil || !strings.Contains(string(cm), branchName) {
 		return nil, fmt.Errorf("default merge branch found")
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// commandEnv are the variables every git command runs with. The output of
+// git is parsed, so it is kept untranslated, and git never waits on a prompt
+// since the terminal belongs to the gui
+var commandEnv = []string{"LC_ALL=C", "GIT_TERMINAL_PROMPT=0"}
+
+// CommandEnv returns the environment of the process with the locale of the
+// user replaced by the C locale and the terminal prompts of git disabled
+func CommandEnv() []string {
+	env := make([]string, 0, len(os.Environ())+len(commandEnv))
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if key == "LANGUAGE" || strings.HasPrefix(key, "LC_") || key == "GIT_TERMINAL_PROMPT" {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, commandEnv...)
+}
+
+// Command returns the git command with the arguments to run in the directory
+// with the environment of CommandEnv
+func Command(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = CommandEnv()
+	return cmd
+}
@@ -0,0 +1,44 @@
+package git
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandEnv(t *testing.T) {
+	t.Setenv("LANG", "de_DE.UTF-8")
+	t.Setenv("LC_ALL", "tr_TR.UTF-8")
+	t.Setenv("LC_MESSAGES", "tr_TR.UTF-8")
+	t.Setenv("LANGUAGE", "tr")
+	t.Setenv("GIT_TERMINAL_PROMPT", "1")
+
+	env := make(map[string]string)
+	for _, kv := range CommandEnv() {
+		pair := strings.SplitN(kv, "=", 2)
+		_, ok := env[pair[0]]
+		require.False(t, ok, pair[0])
+		env[pair[0]] = pair[1]
+	}
+	require.Equal(t, "C", env["LC_ALL"])
+	require.Equal(t, "0", env["GIT_TERMINAL_PROMPT"])
+	require.NotContains(t, env, "LC_MESSAGES")
+	require.NotContains(t, env, "LANGUAGE")
+}
+
+func TestIsCleanLocale(t *testing.T) {
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	t.Setenv("LANGUAGE", "de")
+
+	th := InitTestRepositoryFromLocal(t)
+	defer th.CleanUp(t)
+
+	require.True(t, th.Repository.isClean())
+	CreateTestCommit(t, th.RepoPath, "file")
+	require.True(t, th.Repository.isClean())
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, "file"), []byte("changed"), 0644))
+	require.False(t, th.Repository.isClean())
+}
@@ -38,6 +38,15 @@ const (
 	StatusIgnored FileStatus = '!'
 )
 
+// Unmerged returns true if the file has unresolved conflicts, either side is
+// updated but unmerged or both sides added or deleted the file
+func (f *File) Unmerged() bool {
+	if f.X == StatusUpdated || f.Y == StatusUpdated {
+		return true
+	}
+	return (f.X == StatusAdded && f.Y == StatusAdded) || (f.X == StatusDeleted && f.Y == StatusDeleted)
+}
+
 // FilesAlphabetical slice is the re-ordered *File slice that sorted according
 // to alphabetical order (A-Z)
 type FilesAlphabetical []*File
@@ -3,6 +3,7 @@ package git
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -58,6 +59,49 @@ func InitTestRepository(t *testing.T) *TestHelper {
 	}
 }
 
+// InitTestRepositoryWithLocalRemote creates a bare repository to act as the
+// origin and clones it, so remote operations can be tested without network
+func InitTestRepositoryWithLocalRemote(t *testing.T) *TestHelper {
+	testPathDir, err := ioutil.TempDir("", "gitbatch")
+	require.NoError(t, err)
+
+	remote := filepath.Join(testPathDir, "remote.git")
+	local := filepath.Join(testPathDir, "local")
+	RunTestGit(t, testPathDir, "init", "--bare", remote)
+	RunTestGit(t, remote, "symbolic-ref", "HEAD", "refs/heads/master")
+	RunTestGit(t, testPathDir, "clone", remote, local)
+	RunTestGit(t, local, "symbolic-ref", "HEAD", "refs/heads/master")
+	CreateTestCommit(t, local, "README.md")
+	RunTestGit(t, local, "push", "-u", "origin", "master")
+
+	r, err := InitializeRepo(local)
+	require.NoError(t, err)
+
+	return &TestHelper{
+		Repository: r,
+		RepoPath:   local,
+	}
+}
+
+// RunTestGit runs a git command in given directory and fails the test if the
+// command returns an error
+func RunTestGit(t *testing.T, dir string, args ...string) string {
+	args = append([]string{"-c", "user.name=gitbatch", "-c", "user.email=gitbatch@localhost"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+// CreateTestCommit writes a file into the given work tree and commits it
+func CreateTestCommit(t *testing.T, dir, name string) {
+	err := ioutil.WriteFile(filepath.Join(dir, name), []byte(RandomString(16)), 0644)
+	require.NoError(t, err)
+	RunTestGit(t, dir, "add", name)
+	RunTestGit(t, dir, "commit", "-m", "add "+name)
+}
+
 func (h *TestHelper) CleanUp(t *testing.T) {
 	err := os.RemoveAll(filepath.Dir(h.RepoPath))
 	require.NoError(t, err)
@@ -74,3 +118,7 @@ func (h *TestHelper) BasicRepoPath() string {
 func (h *TestHelper) NonRepoPath() string {
 	return filepath.Join(h.RepoPath, "non-repo")
 }
+
+func (h *TestHelper) RemoteRepoPath() string {
+	return filepath.Join(filepath.Dir(h.RepoPath), "remote.git")
+}
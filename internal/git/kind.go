@@ -0,0 +1,105 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// Kind is the layout of a repository on the filesystem
+type Kind uint8
+
+const (
+	// KindNone means the directory is not a repository
+	KindNone Kind = iota
+	// KindRegular is a repository with its .git directory
+	KindRegular
+	// KindBare is a repository without a worktree
+	KindBare
+	// KindWorktree is a linked worktree created with "git worktree add"
+	KindWorktree
+	// KindSubmodule is a submodule of another repository
+	KindSubmodule
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindRegular:
+		return "regular"
+	case KindBare:
+		return "bare"
+	case KindWorktree:
+		return "worktree"
+	case KindSubmodule:
+		return "submodule"
+	}
+	return "none"
+}
+
+// HasWorktree returns false if the repository has no files checked out
+func (k Kind) HasWorktree() bool {
+	return k != KindBare
+}
+
+const gitDirPrefix = "gitdir:"
+
+// DetectKind finds out the kind of the repository in the given directory. A
+// .git file points to the actual git directory of a linked worktree or a
+// submodule, a bare repository has the git directory layout in itself
+func DetectKind(dir string) Kind {
+	fi, err := os.Stat(filepath.Join(dir, ".git"))
+	if err == nil {
+		if fi.IsDir() {
+			return KindRegular
+		}
+		return gitFileKind(filepath.Join(dir, ".git"))
+	}
+	if isGitDir(dir) {
+		return KindBare
+	}
+	return KindNone
+}
+
+// gitFileKind reads the gitdir of the .git file to tell a worktree from a
+// submodule, other git files are considered as a regular repository
+func gitFileKind(path string) Kind {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return KindNone
+	}
+	content := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(content, gitDirPrefix) {
+		return KindNone
+	}
+	gitDir := filepath.ToSlash(strings.TrimSpace(strings.TrimPrefix(content, gitDirPrefix)))
+	if strings.Contains(gitDir, "/worktrees/") {
+		return KindWorktree
+	} else if strings.Contains(gitDir, "/modules/") {
+		return KindSubmodule
+	}
+	return KindRegular
+}
+
+// isGitDir checks whether the directory has the layout of a git directory
+func isGitDir(dir string) bool {
+	if fi, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil || fi.IsDir() {
+		return false
+	}
+	for _, d := range []string{"objects", "refs"} {
+		if fi, err := os.Stat(filepath.Join(dir, d)); err != nil || !fi.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// openRepository opens the go-git repository, the common directory of linked
+// worktrees is enabled so that the refs shared with the main worktree resolve
+func openRepository(dir string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{
+		EnableDotGitCommonDir: true,
+	})
+}
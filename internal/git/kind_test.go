@@ -0,0 +1,58 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectKind(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	worktree := filepath.Join(filepath.Dir(th.RepoPath), "worktree")
+	RunTestGit(t, th.RepoPath, "worktree", "add", "-b", "feature", worktree)
+	RunTestGit(t, th.RepoPath, "-c", "protocol.file.allow=always", "submodule", "add", th.RemoteRepoPath(), "sub")
+
+	var tests = []struct {
+		input  string
+		output Kind
+	}{
+		{th.RepoPath, KindRegular},
+		{th.RemoteRepoPath(), KindBare},
+		{worktree, KindWorktree},
+		{filepath.Join(th.RepoPath, "sub"), KindSubmodule},
+		{filepath.Dir(th.RepoPath), KindNone},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.output, DetectKind(test.input), test.input)
+	}
+}
+
+func TestInitializeRepoKinds(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	worktree := filepath.Join(filepath.Dir(th.RepoPath), "worktree")
+	RunTestGit(t, th.RepoPath, "worktree", "add", "-b", "feature", worktree)
+	mirror := filepath.Join(filepath.Dir(th.RepoPath), "mirror.git")
+	RunTestGit(t, th.RepoPath, "clone", "--bare", th.RemoteRepoPath(), mirror)
+
+	var tests = []struct {
+		input  string
+		kind   Kind
+		branch string
+		clean  bool
+	}{
+		{mirror, KindBare, "master", true},
+		{worktree, KindWorktree, "feature", true},
+	}
+	for _, test := range tests {
+		r, err := InitializeRepo(test.input)
+		require.NoError(t, err)
+		require.Equal(t, test.kind, r.Kind)
+		require.Equal(t, test.branch, r.State.Branch.Name)
+		require.Equal(t, test.clean, r.State.Branch.Clean)
+	}
+}
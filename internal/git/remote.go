@@ -1,6 +1,11 @@
 package git
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
 
 // Remote struct is simply a collection of remote branches and wraps it with the
 // name of the remote and fetch/push urls. It also holds the *selected* remote
@@ -44,3 +49,72 @@ func (r *Repository) initRemotes() error {
 	r.State.Remote = r.Remotes[0]
 	return err
 }
+
+// initDefaultBranch resolves the default branch from refs/remotes/<remote>/HEAD
+// which is set by clone or "git remote set-head". The selected remote is
+// preferred over the others
+func (r *Repository) initDefaultBranch() {
+	r.DefaultBranch = ""
+	remotes := append([]*Remote{r.State.Remote}, r.Remotes...)
+	for _, rm := range remotes {
+		ref, err := r.Repo.Reference(plumbing.NewRemoteHEADReferenceName(rm.Name), false)
+		if err != nil || ref.Type() != plumbing.SymbolicReference {
+			continue
+		}
+		r.DefaultBranch = strings.TrimPrefix(ref.Target().Short(), rm.Name+"/")
+		return
+	}
+}
+
+// SetDefaultBranch records the default branch of the remote locally, it is
+// the equivalent of "git remote set-head <remote> <branch>"
+func (r *Repository) SetDefaultBranch(remote, branch string) error {
+	ref := plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName(remote),
+		plumbing.NewRemoteReferenceName(remote, branch))
+	if err := r.Repo.Storer.SetReference(ref); err != nil {
+		return err
+	}
+	r.DefaultBranch = branch
+	return nil
+}
+
+// Host returns the host name of the remote's first URL. It understands both
+// URLs with a scheme and the scp-like syntax (e.g. git@github.com:user/repo).
+// An empty string is returned for local remotes or unparsable URLs
+func (rm *Remote) Host() string {
+	if len(rm.URL) == 0 {
+		return ""
+	}
+	u, err := ParseRemoteURL(rm.URL[0])
+	if err != nil || u.Scheme == AuthProtocolFile {
+		return ""
+	}
+	return u.Host
+}
+
+// RefChanges are the references of a remote that a fetch created, moved or
+// deleted. Tags are listed by the remote they are fetched from
+type RefChanges struct {
+	Remote  string   `json:"remote"`
+	New     []string `json:"new,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Pruned  []string `json:"pruned,omitempty"`
+}
+
+// String summarizes the changes, e.g. "origin: 2 new, 1 pruned"
+func (c *RefChanges) String() string {
+	parts := make([]string, 0, 3)
+	if len(c.New) > 0 {
+		parts = append(parts, fmt.Sprintf("%d new", len(c.New)))
+	}
+	if len(c.Updated) > 0 {
+		parts = append(parts, fmt.Sprintf("%d updated", len(c.Updated)))
+	}
+	if len(c.Pruned) > 0 {
+		parts = append(parts, fmt.Sprintf("%d pruned", len(c.Pruned)))
+	}
+	if len(parts) == 0 {
+		return c.Remote + ": up-to-date"
+	}
+	return c.Remote + ": " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteHost(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{"https://gitlab.com/isacikgoz/dirty-repo.git", "gitlab.com"},
+		{"https://user@github.example.com:8443/org/repo.git", "github.example.com"},
+		{"ssh://git@github.com:22/isacikgoz/gitbatch.git", "github.com"},
+		{"git@github.com:isacikgoz/gitbatch.git", "github.com"},
+		{"github.com:isacikgoz/gitbatch.git", "github.com"},
+		{"file:///tmp/remote.git", ""},
+		{"/tmp/remote.git", ""},
+		{"./remote:name", ""},
+	}
+	for _, test := range tests {
+		rm := &Remote{URL: []string{test.input}}
+		require.Equal(t, test.expected, rm.Host(), test.input)
+	}
+	require.Equal(t, "", (&Remote{}).Host())
+}
+
+func TestDefaultBranch(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	// the remote HEAD is only recorded by clone, a repository pushed to an
+	// empty remote does not know it
+	require.Empty(t, th.Repository.DefaultBranch)
+
+	require.NoError(t, th.Repository.SetDefaultBranch("origin", "develop"))
+	r, err := InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Equal(t, "develop", r.DefaultBranch)
+}
@@ -2,7 +2,7 @@ package git
 
 import (
 	"os"
-	"os/exec"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -19,15 +19,20 @@ type Reference interface {
 // actually the name of its folder in the host's filesystem. It holds the go-git
 // repository entity along with critic entities such as remote/branches and commits
 type Repository struct {
-	RepoID   string
-	Name     string
-	AbsPath  string
-	ModTime  time.Time
-	Repo     git.Repository
-	Branches []*Branch
-	Remotes  []*Remote
-	Stasheds []*StashedItem
-	State    *RepositoryState
+	RepoID  string
+	Name    string
+	AbsPath string
+	ModTime time.Time
+	Kind    Kind
+	// DefaultBranch is the branch that HEAD of the remote points to, it is
+	// empty if it is not known locally
+	DefaultBranch string
+	Repo          git.Repository
+	Branches      []*Branch
+	Remotes       []*Remote
+	Stasheds      []*StashedItem
+	Tags          []*Tag
+	State         *RepositoryState
 
 	mutex     *sync.RWMutex
 	listeners map[string][]RepositoryListener
@@ -39,6 +44,10 @@ type RepositoryState struct {
 	Branch     *Branch
 	Remote     *Remote
 	Message    string
+	// Error is the error of the last failed operation, nil if it succeeded
+	Error error
+	// Fetched are the references changed by the last fetch of each remote
+	Fetched []*RefChanges
 }
 
 // RepositoryListener is a type for listeners
@@ -70,6 +79,8 @@ var (
 	Success = WorkStatus{Status: 4, Ready: true}
 	// Fail is the unexpected outcome of the operation
 	Fail = WorkStatus{Status: 5, Ready: false}
+	// Cancelled means the operation is stopped by the user or timed out
+	Cancelled = WorkStatus{Status: 6, Ready: true}
 )
 
 const (
@@ -88,16 +99,26 @@ func FastInitializeRepo(dir string) (r *Repository, err error) {
 	defer f.Close()
 	// get status of the file
 	fstat, _ := f.Stat()
-	rp, err := git.PlainOpen(dir)
+	rp, err := openRepository(dir)
 	if err != nil {
 		return nil, err
 	}
 	// initialize Repository with minimum viable fields
-	r = &Repository{RepoID: RandomString(8),
-		Name:    fstat.Name(),
+	r = NewRepository(dir)
+	r.Name = fstat.Name()
+	r.ModTime = fstat.ModTime()
+	r.Kind = DetectKind(dir)
+	r.Repo = *rp
+	return r, nil
+}
+
+// NewRepository creates the entity of a repository that is not on the disk
+// yet, e.g. it is going to be cloned. It should be opened with Open once the
+// repository is created
+func NewRepository(dir string) *Repository {
+	return &Repository{RepoID: RandomString(8),
+		Name:    filepath.Base(dir),
 		AbsPath: dir,
-		ModTime: fstat.ModTime(),
-		Repo:    *rp,
 		State: &RepositoryState{
 			workStatus: Available,
 			Message:    "",
@@ -105,7 +126,23 @@ func FastInitializeRepo(dir string) (r *Repository, err error) {
 		mutex:     &sync.RWMutex{},
 		listeners: make(map[string][]RepositoryListener),
 	}
-	return r, nil
+}
+
+// Open loads the repository and its belongings from its path, it is used
+// after the repository is created on the disk
+func (r *Repository) Open() error {
+	fstat, err := os.Stat(r.AbsPath)
+	if err != nil {
+		return err
+	}
+	rp, err := openRepository(r.AbsPath)
+	if err != nil {
+		return err
+	}
+	r.ModTime = fstat.ModTime()
+	r.Kind = DetectKind(r.AbsPath)
+	r.Repo = *rp
+	return r.loadComponents(true)
 }
 
 // InitializeRepo initializes a Repository struct with its belongings.
@@ -124,6 +161,7 @@ func (r *Repository) loadComponents(reset bool) error {
 	if err := r.initRemotes(); err != nil {
 		return err
 	}
+	r.initDefaultBranch()
 
 	if err := r.initBranches(); err != nil {
 		return err
@@ -133,6 +171,9 @@ func (r *Repository) loadComponents(reset bool) error {
 		return err
 	}
 
+	if err := r.loadTags(); err != nil {
+		return err
+	}
 	return r.loadStashedItems()
 }
 
@@ -149,7 +190,7 @@ func (r *Repository) Refresh() error {
 	file, _ := os.Open(r.AbsPath)
 	fstat, _ := file.Stat()
 	// re-initialize the go-git repository struct after supposed update
-	rp, err := git.PlainOpen(r.AbsPath)
+	rp, err := openRepository(r.AbsPath)
 	if err != nil {
 		return err
 	}
@@ -211,8 +252,7 @@ func (r *Repository) String() string {
 }
 
 func Create(dir string) (*Repository, error) {
-	cmd := exec.Command("git", "init")
-	cmd.Dir = dir
+	cmd := Command(dir, "init")
 	_, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, err
@@ -1,7 +1,6 @@
 package git
 
 import (
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
@@ -18,12 +17,14 @@ type StashedItem struct {
 
 func (r *Repository) loadStashedItems() error {
 	r.Stasheds = make([]*StashedItem, 0)
-	output := stashGet(r, "list")
+	// the format is the default one, spelled out so that the config of the
+	// user can not change it
+	output := stashGet(r, "list", "--format=%gd: %gs")
 	stashIDRegex := regexp.MustCompile(`stash@{[\d]+}:`)
 	stashIDRegexInt := regexp.MustCompile(`[\d]+`)
 	stashBranchRegex := regexp.MustCompile(`^(.*?): `)
 	stashMsgRegex := regexp.MustCompile(`WIP on \(?([^)]*)\)?`)
-	stashHashRegex := regexp.MustCompile(`[\w|\d]{7}\s`)
+	stashHashRegex := regexp.MustCompile(`^[0-9a-f]{7,}\s`)
 
 	stashlist := strings.Split(output, "\n")
 	for _, stashitem := range stashlist {
@@ -55,8 +56,9 @@ func (r *Repository) loadStashedItems() error {
 		hash := ""
 
 		var desc string
-		if stashHashRegex.MatchString(trimmed) {
-			hash = stashHashRegex.FindString(trimmed)[:7]
+		// only the items without a message start with the hash of the commit
+		if strings.HasPrefix(strings.TrimSpace(branchName), "WIP on ") && stashHashRegex.MatchString(trimmed) {
+			hash = strings.TrimSpace(stashHashRegex.FindString(trimmed))
 			desc = stashHashRegex.Split(trimmed, 2)[1]
 		} else {
 			desc = trimmed
@@ -74,12 +76,11 @@ func (r *Repository) loadStashedItems() error {
 	return nil
 }
 
-func stashGet(r *Repository, option string) string {
+func stashGet(r *Repository, option ...string) string {
 	args := make([]string, 0)
 	args = append(args, "stash")
-	args = append(args, option)
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+	args = append(args, option...)
+	cmd := Command(r.AbsPath, args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "?"
@@ -93,12 +94,43 @@ func (stashedItem *StashedItem) Pop() (string, error) {
 	args = append(args, "stash")
 	args = append(args, "pop")
 	args = append(args, "stash@{"+strconv.Itoa(stashedItem.StashID)+"}")
-	cmd := exec.Command("git", args...)
-	cmd.Dir = stashedItem.EntityPath
+	cmd := Command(stashedItem.EntityPath, args...)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
 
+// Apply is the wrapper of "git stash apply" command, the item is kept in the
+// stash list
+func (stashedItem *StashedItem) Apply() (string, error) {
+	return stashedItem.run("apply")
+}
+
+// Drop is the wrapper of "git stash drop" command
+func (stashedItem *StashedItem) Drop() (string, error) {
+	return stashedItem.run("drop")
+}
+
+// Branch is the wrapper of "git stash branch" command, it creates the branch
+// from the commit that the item is stashed on and pops the item there
+func (stashedItem *StashedItem) Branch(name string) (string, error) {
+	args := []string{"stash", "branch", name, stashedItem.ref()}
+	cmd := Command(stashedItem.EntityPath, args...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// runs the stash subcommand on the item
+func (stashedItem *StashedItem) run(option string) (string, error) {
+	cmd := Command(stashedItem.EntityPath, "stash", option, stashedItem.ref())
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// ref returns the reference of the item, e.g. stash@{0}
+func (stashedItem *StashedItem) ref() string {
+	return "stash@{" + strconv.Itoa(stashedItem.StashID) + "}"
+}
+
 // Show is the wrapper of "git stash show -p " command
 func (stashedItem *StashedItem) Show() (string, error) {
 	args := make([]string, 0)
@@ -106,21 +138,61 @@ func (stashedItem *StashedItem) Show() (string, error) {
 	args = append(args, "show")
 	args = append(args, "-p")
 	args = append(args, "stash@{"+strconv.Itoa(stashedItem.StashID)+"}")
-	cmd := exec.Command("git", args...)
-	cmd.Dir = stashedItem.EntityPath
+	cmd := Command(stashedItem.EntityPath, args...)
 	output, err := cmd.CombinedOutput()
 
 	return string(output), err
 }
 
+// StashOptions defines the rules of stashing the local changes
+type StashOptions struct {
+	// Message is the description of the stash, git generates one if empty
+	Message string
+	// IncludeUntracked stashes the untracked files as well
+	IncludeUntracked bool
+	// KeepIndex leaves the staged changes in the worktree
+	KeepIndex bool
+}
+
 // Stash is the wrapper of conventional "git stash" command
 func (r *Repository) Stash() (string, error) {
-	args := make([]string, 0)
-	args = append(args, "stash")
+	return r.StashWithOptions(&StashOptions{})
+}
+
+// StashWithOptions is the wrapper of "git stash push" command
+func (r *Repository) StashWithOptions(o *StashOptions) (string, error) {
+	args := []string{"stash", "push"}
+	if len(o.Message) > 0 {
+		args = append(args, "-m", o.Message)
+	}
+	if o.IncludeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if o.KeepIndex {
+		args = append(args, "--keep-index")
+	}
+	cmd := Command(r.AbsPath, args...)
+	output, err := cmd.CombinedOutput()
+	_ = r.Refresh()
+	return string(output), err
+}
 
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.AbsPath
+// ClearStash is the wrapper of "git stash clear" command, all of the stashed
+// items are removed
+func (r *Repository) ClearStash() (string, error) {
+	cmd := Command(r.AbsPath, "stash", "clear")
 	output, err := cmd.CombinedOutput()
 	_ = r.Refresh()
 	return string(output), err
 }
+
+// FindStash returns the latest stashed item with the description, nil if
+// there is none
+func (r *Repository) FindStash(message string) *StashedItem {
+	for _, s := range r.Stasheds {
+		if s.Description == message {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStashWithOptions(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@example.com")
+
+	CreateTestCommit(t, th.RepoPath, "a.txt")
+	tracked := filepath.Join(th.RepoPath, "a.txt")
+	untracked := filepath.Join(th.RepoPath, "b.txt")
+	require.NoError(t, ioutil.WriteFile(tracked, []byte("changed"), 0644))
+	require.NoError(t, ioutil.WriteFile(untracked, []byte("new"), 0644))
+
+	r, err := InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+
+	_, err = r.StashWithOptions(&StashOptions{Message: "before pull", IncludeUntracked: true})
+	require.NoError(t, err)
+	require.Len(t, r.Stasheds, 1)
+	require.Equal(t, "before pull", r.Stasheds[0].Description)
+	require.NoFileExists(t, untracked)
+
+	var tests = []struct {
+		message string
+		found   bool
+	}{
+		{"before pull", true},
+		{"before", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		s := r.FindStash(test.message)
+		require.Equal(t, test.found, s != nil, test.message)
+	}
+
+	// apply keeps the item, drop removes it
+	_, err = r.FindStash("before pull").Apply()
+	require.NoError(t, err)
+	require.FileExists(t, untracked)
+	require.NoError(t, r.Refresh())
+	require.Len(t, r.Stasheds, 1)
+	_, err = r.Stasheds[0].Drop()
+	require.NoError(t, err)
+	require.NoError(t, r.Refresh())
+	require.Empty(t, r.Stasheds)
+}
+
+func TestStashBranchAndClear(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@example.com")
+
+	CreateTestCommit(t, th.RepoPath, "a.txt")
+	tracked := filepath.Join(th.RepoPath, "a.txt")
+	r, err := InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+
+	for _, content := range []string{"first", "second"} {
+		require.NoError(t, ioutil.WriteFile(tracked, []byte(content), 0644))
+		_, err = r.StashWithOptions(&StashOptions{Message: content})
+		require.NoError(t, err)
+	}
+	require.Len(t, r.Stasheds, 2)
+
+	// the first stash is the older one, it is at stash@{1}
+	out, err := r.FindStash("first").Branch("from-stash")
+	require.NoError(t, err, out)
+	require.Equal(t, "from-stash\n", RunTestGit(t, th.RepoPath, "rev-parse", "--abbrev-ref", "HEAD"))
+	b, err := ioutil.ReadFile(tracked)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(b))
+
+	require.NoError(t, r.Refresh())
+	require.Len(t, r.Stasheds, 1)
+	_, err = r.ClearStash()
+	require.NoError(t, err)
+	require.Empty(t, r.Stasheds)
+}
+
+func TestStashWithoutMessage(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@example.com")
+
+	CreateTestCommit(t, th.RepoPath, "a.txt")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, "a.txt"), []byte("changed"), 0644))
+	RunTestGit(t, th.RepoPath, "stash")
+	head := RunTestGit(t, th.RepoPath, "rev-parse", "--short", "HEAD")
+	subject := RunTestGit(t, th.RepoPath, "log", "-1", "--format=%s")
+
+	r, err := InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Len(t, r.Stasheds, 1)
+	require.Equal(t, strings.TrimSpace(head), r.Stasheds[0].Hash)
+	require.Equal(t, strings.TrimSpace(subject), r.Stasheds[0].Description)
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Tag is a lightweight or an annotated tag of the repository. Hash is the
+// commit that the tag points to, not the hash of the tag object
+type Tag struct {
+	Name      string
+	Reference *plumbing.Reference
+	Hash      plumbing.Hash
+	// Annotated tags have a tagger and a message
+	Annotated bool
+	Message   string
+	// When is the time of tagging, or the time of the commit for the
+	// lightweight tags
+	When time.Time
+}
+
+// loads the tags of the repository, the latest one comes first. The tags
+// that do not point to a commit are skipped
+func (r *Repository) loadTags() error {
+	r.Tags = make([]*Tag, 0)
+	refs, err := r.Repo.Tags()
+	if err != nil {
+		return err
+	}
+	defer refs.Close()
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		t := &Tag{
+			Name:      ref.Name().Short(),
+			Reference: ref,
+			Hash:      ref.Hash(),
+		}
+		if to, err := r.Repo.TagObject(ref.Hash()); err == nil {
+			c, err := to.Commit()
+			if err != nil {
+				return nil
+			}
+			t.Hash = c.Hash
+			t.Annotated = true
+			t.Message = to.Message
+			t.When = to.Tagger.When
+		} else {
+			c, err := r.Repo.CommitObject(ref.Hash())
+			if err != nil {
+				return nil
+			}
+			t.When = c.Committer.When
+		}
+		r.Tags = append(r.Tags, t)
+		return nil
+	})
+	sort.SliceStable(r.Tags, func(i, j int) bool {
+		if r.Tags[i].When.Equal(r.Tags[j].When) {
+			return r.Tags[i].Name > r.Tags[j].Name
+		}
+		return r.Tags[i].When.After(r.Tags[j].When)
+	})
+	return err
+}
+
+// LatestTag returns the closest tag reachable from HEAD and the count of the
+// commits since that tag, like "git describe --tags". The tag is nil if no
+// tag is reachable
+func (r *Repository) LatestTag() (*Tag, int, error) {
+	if len(r.Tags) == 0 {
+		return nil, 0, nil
+	}
+	tags := make(map[plumbing.Hash]*Tag)
+	// the tags are sorted, the latest tag of a commit is kept
+	for i := len(r.Tags) - 1; i >= 0; i-- {
+		tags[r.Tags[i].Hash] = r.Tags[i]
+	}
+	head, err := r.Repo.Head()
+	if err != nil {
+		return nil, 0, err
+	}
+	cIter, err := r.Repo.Log(&git.LogOptions{
+		From:  head.Hash(),
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cIter.Close()
+	var (
+		latest *Tag
+		count  int
+	)
+	err = cIter.ForEach(func(c *object.Commit) error {
+		if t, ok := tags[c.Hash]; ok {
+			latest = t
+			return storer.ErrStop
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if latest == nil {
+		return nil, 0, nil
+	}
+	return latest, count, nil
+}
+
+// TagsOf returns the names of the tags pointing to the commit
+func (r *Repository) TagsOf(hash string) []string {
+	names := make([]string, 0)
+	for _, t := range r.Tags {
+		if t.Hash.String() == hash {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
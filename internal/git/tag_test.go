@@ -0,0 +1,39 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestTag(t *testing.T) {
+	th := InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	RunTestGit(t, th.RepoPath, "tag", "v0.1.0")
+	CreateTestCommit(t, th.RepoPath, "a.txt")
+	RunTestGit(t, th.RepoPath, "tag", "-a", "v0.2.0", "-m", "second release")
+	CreateTestCommit(t, th.RepoPath, "b.txt")
+	CreateTestCommit(t, th.RepoPath, "c.txt")
+
+	r, err := InitializeRepo(th.RepoPath)
+	require.NoError(t, err)
+	require.Len(t, r.Tags, 2)
+
+	tags := make(map[string]*Tag)
+	for _, tag := range r.Tags {
+		tags[tag.Name] = tag
+	}
+	require.False(t, tags["v0.1.0"].Annotated)
+	require.True(t, tags["v0.2.0"].Annotated)
+	require.Equal(t, "second release\n", tags["v0.2.0"].Message)
+	// the annotated tag resolves to its commit
+	head := RunTestGit(t, th.RepoPath, "rev-parse", "HEAD~2")
+	require.Equal(t, head[:40], tags["v0.2.0"].Hash.String())
+	require.Equal(t, []string{"v0.2.0"}, r.TagsOf(head[:40]))
+
+	latest, since, err := r.LatestTag()
+	require.NoError(t, err)
+	require.Equal(t, "v0.2.0", latest.Name)
+	require.Equal(t, 2, since)
+}
@@ -0,0 +1,180 @@
+package group
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Groups holds the groups and tags of the repositories. A repository belongs
+// to a group if its path matches one of the patterns of the group, if it is
+// assigned by the workspace manifest or if it is tagged interactively. The
+// tags are saved to a file so that they persist between the sessions
+type Groups struct {
+	mx       sync.RWMutex
+	patterns map[string]Patterns
+	assigned map[string][]string
+	tags     map[string][]string
+	file     string
+}
+
+// New creates the groups from the path patterns keyed by group name. The
+// patterns are in gitignore syntax and relative to each of the roots, see
+// Patterns
+func New(roots []string, patterns map[string][]string) *Groups {
+	g := &Groups{
+		patterns: make(map[string]Patterns),
+		assigned: make(map[string][]string),
+		tags:     make(map[string][]string),
+	}
+	for name, ps := range patterns {
+		g.patterns[name] = ParsePatterns(roots, ps)
+	}
+	return g
+}
+
+// Assign adds the repository to the groups, it is not persisted
+func (g *Groups) Assign(path string, groups ...string) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.assigned[path] = union(g.assigned[path], groups)
+}
+
+// LoadTags reads the tags from the file, a missing file means that there are
+// no tags yet. The tags are saved to the same file when they are changed
+func (g *Groups) LoadTags(file string) error {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	g.file = file
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	tags := make(map[string][]string)
+	if err := yaml.Unmarshal(b, &tags); err != nil {
+		return err
+	}
+	g.tags = tags
+	return nil
+}
+
+// Toggle adds the tag to the repository, or removes it if the repository is
+// already tagged with it. It returns true if the tag is added
+func (g *Groups) Toggle(path, tag string) (bool, error) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	tags := g.tags[path]
+	added := true
+	for i, t := range tags {
+		if t == tag {
+			tags = append(tags[:i:i], tags[i+1:]...)
+			added = false
+			break
+		}
+	}
+	if added {
+		tags = union(tags, []string{tag})
+	}
+	if len(tags) == 0 {
+		delete(g.tags, path)
+	} else {
+		g.tags[path] = tags
+	}
+	return added, g.save()
+}
+
+// save writes the tags to the file, the caller holds the lock
+func (g *Groups) save() error {
+	if len(g.file) == 0 {
+		return nil
+	}
+	b, err := yaml.Marshal(g.tags)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(g.file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(g.file, b, 0644)
+}
+
+// Of returns the sorted names of the groups and tags of the repository
+func (g *Groups) Of(path string) []string {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+	groups := union(g.assigned[path], g.tags[path])
+	for name, ps := range g.patterns {
+		if ps.Match(path) {
+			groups = union(groups, []string{name})
+		}
+	}
+	return groups
+}
+
+// Has returns true if the repository belongs to the group
+func (g *Groups) Has(path, name string) bool {
+	for _, group := range g.Of(path) {
+		if group == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the sorted names of all of the known groups and tags
+func (g *Groups) Names() []string {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+	names := make([]string, 0)
+	for name := range g.patterns {
+		names = union(names, []string{name})
+	}
+	for _, groups := range g.assigned {
+		names = union(names, groups)
+	}
+	for _, tags := range g.tags {
+		names = union(names, tags)
+	}
+	return names
+}
+
+// Filter returns the directories that belong to any of the groups, all of
+// them if no group is given
+func (g *Groups) Filter(dirs []string, names []string) []string {
+	if len(names) == 0 {
+		return dirs
+	}
+	filtered := make([]string, 0)
+	for _, dir := range dirs {
+		for _, name := range names {
+			if g.Has(dir, name) {
+				filtered = append(filtered, dir)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// union returns the sorted set of the names in both slices
+func union(a, b []string) []string {
+	set := make(map[string]bool)
+	for _, s := range append(append([]string{}, a...), b...) {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			set[s] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for s := range set {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	return names
+}
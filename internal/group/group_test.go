@@ -0,0 +1,74 @@
+package group
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroups(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitbatch-group")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	api := filepath.Join(root, "services", "api")
+	web := filepath.Join(root, "frontend", "web")
+	tool := filepath.Join(root, "tool")
+	// the parent directory of the repository matches
+	infra := filepath.Join(root, "infra", "terraform")
+
+	g := New([]string{root}, map[string][]string{
+		"backend": {"services/*"},
+		"web":     {"frontend/**"},
+		"infra":   {"infra"},
+	})
+	g.Assign(tool, "tools", "backend")
+
+	var tests = []struct {
+		path     string
+		expected []string
+	}{
+		{api, []string{"backend"}},
+		{web, []string{"web"}},
+		{tool, []string{"backend", "tools"}},
+		{infra, []string{"infra"}},
+		{filepath.Join(root, "other"), []string{}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, g.Of(test.path))
+	}
+	require.Equal(t, []string{"backend", "infra", "tools", "web"}, g.Names())
+	require.Equal(t, []string{api, tool}, g.Filter([]string{api, web, tool}, []string{"backend"}))
+	require.Equal(t, []string{web, tool}, g.Filter([]string{api, web, tool}, []string{"web", "tools"}))
+	require.Equal(t, []string{api, web}, g.Filter([]string{api, web}, nil))
+}
+
+func TestToggle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitbatch-group")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "tags.yml")
+
+	g := New(nil, nil)
+	require.NoError(t, g.LoadTags(file))
+	added, err := g.Toggle("/src/a", "release")
+	require.NoError(t, err)
+	require.True(t, added)
+	_, err = g.Toggle("/src/b", "release")
+	require.NoError(t, err)
+
+	// the tags are persisted
+	g = New(nil, nil)
+	require.NoError(t, g.LoadTags(file))
+	require.True(t, g.Has("/src/a", "release"))
+	require.Equal(t, []string{"release"}, g.Names())
+
+	added, err = g.Toggle("/src/a", "release")
+	require.NoError(t, err)
+	require.False(t, added)
+	require.False(t, g.Has("/src/a", "release"))
+	require.True(t, g.Has("/src/b", "release"))
+}
@@ -0,0 +1,51 @@
+package group
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// Patterns are path patterns in gitignore syntax. A path matches if it or one
+// of its parents matches, the groups and the include filter of the discovery
+// select the repositories by the same rules
+type Patterns []gitignore.Pattern
+
+// ParsePatterns parses each of the patterns relative to each of the roots,
+// the roots that cannot be resolved are skipped
+func ParsePatterns(roots, patterns []string) Patterns {
+	ps := make(Patterns, 0)
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		domain := SplitPath(abs)
+		for _, p := range patterns {
+			ps = append(ps, gitignore.ParsePattern(p, domain))
+		}
+	}
+	return ps
+}
+
+// Match returns true if the path or one of its parents matches the patterns
+func (ps Patterns) Match(path string) bool {
+	if len(ps) == 0 {
+		return false
+	}
+	m := gitignore.NewMatcher(ps)
+	parts := SplitPath(path)
+	for i := len(parts); i > 0; i-- {
+		if m.Match(parts[:i], true) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitPath returns the elements of the cleaned path, the patterns are matched
+// against them
+func SplitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+}
@@ -19,6 +19,11 @@ var (
 	authUserLabelFeature      = viewFeature{Name: "authuserlabel", Title: " User: "}
 	authPswdLabelViewFeature  = viewFeature{Name: "authpasswdlabel", Title: " Password: "}
 
+	// ssh remotes are authenticated with a key file and its passphrase, so
+	// the same views are labeled differently
+	authKeyLabelFeature        = viewFeature{Name: "authuserlabel", Title: " Key: "}
+	authPassphraseLabelFeature = viewFeature{Name: "authpasswdlabel", Title: " Passphrase: "}
+
 	// these views used as a input for the credentials
 	authUserFeature         = viewFeature{Name: "authuser", Title: " User "}
 	authPasswordViewFeature = viewFeature{Name: "authpasswd", Title: " Password "}
@@ -29,6 +34,9 @@ var (
 
 	// we can hold the job that is required to authenticate
 	jobRequiresAuth *job.Job
+
+	// the remote of the job is a ssh remote
+	authSSH bool
 )
 
 // open an auth view to get user credentials
@@ -46,6 +54,8 @@ func (gui *Gui) openAuthenticationView(g *gocui.Gui, jobQueue *job.Queue, job *j
 		}
 	}
 	authenticationReturnView = returnViewName
+	protocol, _ := git.AuthProtocol(jobRequiresAuth.Repository.State.Remote)
+	authSSH = protocol == git.AuthProtocolSSH
 	v, err := g.SetView(authenticationViewFeature.Name, maxX/2-30, maxY/2-2, maxX/2+30, maxY/2+2)
 	if err != nil {
 		if err != gocui.ErrUnknownView {
@@ -95,47 +105,81 @@ func (gui *Gui) submitAuthenticationView(g *gocui.Gui, v *gocui.View) error {
 	re := regexp.MustCompile(`\r?\n`)
 	creduser := re.ReplaceAllString(vUser.ViewBuffer(), "")
 	credpswd := re.ReplaceAllString(vPswd.ViewBuffer(), "")
-
-	// since the git ops require different types of options we better switch
-	switch mode := jobRequiresAuth.JobType; mode {
-	case job.FetchJob:
-		jobRequiresAuth.Options = &command.FetchOptions{
-			RemoteName: jobRequiresAuth.Repository.State.Remote.Name,
-			Credentials: &git.Credentials{
-				User:     creduser,
-				Password: credpswd,
-			},
-		}
-	case job.PullJob:
-		// we handle pull as fetch&merge so same rule applies
-		jobRequiresAuth.Options = &command.PullOptions{
-			RemoteName: jobRequiresAuth.Repository.State.Remote.Name,
-			Credentials: &git.Credentials{
-				User:     creduser,
-				Password: credpswd,
-			},
+	// the credentials are kept for the session, so that every paused
+	// repository on the same host is queued again without asking
+	url := jobRequiresAuth.Repository.State.Remote.URL[0]
+	creds := &git.Credentials{
+		User:     creduser,
+		Password: credpswd,
+		Host:     command.CredentialKey(url),
+	}
+	if authSSH {
+		creds = &git.Credentials{
+			KeyFile:    creduser,
+			Passphrase: credpswd,
+			Host:       command.CredentialKey(url),
 		}
 	}
-	jobRequiresAuth.Repository.SetWorkStatus(git.Queued)
-
-	// add this job to the last of the queue
-	if err := gui.State.Queue.AddJob(jobRequiresAuth); err != nil {
+	command.CacheCredentials(url, creds)
+	_ = gui.State.FailoverQueue.RemoveFromQueue(jobRequiresAuth.Repository)
+	if err := gui.requeueWithCredentials(jobRequiresAuth, creds); err != nil {
 		return err
 	}
+	for _, j := range gui.State.FailoverQueue.Jobs() {
+		remote := j.Repository.State.Remote
+		if j.Repository.WorkStatus() != git.Paused || remote == nil || len(remote.URL) == 0 ||
+			command.CredentialKey(remote.URL[0]) != command.CredentialKey(url) {
+			continue
+		}
+		if err := gui.State.FailoverQueue.RemoveFromQueue(j.Repository); err != nil {
+			return err
+		}
+		if err := gui.requeueWithCredentials(j, creds); err != nil {
+			return err
+		}
+	}
 
 	return gui.closeAuthenticationView(g, v)
 }
 
+// sets the credentials to the options of the job and adds it to the last of
+// the queue
+func (gui *Gui) requeueWithCredentials(j *job.Job, creds *git.Credentials) error {
+	// the options given while queueing are kept, e.g. the strategy of pull
+	switch j.JobType {
+	case job.FetchJob:
+		if j.Options == nil {
+			opts := gui.State.Settings.Fetch
+			opts.RemoteName = j.Repository.State.Remote.Name
+			j.Options = &opts
+		}
+	case job.PullJob:
+		if j.Options == nil {
+			j.Options = &command.PullOptions{
+				RemoteName: j.Repository.State.Remote.Name,
+				Strategy:   gui.State.Settings.Strategy,
+			}
+		}
+	}
+	j.SetCredentials(creds)
+	j.Repository.SetWorkStatus(git.Queued)
+	return gui.State.Queue.AddJob(j)
+}
+
 // open an error view to inform user with a message and a useful note
 func (gui *Gui) openUserView(g *gocui.Gui) error {
 	maxX, maxY := g.Size()
+	label := authUserLabelFeature
+	if authSSH {
+		label = authKeyLabelFeature
+	}
 	// first, create the label for user
-	vlabel, err := g.SetView(authUserLabelFeature.Name, maxX/2-30, maxY/2-1, maxX/2-19, maxY/2+1)
+	vlabel, err := g.SetView(label.Name, maxX/2-30, maxY/2-1, maxX/2-19, maxY/2+1)
 	if err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
-		fmt.Fprintln(vlabel, authUserLabelFeature.Title)
+		fmt.Fprintln(vlabel, label.Title)
 		vlabel.Frame = false
 	}
 	// second, crete the user input
@@ -147,6 +191,10 @@ func (gui *Gui) openUserView(g *gocui.Gui) error {
 		v.Title = authUserFeature.Title
 		v.Editable = true
 		v.Frame = false
+		if authSSH {
+			// suggest the key that ssh would use for this remote
+			gui.suggestKeyFile(v)
+		}
 	}
 	return gui.focusToView(authUserFeature.Name)
 }
@@ -154,13 +202,17 @@ func (gui *Gui) openUserView(g *gocui.Gui) error {
 // open an error view to inform user with a message and a useful note
 func (gui *Gui) openPasswordView(g *gocui.Gui) error {
 	maxX, maxY := g.Size()
+	label := authPswdLabelViewFeature
+	if authSSH {
+		label = authPassphraseLabelFeature
+	}
 	// first, create the label for password
-	vlabel, err := g.SetView(authPswdLabelViewFeature.Name, maxX/2-30, maxY/2, maxX/2-19, maxY/2+2)
+	vlabel, err := g.SetView(label.Name, maxX/2-30, maxY/2, maxX/2-19, maxY/2+2)
 	if err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
 		}
-		fmt.Fprintln(vlabel, authPswdLabelViewFeature.Title)
+		fmt.Fprintln(vlabel, label.Title)
 		vlabel.Frame = false
 	}
 	// second, crete the masked password input
@@ -182,3 +234,15 @@ func (gui *Gui) nextAuthView(g *gocui.Gui, v *gocui.View) error {
 	err := gui.nextViewOfGroup(g, v, authViews)
 	return err
 }
+
+// writes the first identity file of the remote into the key input
+func (gui *Gui) suggestKeyFile(v *gocui.View) {
+	u, err := git.ParseRemoteURL(jobRequiresAuth.Repository.State.Remote.URL[0])
+	if err != nil {
+		return
+	}
+	if files := command.IdentityFiles(u); len(files) > 0 {
+		fmt.Fprint(v, files[0])
+		_ = v.SetCursor(len(files[0]), 0)
+	}
+}
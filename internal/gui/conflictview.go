@@ -0,0 +1,214 @@
+package gui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/jroimartin/gocui"
+	"github.com/nsf/termbox-go"
+)
+
+// open the conflicts of the selected repository in the dynamic view
+func (gui *Gui) openConflicts(g *gocui.Gui, v *gocui.View) error {
+	r := gui.getSelectedRepository()
+	files, err := command.UnmergedFiles(r)
+	if err != nil {
+		return err
+	}
+	gui.State.conflicts = files
+	gui.State.conflictCursor = 0
+	vd, err := g.View(dynamicViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	vd.Title = string(ConflictMode)
+	if err := gui.updateDynamicKeybindings(); err != nil {
+		return err
+	}
+	if err := gui.renderConflicts(); err != nil {
+		return err
+	}
+	return gui.focusToView(dynamicViewFeature.Name)
+}
+
+// renders the unmerged files and the conflicting hunks of the one under the
+// cursor with their ours, base and theirs sides
+func (gui *Gui) renderConflicts() error {
+	v, err := gui.g.View(dynamicViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	r := gui.getSelectedRepository()
+	v.Clear()
+	_ = v.SetOrigin(0, 0)
+	_ = v.SetCursor(0, 0)
+	op := command.ConflictedOperation(r)
+	if op == command.OperationNone {
+		fmt.Fprintln(v, "No merge or rebase in progress")
+	} else {
+		fmt.Fprintln(v, "The "+cyan.Sprint(string(op))+" is in progress")
+	}
+	if len(gui.State.conflicts) == 0 {
+		if op != command.OperationNone {
+			fmt.Fprintln(v, "All conflicts are resolved")
+			fmt.Fprintln(v, "(\"C\" to continue the "+string(op)+", \"A\" to abort it)")
+		}
+		return nil
+	}
+	fmt.Fprintln(v, "\nUnmerged paths:")
+	fmt.Fprintln(v, "")
+	for i, f := range gui.State.conflicts {
+		line := red.Sprint(string(f.X) + string(f.Y) + ws + f.Name)
+		if i == gui.State.conflictCursor {
+			fmt.Fprintln(v, selectionIndicator+line)
+		} else {
+			fmt.Fprintln(v, tab+tab+line)
+		}
+	}
+	f := gui.State.conflicts[gui.State.conflictCursor]
+	hunks, err := command.ConflictHunks(r, f)
+	if err != nil {
+		fmt.Fprintln(v, "\nCan't read the conflicts of "+f.Name)
+		return nil
+	}
+	if len(hunks) == 0 {
+		fmt.Fprintln(v, "\n"+f.Name+" has no conflict markers, it is deleted or added on both sides")
+		return nil
+	}
+	section := func(name string, lines []string) {
+		fmt.Fprintln(v, ws+yellow.Sprint(name))
+		for _, l := range lines {
+			fmt.Fprintln(v, ws+ws+l)
+		}
+	}
+	for _, h := range hunks {
+		fmt.Fprintln(v, "\n"+cyan.Sprintf("@@ %s:%d", f.Name, h.Line))
+		section("ours", h.Ours)
+		if len(h.Base) > 0 {
+			section("base", h.Base)
+		}
+		section("theirs", h.Theirs)
+	}
+	return nil
+}
+
+// moves the cursor of the conflicts downwards
+func (gui *Gui) conflictCursorDown(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.conflictCursor+1 >= len(gui.State.conflicts) {
+		return nil
+	}
+	gui.State.conflictCursor++
+	return gui.renderConflicts()
+}
+
+// moves the cursor of the conflicts upwards
+func (gui *Gui) conflictCursorUp(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.conflictCursor <= 0 {
+		return nil
+	}
+	gui.State.conflictCursor--
+	return gui.renderConflicts()
+}
+
+// returns the unmerged file under the cursor
+func (gui *Gui) selectedConflict() *git.File {
+	if len(gui.State.conflicts) == 0 {
+		return nil
+	}
+	return gui.State.conflicts[gui.State.conflictCursor]
+}
+
+// resolve the file with the checked out side
+func (gui *Gui) resolveWithOurs(g *gocui.Gui, v *gocui.View) error {
+	return gui.resolveConflict(g, v, command.ConflictOurs)
+}
+
+// resolve the file with the merged side
+func (gui *Gui) resolveWithTheirs(g *gocui.Gui, v *gocui.View) error {
+	return gui.resolveConflict(g, v, command.ConflictTheirs)
+}
+
+// resolves the file under the cursor with one side of the merge
+func (gui *Gui) resolveConflict(g *gocui.Gui, v *gocui.View, side command.ConflictSide) error {
+	f := gui.selectedConflict()
+	if f == nil {
+		return nil
+	}
+	return gui.conflictOperationDone(g, command.ResolveConflict(gui.getSelectedRepository(), f, side))
+}
+
+// mark the file under the cursor as resolved
+func (gui *Gui) markConflictResolved(g *gocui.Gui, v *gocui.View) error {
+	f := gui.selectedConflict()
+	if f == nil {
+		return nil
+	}
+	return gui.conflictOperationDone(g, command.MarkResolved(gui.getSelectedRepository(), f))
+}
+
+// open the file under the cursor with the editor of git, the gui is suspended
+// until the editor exits
+func (gui *Gui) editConflict(g *gocui.Gui, v *gocui.View) error {
+	f := gui.selectedConflict()
+	if f == nil {
+		return nil
+	}
+	editor := command.Editor(gui.getSelectedRepository())
+	termbox.Close()
+	// the editor may have arguments, let the shell split them like git does
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, f.AbsPath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	termbox.SetInputMode(termbox.InputEsc)
+	termbox.SetOutputMode(termbox.OutputNormal)
+	if err != nil {
+		return gui.conflictOperationDone(g, fmt.Errorf("%s: %v", editor, err))
+	}
+	return gui.conflictOperationDone(g, nil)
+}
+
+// abort the merge or the rebase
+func (gui *Gui) abortConflict(g *gocui.Gui, v *gocui.View) error {
+	return gui.conflictOperationDone(g, command.AbortConflict(gui.getSelectedRepository()))
+}
+
+// commit the merge or continue the rebase
+func (gui *Gui) continueConflict(g *gocui.Gui, v *gocui.View) error {
+	return gui.conflictOperationDone(g, command.ContinueConflict(gui.getSelectedRepository()))
+}
+
+// reloads the unmerged files after an operation, the error is shown if the
+// operation failed
+func (gui *Gui) conflictOperationDone(g *gocui.Gui, err error) error {
+	r := gui.getSelectedRepository()
+	files, ferr := command.UnmergedFiles(r)
+	if ferr != nil {
+		return ferr
+	}
+	gui.State.conflicts = files
+	if gui.State.conflictCursor >= len(files) {
+		gui.State.conflictCursor = 0
+	}
+	_ = r.Refresh()
+	_ = r.State.Branch.InitializeCommits(r)
+	if rerr := gui.renderCommits(r); rerr != nil {
+		return rerr
+	}
+	if rerr := gui.renderConflicts(); rerr != nil {
+		return rerr
+	}
+	if err != nil {
+		return gui.openErrorView(g, err.Error(),
+			"The conflict is not resolved, you may need to resolve it manually",
+			dynamicViewFeature.Name)
+	}
+	return nil
+}
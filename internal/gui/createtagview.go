@@ -0,0 +1,174 @@
+package gui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/job"
+	"github.com/jroimartin/gocui"
+)
+
+var (
+	createTagFrameViewFeature   = viewFeature{Name: "createtagframe", Title: " Create Tag "}
+	createTagNameLabelFeature   = viewFeature{Name: "createtagnamelabel", Title: " Name: "}
+	createTagMsgLabelFeature    = viewFeature{Name: "createtagmsglabel", Title: " Message: "}
+	createTagTargetLabelFeature = viewFeature{Name: "createtagtargetlabel", Title: " Branch: "}
+
+	// these views are the inputs of the tag
+	createTagNameViewFeature   = viewFeature{Name: "createtagname", Title: " Name "}
+	createTagMsgViewFeature    = viewFeature{Name: "createtagmsg", Title: " Message "}
+	createTagTargetViewFeature = viewFeature{Name: "createtagtarget", Title: " Branch "}
+
+	createTagViews      = []viewFeature{createTagNameViewFeature, createTagMsgViewFeature, createTagTargetViewFeature}
+	createTagLabelViews = []viewFeature{createTagFrameViewFeature, createTagNameLabelFeature, createTagMsgLabelFeature, createTagTargetLabelFeature}
+)
+
+// open the views to create a tag on the marked repositories, or on the
+// selected one if none is marked
+func (gui *Gui) openCreateTagView(g *gocui.Gui, _ *gocui.View) error {
+	maxX, maxY := g.Size()
+	vFrame, err := g.SetView(createTagFrameViewFeature.Name, maxX/2-30, maxY/2-3, maxX/2+30, maxY/2+3)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		vFrame.Frame = true
+	}
+	gui.renderCreateTagFrame()
+	for i, label := range createTagLabelViews[1:] {
+		input := createTagViews[i]
+		y := maxY/2 - 2 + i
+		vlabel, err := g.SetView(label.Name, maxX/2-30, y, maxX/2-19, y+2)
+		if err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			fmt.Fprintln(vlabel, label.Title)
+			vlabel.Frame = false
+		}
+		v, err := g.SetView(input.Name, maxX/2-18, y, maxX/2+29, y+2)
+		if err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Editable = true
+			v.Frame = false
+		}
+	}
+	g.Cursor = true
+	return gui.focusToView(createTagNameViewFeature.Name)
+}
+
+// renders the signing and pushing options into the frame
+func (gui *Gui) renderCreateTagFrame() {
+	v, err := gui.g.View(createTagFrameViewFeature.Name)
+	if err != nil {
+		return
+	}
+	onOff := func(b bool) string {
+		if b {
+			return green.Sprint("on")
+		}
+		return "off"
+	}
+	v.Title = createTagFrameViewFeature.Title
+	v.Clear()
+	fmt.Fprintf(v, "\n\n\n\n sign: %s%spush: %s\n", onOff(gui.State.signTag), sep, onOff(gui.State.pushTag))
+}
+
+// switch signing the tag
+func (gui *Gui) toggleSignTag(g *gocui.Gui, v *gocui.View) error {
+	gui.State.signTag = !gui.State.signTag
+	gui.renderCreateTagFrame()
+	return nil
+}
+
+// switch pushing the tag
+func (gui *Gui) togglePushTag(g *gocui.Gui, v *gocui.View) error {
+	gui.State.pushTag = !gui.State.pushTag
+	gui.renderCreateTagFrame()
+	return nil
+}
+
+// focus to next input of the tag
+func (gui *Gui) nextCreateTagView(g *gocui.Gui, v *gocui.View) error {
+	return gui.nextViewOfGroup(g, v, createTagViews)
+}
+
+// close the tag views without creating a tag
+func (gui *Gui) closeCreateTagView(g *gocui.Gui, v *gocui.View) error {
+	g.Cursor = false
+	for _, view := range append(createTagViews, createTagLabelViews...) {
+		if err := g.DeleteView(view.Name); err != nil {
+			return err
+		}
+	}
+	return gui.focusToView(mainViewFeature.Name)
+}
+
+// queues the tag jobs of the marked repositories. Nothing is tagged if any of
+// them is dirty or behind its upstream, the blocking ones are listed instead
+func (gui *Gui) submitCreateTagView(g *gocui.Gui, v *gocui.View) error {
+	read := func(vf viewFeature) string {
+		v, err := g.View(vf.Name)
+		if err != nil {
+			return ""
+		}
+		re := regexp.MustCompile(`\r?\n`)
+		return strings.TrimSpace(re.ReplaceAllString(v.ViewBuffer(), ""))
+	}
+	name := read(createTagNameViewFeature)
+	if len(name) == 0 {
+		return nil
+	}
+	opts := command.TagOptions{
+		Name:        name,
+		Message:     read(createTagMsgViewFeature),
+		Target:      read(createTagTargetViewFeature),
+		Sign:        gui.State.signTag,
+		Push:        gui.State.pushTag,
+		CommandMode: command.ModeNative,
+		Sources:     gui.State.Settings.Credentials,
+	}
+	marked := gui.markedRepositories()
+	blocked := make([]string, 0)
+	for _, r := range marked {
+		if err := command.TagBlocker(r, opts.Target); err != nil {
+			blocked = append(blocked, repositoryName(r)+": "+err.Error())
+		}
+	}
+	if err := gui.closeCreateTagView(g, v); err != nil {
+		return err
+	}
+	if len(blocked) > 0 {
+		return gui.openErrorView(g, strings.Join(blocked, "\n"),
+			"Nothing is tagged, commit or pull the changes first",
+			mainViewFeature.Name)
+	}
+	for _, r := range marked {
+		if r.WorkStatus() == git.Queued {
+			if err := gui.State.Queue.RemoveFromQueue(r); err != nil {
+				return err
+			}
+		}
+		o := opts
+		if r.State.Remote != nil {
+			o.RemoteName = r.State.Remote.Name
+		}
+		if err := gui.State.Queue.AddJob(&job.Job{
+			JobType:    job.TagJob,
+			Repository: r,
+			Options:    &o,
+		}); err != nil {
+			return err
+		}
+		r.SetWorkStatus(git.Queued)
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	return gui.startQueue(g, v)
+}
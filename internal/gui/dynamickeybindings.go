@@ -202,6 +202,22 @@ func (gui *Gui) updateDynamicKeybindings() error {
 				Display:     "t",
 				Description: "stash",
 				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'x',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.openConflicts,
+				Display:     "x",
+				Description: "conflicts",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'p',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.openPatch,
+				Display:     "p",
+				Description: "hunks",
+				Vital:       true,
 			}, {
 				View:        dynamicViewFeature.Name,
 				Key:         gocui.KeyArrowDown,
@@ -290,6 +306,200 @@ func (gui *Gui) updateDynamicKeybindings() error {
 			},
 		}
 		keybindings = append(keybindings, caseBindings...)
+	case ConflictMode:
+		caseBindings := []*KeyBinding{
+			{
+				View:        dynamicViewFeature.Name,
+				Key:         's',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.statusStat,
+				Display:     "s",
+				Description: "status",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeyArrowDown,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.conflictCursorDown,
+				Display:     "↓",
+				Description: "Down",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeyArrowUp,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.conflictCursorUp,
+				Display:     "↑",
+				Description: "Up",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'j',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.conflictCursorDown,
+				Display:     "j",
+				Description: "Down",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'k',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.conflictCursorUp,
+				Display:     "k",
+				Description: "Up",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'o',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.resolveWithOurs,
+				Display:     "o",
+				Description: "ours",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         't',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.resolveWithTheirs,
+				Display:     "t",
+				Description: "theirs",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'e',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.editConflict,
+				Display:     "e",
+				Description: "edit",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeySpace,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.markConflictResolved,
+				Display:     "space",
+				Description: "resolved",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'C',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.continueConflict,
+				Display:     "C",
+				Description: "continue",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'A',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.abortConflict,
+				Display:     "A",
+				Description: "abort",
+				Vital:       true,
+			},
+		}
+		keybindings = append(keybindings, caseBindings...)
+	case PatchMode:
+		caseBindings := []*KeyBinding{
+			{
+				View:        dynamicViewFeature.Name,
+				Key:         's',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.statusStat,
+				Display:     "s",
+				Description: "status",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeyArrowDown,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchCursorDown,
+				Display:     "↓",
+				Description: "Down",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeyArrowUp,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchCursorUp,
+				Display:     "↑",
+				Description: "Up",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'j',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchCursorDown,
+				Display:     "j",
+				Description: "Down",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'k',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchCursorUp,
+				Display:     "k",
+				Description: "Up",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'n',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchNextHunk,
+				Display:     "n",
+				Description: "next hunk",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'p',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.patchPrevHunk,
+				Display:     "p",
+				Description: "prev hunk",
+				Vital:       false,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeySpace,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.togglePatchLine,
+				Display:     "space",
+				Description: "select line",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'a',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.togglePatchHunk,
+				Display:     "a",
+				Description: "select hunk",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         gocui.KeyEnter,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.applyPatch,
+				Display:     "enter",
+				Description: "stage/unstage",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'u',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.switchPatchSide,
+				Display:     "u",
+				Description: "staged/unstaged",
+				Vital:       true,
+			}, {
+				View:        dynamicViewFeature.Name,
+				Key:         'x',
+				Modifier:    gocui.ModNone,
+				Handler:     gui.openPatchConfirmView,
+				Display:     "x",
+				Description: "discard",
+				Vital:       true,
+			},
+		}
+		keybindings = append(keybindings, caseBindings...)
 	default:
 
 	}
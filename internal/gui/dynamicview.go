@@ -24,6 +24,10 @@ const (
 	StatusMode DynamicViewMode = " Repository Status "
 	// FileDiffMode when dynamic mode morphed into file diff mode
 	FileDiffMode DynamicViewMode = " File Diffs "
+	// ConflictMode when dynamic mode morphed into conflict resolution mode
+	ConflictMode DynamicViewMode = " Conflicts "
+	// PatchMode when dynamic mode morphed into hunk staging mode
+	PatchMode DynamicViewMode = " Hunks "
 )
 
 // shows the stats of current commit
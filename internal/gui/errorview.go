@@ -1,8 +1,10 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
 
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/jroimartin/gocui"
 )
 
@@ -26,6 +28,33 @@ func (gui *Gui) openErrorView(g *gocui.Gui, message, note, returnViewName string
 	return gui.focusToView(errorViewFeature.Name)
 }
 
+// open the full output of the last failed operation of the selected
+// repository, the view scrolls if the output is long
+func (gui *Gui) openRawErrorView(g *gocui.Gui, v *gocui.View) error {
+	r := gui.getSelectedRepository()
+	if r == nil || r.State.Error == nil {
+		return nil
+	}
+	raw := r.State.Error.Error() + "\n"
+	var gitErr *gerr.Error
+	if errors.As(r.State.Error, &gitErr) {
+		raw = gitErr.Raw()
+	}
+	maxX, maxY := g.Size()
+	errorReturnView = v.Name()
+	ev, err := g.SetView(errorViewFeature.Name, maxX/2-40, maxY/2-10, maxX/2+40, maxY/2+10)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		ev.Title = " Output of " + r.Name + " "
+		ev.Wrap = true
+	}
+	ev.Clear()
+	fmt.Fprint(ev, raw)
+	return gui.focusToView(errorViewFeature.Name)
+}
+
 // close the opened error view
 func (gui *Gui) closeErrorView(g *gocui.Gui, v *gocui.View) error {
 
@@ -68,12 +68,24 @@ func (gui *Gui) switchToMergeMode(g *gocui.Gui, v *gocui.View) error {
 	return gui.updateKeyBindingsView(g, mainViewFeature.Name)
 }
 
+// switch to the next strategy of pull and merge modes
+func (gui *Gui) switchStrategy(g *gocui.Gui, v *gocui.View) error {
+	gui.State.Settings.Strategy = gui.State.Settings.Strategy.Next()
+	return gui.updateKeyBindingsView(g, mainViewFeature.Name)
+}
+
 // switch the app's mode to checkout
 func (gui *Gui) switchToCheckoutMode(g *gocui.Gui, v *gocui.View) error {
 	gui.State.Mode = checkoutMode
 	return gui.updateKeyBindingsView(g, mainViewFeature.Name)
 }
 
+// switch the app's mode to push
+func (gui *Gui) switchToPushMode(g *gocui.Gui, v *gocui.View) error {
+	gui.State.Mode = pushMode
+	return gui.updateKeyBindingsView(g, mainViewFeature.Name)
+}
+
 // if the cursor down past the last item, move it to the last line
 // nolint: unused
 func (gui *Gui) correctCursor(v *gocui.View) error {
@@ -0,0 +1,192 @@
+package gui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/jroimartin/gocui"
+)
+
+// repositoryPredicate decides if a repository matches a term of the filter
+type repositoryPredicate func(r *git.Repository) bool
+
+// comparison predicates such as behind>0 or ahead<=2
+var countPredicate = regexp.MustCompile(`^(ahead|behind)(>=|<=|>|<|=)(\d+)$`)
+
+// predicates are the structured terms of the filter, every other term is
+// fuzzy matched against the name, branch and path of the repository
+var predicates = map[string]repositoryPredicate{
+	"dirty": func(r *git.Repository) bool {
+		return r.State.Branch != nil && !r.State.Branch.Clean
+	},
+	"clean": func(r *git.Repository) bool {
+		return r.State.Branch != nil && r.State.Branch.Clean
+	},
+	"failed": func(r *git.Repository) bool {
+		return r.WorkStatus() == git.Fail
+	},
+	"detached": func(r *git.Repository) bool {
+		b := r.State.Branch
+		return b != nil && b.Reference != nil && !b.Reference.Name().IsBranch()
+	},
+	"no-upstream": func(r *git.Repository) bool {
+		return r.State.Branch != nil && r.State.Branch.Upstream == nil
+	},
+}
+
+// visible returns true if the repository is listed in the main view
+func (gui *Gui) visible(r *git.Repository) bool {
+	if !gui.inGroup(r) {
+		return false
+	}
+	for _, p := range gui.State.predicates {
+		if !p(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// showRepositories lists the repositories that are in the shown group and
+// match the filter, the cursor is moved to the top
+func (gui *Gui) showRepositories() error {
+	rs := make([]*git.Repository, 0)
+	for _, r := range gui.State.allRepositories {
+		if gui.visible(r) {
+			rs = append(rs, r)
+		}
+	}
+	gui.State.Repositories = rs
+	if v, err := gui.g.View(mainViewFeature.Name); err == nil {
+		_ = v.SetCursor(0, 0)
+		_ = v.SetOrigin(0, 0)
+	}
+	if err := gui.renderTitle(); err != nil {
+		return err
+	}
+	return gui.renderMain()
+}
+
+// applyFilter narrows the main view to the repositories matching all of the
+// terms of the query
+func (gui *Gui) applyFilter(query string) error {
+	gui.State.query = query
+	gui.State.predicates = parseFilter(query)
+	return gui.showRepositories()
+}
+
+// filterEditor narrows the list as the filter is typed
+func (gui *Gui) filterEditor() gocui.Editor {
+	return gocui.EditorFunc(func(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+		gocui.DefaultEditor.Edit(v, key, ch, mod)
+		_ = gui.applyFilter(strings.TrimSpace(v.Buffer()))
+	})
+}
+
+// open the filter prompt with the current query
+func (gui *Gui) openFilterView(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.SetViewOnTop(filterViewFeature.Name); err != nil {
+		return err
+	}
+	return gui.focusToView(filterViewFeature.Name)
+}
+
+// close the filter prompt and keep the list filtered
+func (gui *Gui) closeFilterView(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.SetViewOnBottom(filterViewFeature.Name); err != nil {
+		return err
+	}
+	return gui.focusToView(mainViewFeature.Name)
+}
+
+// clear the filter and close the prompt
+func (gui *Gui) clearFilter(g *gocui.Gui, v *gocui.View) error {
+	v.Clear()
+	_ = v.SetCursor(0, 0)
+	_ = v.SetOrigin(0, 0)
+	if err := gui.applyFilter(""); err != nil {
+		return err
+	}
+	return gui.closeFilterView(g, v)
+}
+
+// parseFilter converts the space separated terms of the query to predicates,
+// unknown terms are fuzzy matched
+func parseFilter(query string) []repositoryPredicate {
+	ps := make([]repositoryPredicate, 0)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if p, ok := predicates[term]; ok {
+			ps = append(ps, p)
+		} else if m := countPredicate.FindStringSubmatch(term); m != nil {
+			ps = append(ps, countMatcher(m[1], m[2], m[3]))
+		} else {
+			ps = append(ps, fuzzyMatcher(term))
+		}
+	}
+	return ps
+}
+
+// countMatcher compares the ahead or behind count of the branch, unknown
+// counts never match
+func countMatcher(field, op, value string) repositoryPredicate {
+	n, _ := strconv.Atoi(value)
+	return func(r *git.Repository) bool {
+		b := r.State.Branch
+		if b == nil {
+			return false
+		}
+		s := b.Pushables
+		if field == "behind" {
+			s = b.Pullables
+		}
+		count, err := strconv.Atoi(s)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">":
+			return count > n
+		case "<":
+			return count < n
+		case ">=":
+			return count >= n
+		case "<=":
+			return count <= n
+		}
+		return count == n
+	}
+}
+
+// fuzzyMatcher matches if the letters of the term appear in the same order in
+// the name, the branch or the path of the repository
+func fuzzyMatcher(term string) repositoryPredicate {
+	return func(r *git.Repository) bool {
+		candidates := []string{r.Name, r.AbsPath}
+		if r.State.Branch != nil {
+			candidates = append(candidates, r.State.Branch.Name)
+		}
+		for _, c := range candidates {
+			if fuzzyMatch(term, c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func fuzzyMatch(term, s string) bool {
+	runes := []rune(term)
+	i := 0
+	for _, c := range s {
+		if i == len(runes) {
+			break
+		}
+		if unicode.ToLower(c) == runes[i] {
+			i++
+		}
+	}
+	return i == len(runes)
+}
@@ -0,0 +1,114 @@
+package gui
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepository returns a repository on the branch with the given counts of
+// pushable and pullable commits
+func testRepository(name, branch, ahead, behind string, clean bool) *git.Repository {
+	r := git.NewRepository("/work/" + name)
+	r.State.Branch = &git.Branch{
+		Name:      branch,
+		Reference: plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), plumbing.ZeroHash),
+		Upstream:  &git.RemoteBranch{Name: "origin/" + branch},
+		Pushables: ahead,
+		Pullables: behind,
+		Clean:     clean,
+	}
+	return r
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	var tests = []struct {
+		term     string
+		input    string
+		expected bool
+	}{
+		{"gb", "gitbatch", true},
+		{"gitbatch", "GitBatch", true},
+		{"", "gitbatch", true},
+		{"bg", "gitbatch", false},
+		{"gitbatchx", "gitbatch", false},
+		{"api", "services/api", true},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, fuzzyMatch(test.term, test.input), test.term+" "+test.input)
+	}
+}
+
+func TestCountMatcher(t *testing.T) {
+	r := testRepository("api", "master", "2", "0", true)
+	unknown := testRepository("web", "master", "?", "?", true)
+	var tests = []struct {
+		field    string
+		op       string
+		value    string
+		input    *git.Repository
+		expected bool
+	}{
+		{"ahead", ">", "0", r, true},
+		{"ahead", ">", "2", r, false},
+		{"ahead", ">=", "2", r, true},
+		{"ahead", "<", "2", r, false},
+		{"ahead", "<=", "2", r, true},
+		{"ahead", "=", "2", r, true},
+		{"behind", "=", "0", r, true},
+		{"behind", ">", "0", r, false},
+		// unknown counts never match
+		{"ahead", ">=", "0", unknown, false},
+		{"behind", "<", "1", unknown, false},
+	}
+	for _, test := range tests {
+		expr := test.field + test.op + test.value
+		require.Equal(t, test.expected, countMatcher(test.field, test.op, test.value)(test.input), expr)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	api := testRepository("api", "master", "1", "0", false)
+	web := testRepository("web", "develop", "0", "3", true)
+	web.State.Branch.Upstream = nil
+	cli := testRepository("cli", "master", "0", "0", true)
+	cli.State.Branch.Reference = plumbing.NewHashReference(plumbing.HEAD, plumbing.ZeroHash)
+	cli.SetWorkStatus(git.Fail)
+	all := []*git.Repository{api, web, cli}
+
+	var tests = []struct {
+		query    string
+		expected []*git.Repository
+	}{
+		{"", all},
+		{"dirty", []*git.Repository{api}},
+		{"clean", []*git.Repository{web, cli}},
+		{"failed", []*git.Repository{cli}},
+		{"detached", []*git.Repository{cli}},
+		{"no-upstream", []*git.Repository{web}},
+		{"behind>0", []*git.Repository{web}},
+		{"ahead>=1", []*git.Repository{api}},
+		{"Clean behind=0", []*git.Repository{cli}},
+		// the other terms are fuzzy matched with the name, path and branch
+		{"dvlp", []*git.Repository{web}},
+		{"work", all},
+		{"clean mstr", []*git.Repository{cli}},
+		{"nothing", []*git.Repository{}},
+	}
+	for _, test := range tests {
+		ps := parseFilter(test.query)
+		matched := make([]*git.Repository, 0)
+		for _, r := range all {
+			ok := true
+			for _, p := range ps {
+				ok = ok && p(r)
+			}
+			if ok {
+				matched = append(matched, r)
+			}
+		}
+		require.Equal(t, test.expected, matched, test.query)
+	}
+}
@@ -2,6 +2,7 @@ package gui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/jroimartin/gocui"
@@ -62,6 +63,10 @@ func (gui *Gui) renderCommits(r *git.Repository) error {
 		return err
 	}
 	v.Clear()
+	v.Title = commitViewFeature.Title
+	if t, since, err := r.LatestTag(); err == nil && t != nil {
+		v.Title = fmt.Sprintf("%s(%s +%d) ", v.Title, t.Name, since)
+	}
 	cs := r.State.Branch.Commits
 	// bc := r.State.Branch.State.Commit
 	si := 0
@@ -74,7 +79,11 @@ func (gui *Gui) renderCommits(r *git.Repository) error {
 		// 	continue
 		// }
 
-		fmt.Fprintln(v, tab+commitLabel(c, false))
+		label := commitLabel(c, false)
+		if tags := r.TagsOf(c.Hash); len(tags) > 0 {
+			label = label + ws + green.Sprint("("+strings.Join(tags, ", ")+")")
+		}
+		fmt.Fprintln(v, tab+label)
 	}
 	_ = adjustAnchor(si, len(cs), v)
 	return nil
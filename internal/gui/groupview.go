@@ -0,0 +1,98 @@
+package gui
+
+import (
+	"strings"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/jroimartin/gocui"
+)
+
+// inGroup returns true if the repository is in the group that is shown, all
+// of the repositories are shown if there is no group selected
+func (gui *Gui) inGroup(r *git.Repository) bool {
+	return len(gui.State.group) == 0 || gui.State.Groups.Has(r.AbsPath, gui.State.group)
+}
+
+// frameTitle returns the title of the main view with the group and the
+// filter that are applied
+func (gui *Gui) frameTitle() string {
+	title := mainViewFrameFeature.Title
+	if len(gui.State.group) > 0 {
+		title = title + "[" + gui.State.group + "] "
+	}
+	if len(gui.State.query) > 0 {
+		title = title + "/" + gui.State.query + " "
+	}
+	return title
+}
+
+// nextGroup switches the main view to the next group, after the last one
+// all of the repositories are shown again. Selecting all repositories marks
+// the whole group since only the group is listed
+func (gui *Gui) nextGroup(g *gocui.Gui, v *gocui.View) error {
+	names := gui.State.Groups.Names()
+	next := ""
+	for i, name := range names {
+		if len(gui.State.group) == 0 {
+			next = name
+			break
+		}
+		if name == gui.State.group && i+1 < len(names) {
+			next = names[i+1]
+			break
+		}
+	}
+	return gui.showGroup(next)
+}
+
+// showGroup lists the repositories of the group in the main view
+func (gui *Gui) showGroup(name string) error {
+	gui.State.group = name
+	return gui.showRepositories()
+}
+
+// open the tag view to toggle a tag of the selected repository
+func (gui *Gui) openTagView(g *gocui.Gui, v *gocui.View) error {
+	r := gui.getSelectedRepository()
+	if r == nil {
+		return nil
+	}
+	tv, err := g.SetViewOnTop(tagViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	tv.Title = tagViewFeature.Title
+	if tags := gui.State.Groups.Of(r.AbsPath); len(tags) > 0 {
+		tv.Title = tv.Title + "(" + strings.Join(tags, ", ") + ") "
+	}
+	return gui.focusToView(tagViewFeature.Name)
+}
+
+// close the tag view without changing the tags
+func (gui *Gui) closeTagView(g *gocui.Gui, v *gocui.View) error {
+	v.Clear()
+	_ = v.SetCursor(0, 0)
+	if _, err := g.SetViewOnBottom(tagViewFeature.Name); err != nil {
+		return err
+	}
+	return gui.focusToView(mainViewFeature.Name)
+}
+
+// toggle the entered tag of the selected repository and close the tag view,
+// the main view is refreshed in case the repository left the shown group
+func (gui *Gui) closeTagViewWithToggle(g *gocui.Gui, v *gocui.View) error {
+	tag := strings.TrimSpace(v.ViewBuffer())
+	r := gui.getSelectedRepository()
+	if len(tag) == 0 || r == nil {
+		return gui.closeTagView(g, v)
+	}
+	if err := gui.closeTagView(g, v); err != nil {
+		return err
+	}
+	if _, err := gui.State.Groups.Toggle(r.AbsPath, tag); err != nil {
+		return gui.openErrorView(g, err.Error(),
+			"The tag is kept until the application is closed",
+			mainViewFeature.Name)
+	}
+	return gui.showGroup(gui.State.group)
+}
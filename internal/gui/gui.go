@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/group"
 	"github.com/isacikgoz/gitbatch/internal/job"
 	"github.com/isacikgoz/gitbatch/internal/load"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
 	"github.com/jroimartin/gocui"
 )
 
@@ -26,11 +30,67 @@ type Gui struct {
 type guiState struct {
 	Repositories  []*git.Repository
 	Directories   []string
+	Groups        *group.Groups
 	Mode          mode
 	Queue         *job.Queue
 	FailoverQueue *job.Queue
-	targetBranch  string
-	totalBranches []*branchCountMap
+	Settings      QueueSettings
+	Manifest      string
+	revisions     map[string]string
+	// all of the loaded repositories, Repositories are the ones in the group
+	// that is shown
+	allRepositories []*git.Repository
+	group           string
+	query           string
+	predicates      []repositoryPredicate
+	targetBranch    string
+	// stale branches of the cleanup view
+	staleBranches []*staleBranch
+	staleCursor   int
+	deleteRemote  bool
+	// options of the tag to be created
+	signTag bool
+	pushTag bool
+	// state of the stash prompt, stashMessage is the message of the last
+	// batch stash
+	stashPrompt    stashPrompt
+	stashID        int
+	stashMessage   string
+	stashUntracked bool
+	stashKeepIndex bool
+	// unmerged files of the conflict view
+	conflicts      []*git.File
+	conflictCursor int
+	// hunks of the file in the patch view, the cursor is at a line of a hunk
+	patchFile      *git.File
+	patchDiff      *command.FileDiff
+	patchStaged    bool
+	patchSelection command.LineSelection
+	patchHunk      int
+	patchLine      int
+	totalBranches  []*branchCountMap
+}
+
+// QueueSettings defines how the jobs of the gui are executed
+type QueueSettings struct {
+	// Timeout is the maximum duration of a single job, zero means no limit
+	Timeout time.Duration
+	// Workers is the count of repositories processed at the same time
+	Workers int
+	// HostWorkers is the count of repositories processed at the same time
+	// against the same remote host, zero means no limit
+	HostWorkers int
+	// Strategy is the way pull and merge incorporate the upstream changes
+	Strategy command.Strategy
+	// Fetch holds the options of the fetch jobs, the remote is set for each
+	// repository
+	Fetch command.FetchOptions
+	// StaleDays is the age of the last commit of a branch to be listed as
+	// inactive in the cleanup view, zero means never
+	StaleDays int
+	// Credentials are the configured sources of the tokens of the http
+	// remotes, matched by host
+	Credentials command.CredentialSources
 }
 
 // this struct encapsulates the name and title of a view. the name of a view is
@@ -67,6 +127,8 @@ const (
 	MergeMode = "merge"
 	// CheckoutMode checkout selected repositories
 	CheckoutMode = "checkout"
+	// PushMode puts the gui in push state
+	PushMode = "push"
 
 	overview Layout = 0
 	focus    Layout = 1
@@ -86,23 +148,31 @@ var (
 	errorViewFeature         = viewFeature{Name: "error", Title: " Error "}
 	dynamicViewFeature       = viewFeature{Name: "dynamic", Title: " Dynamic "}
 	stashViewFeature         = viewFeature{Name: "stash", Title: " Stash "}
+	tagViewFeature           = viewFeature{Name: "tag", Title: " Toggle Tag "}
+	filterViewFeature        = viewFeature{Name: "filter", Title: " Filter "}
 
 	fetchMode    = mode{ModeID: FetchMode, DisplayString: "Fetch", CommandString: "fetch"}
 	pullMode     = mode{ModeID: PullMode, DisplayString: "Pull", CommandString: "pull"}
 	mergeMode    = mode{ModeID: MergeMode, DisplayString: "Merge", CommandString: "merge"}
 	checkoutMode = mode{ModeID: CheckoutMode, DisplayString: "Checkout", CommandString: "checkout"}
+	pushMode     = mode{ModeID: PushMode, DisplayString: "Push", CommandString: "push"}
 
-	modes = []mode{fetchMode, pullMode, mergeMode}
+	modes = []mode{fetchMode, pullMode, mergeMode, pushMode}
 	// mainViews = []viewFeature{mainViewFeature, commitViewFeature, dynamicViewFeature, remoteViewFeature, remoteBranchViewFeature, branchViewFeature, stashViewFeature}
 	loaded = make(chan bool)
 )
 
 // New creates a Gui object and fill it's state related entities
-func New(mode string, directories []string) (*Gui, error) {
+func New(mode string, directories []string, settings QueueSettings, manifestFile string, groups *group.Groups) (*Gui, error) {
+	if groups == nil {
+		groups = group.New(nil, nil)
+	}
 	initialState := guiState{
 		Directories:   directories,
+		Groups:        groups,
 		Mode:          fetchMode,
-		Queue:         job.CreateJobQueue(),
+		Settings:      settings,
+		Manifest:      manifestFile,
 		FailoverQueue: job.CreateJobQueue(),
 	}
 	gui := &Gui{
@@ -115,9 +185,26 @@ func New(mode string, directories []string) (*Gui, error) {
 			break
 		}
 	}
+	gui.State.Queue = gui.createJobQueue()
+	// the declared revisions are only displayed, a manifest that cannot be
+	// loaded is reported when it is synced
+	if len(manifestFile) > 0 {
+		if m, err := manifest.Load(manifestFile); err == nil {
+			gui.State.revisions = m.Revisions()
+		}
+	}
 	return gui, nil
 }
 
+// createJobQueue creates a job queue with the settings of the gui
+func (gui *Gui) createJobQueue() *job.Queue {
+	q := job.CreateJobQueue()
+	q.SetTimeout(gui.State.Settings.Timeout)
+	q.SetWorkers(gui.State.Settings.Workers)
+	q.SetHostLimit(gui.State.Settings.HostWorkers)
+	return q
+}
+
 // Run function runs the main loop with initial values
 func (gui *Gui) Run() error {
 	g, err := gocui.NewGui(gocui.OutputNormal)
@@ -135,7 +222,7 @@ func (gui *Gui) Run() error {
 
 	// load repositories in background asynchronously
 	go func() {
-		_ = load.AsyncLoad(gui.State.Directories, gui.loadRepository, loaded)
+		_ = load.AsyncLoad(gui.State.Directories, gui.loadRepository, loaded, gui.State.Settings.Workers)
 	}()
 
 	if err := gui.generateKeybindings(); err != nil {
@@ -153,12 +240,11 @@ func (gui *Gui) Run() error {
 
 // add repository to gui's own slice and register listeners
 func (gui *Gui) loadRepository(r *git.Repository) {
+	gui.State.allRepositories = insertRepository(gui.State.allRepositories, r)
 	rs := gui.State.Repositories
-	// insertion sort implementation
-	index := sort.Search(len(rs), func(i int) bool { return git.Less(r, rs[i]) })
-	rs = append(rs, &git.Repository{})
-	copy(rs[index+1:], rs[index:])
-	rs[index] = r
+	if gui.visible(r) {
+		rs = insertRepository(rs, r)
+	}
 	// add listener
 	r.On(git.RepositoryUpdated, gui.repositoryUpdated)
 	r.On(git.BranchUpdated, gui.branchUpdated)
@@ -173,18 +259,27 @@ func (gui *Gui) loadRepository(r *git.Repository) {
 			if err != nil {
 				return
 			}
-			v.Title = mainViewFrameFeature.Title + fmt.Sprintf("(%d) ", len(gui.State.Repositories))
+			v.Title = gui.frameTitle() + fmt.Sprintf("(%d) ", len(gui.State.Repositories))
 		}
 	}()
 }
 
+// insertion sort implementation
+func insertRepository(rs []*git.Repository, r *git.Repository) []*git.Repository {
+	index := sort.Search(len(rs), func(i int) bool { return git.Less(r, rs[i]) })
+	rs = append(rs, &git.Repository{})
+	copy(rs[index+1:], rs[index:])
+	rs[index] = r
+	return rs
+}
+
 // render title with loaded repository count
 func (gui *Gui) renderTitle() error {
 	v, err := gui.g.View(mainViewFrameFeature.Name)
 	if err != nil {
 		return err
 	}
-	v.Title = mainViewFrameFeature.Title + fmt.Sprintf("(%d/%d) ", len(gui.State.Repositories), len(gui.State.Directories))
+	v.Title = gui.frameTitle() + fmt.Sprintf("(%d/%d) ", len(gui.State.Repositories), len(gui.State.Directories))
 	return nil
 }
 
@@ -0,0 +1,223 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/jroimartin/gocui"
+)
+
+var (
+	hygieneViewFeature        = viewFeature{Name: "hygiene", Title: " Branch Cleanup "}
+	hygieneConfirmViewFeature = viewFeature{Name: "hygiene-confirm", Title: " Delete Branches "}
+)
+
+// staleBranch is a stale branch of a repository listed in the cleanup view
+type staleBranch struct {
+	Repository *git.Repository
+	Branch     *command.StaleBranch
+	selected   bool
+}
+
+// open the cleanup view with the stale branches of the loaded repositories.
+// The remotes are not asked for the default branch since the view would wait
+// on them, the repositories that do not know it locally are skipped
+func (gui *Gui) openHygieneView(g *gocui.Gui, v *gocui.View) error {
+	gui.State.staleBranches = make([]*staleBranch, 0)
+	gui.State.staleCursor = 0
+	for _, r := range gui.State.allRepositories {
+		if len(r.DefaultBranch) == 0 {
+			continue
+		}
+		bs, err := command.StaleBranches(r, &command.StaleOptions{
+			Base:         r.DefaultBranch,
+			InactiveDays: gui.State.Settings.StaleDays,
+		})
+		if err != nil {
+			continue
+		}
+		for _, b := range bs {
+			gui.State.staleBranches = append(gui.State.staleBranches, &staleBranch{Repository: r, Branch: b})
+		}
+	}
+	if _, err := g.SetViewOnTop(hygieneViewFeature.Name); err != nil {
+		return err
+	}
+	if err := gui.renderHygiene(); err != nil {
+		return err
+	}
+	return gui.focusToView(hygieneViewFeature.Name)
+}
+
+// close the cleanup view
+func (gui *Gui) closeHygieneView(g *gocui.Gui, v *gocui.View) error {
+	if _, err := g.SetViewOnBottom(hygieneViewFeature.Name); err != nil {
+		return err
+	}
+	return gui.focusToView(mainViewFeature.Name)
+}
+
+// renders the stale branches, the selected ones are marked and the one under
+// the cursor is highlighted
+func (gui *Gui) renderHygiene() error {
+	v, err := gui.g.View(hygieneViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	v.Clear()
+	v.Title = hygieneViewFeature.Title
+	if gui.State.deleteRemote {
+		v.Title = v.Title + "(with remote branches) "
+	}
+	if len(gui.State.staleBranches) == 0 {
+		fmt.Fprintln(v, tab+"no stale branches")
+		return nil
+	}
+	for i, s := range gui.State.staleBranches {
+		mark := "[ ]"
+		if s.selected {
+			mark = "[" + green.Sprint("x") + "]"
+		}
+		line := fmt.Sprintf("%s %s %s%s%s%s(%s)", mark, repositoryName(s.Repository), cyan.Sprint(s.Branch.Branch.Name),
+			sep, s.Branch.String(), ws, s.Branch.LastCommit.Format("2006-01-02"))
+		if i == gui.State.staleCursor {
+			fmt.Fprintln(v, selectionIndicator+line)
+		} else {
+			fmt.Fprintln(v, tab+tab+line)
+		}
+	}
+	return adjustAnchor(gui.State.staleCursor, len(gui.State.staleBranches), v)
+}
+
+// moves the cursor of the cleanup view downwards
+func (gui *Gui) hygieneCursorDown(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.staleCursor+1 >= len(gui.State.staleBranches) {
+		return nil
+	}
+	gui.State.staleCursor++
+	return gui.renderHygiene()
+}
+
+// moves the cursor of the cleanup view upwards
+func (gui *Gui) hygieneCursorUp(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.staleCursor <= 0 {
+		return nil
+	}
+	gui.State.staleCursor--
+	return gui.renderHygiene()
+}
+
+// select or deselect the branch under the cursor
+func (gui *Gui) toggleStaleBranch(g *gocui.Gui, v *gocui.View) error {
+	if len(gui.State.staleBranches) == 0 {
+		return nil
+	}
+	s := gui.State.staleBranches[gui.State.staleCursor]
+	s.selected = !s.selected
+	return gui.renderHygiene()
+}
+
+// select all of the branches, or deselect them if all are already selected
+func (gui *Gui) toggleAllStaleBranches(g *gocui.Gui, v *gocui.View) error {
+	all := true
+	for _, s := range gui.State.staleBranches {
+		all = all && s.selected
+	}
+	for _, s := range gui.State.staleBranches {
+		s.selected = !all
+	}
+	return gui.renderHygiene()
+}
+
+// switch deleting the upstreams of the branches from their remotes
+func (gui *Gui) toggleDeleteRemote(g *gocui.Gui, v *gocui.View) error {
+	gui.State.deleteRemote = !gui.State.deleteRemote
+	return gui.renderHygiene()
+}
+
+// opens the confirmation with a dry run of the deletion of the selected
+// branches
+func (gui *Gui) openHygieneConfirmView(g *gocui.Gui, v *gocui.View) error {
+	lines := make([]string, 0)
+	for _, s := range gui.State.staleBranches {
+		if !s.selected {
+			continue
+		}
+		refs, err := command.DeleteStaleBranch(s.Repository, s.Branch, &command.DeleteBranchOptions{
+			Remote: gui.State.deleteRemote,
+			DryRun: true,
+		})
+		if err != nil {
+			continue
+		}
+		lines = append(lines, repositoryName(s.Repository)+": "+strings.Join(refs, ", "))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	cv, err := g.SetView(hygieneConfirmViewFeature.Name, maxX/2-35, maxY/2-len(lines)/2-2, maxX/2+35, maxY/2+len(lines)/2+2)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		cv.Title = hygieneConfirmViewFeature.Title
+		cv.Wrap = true
+	}
+	cv.Clear()
+	for _, line := range lines {
+		fmt.Fprintln(cv, ws+red.Sprint("delete")+ws+line)
+	}
+	return gui.focusToView(hygieneConfirmViewFeature.Name)
+}
+
+// close the confirmation without deleting anything
+func (gui *Gui) closeHygieneConfirmView(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView(v.Name()); err != nil {
+		return err
+	}
+	return gui.closeViewCleanup(hygieneViewFeature.Name)
+}
+
+// deletes the selected branches, the first failure is reported and the rest
+// of the branches are still deleted
+func (gui *Gui) confirmHygieneDelete(g *gocui.Gui, v *gocui.View) error {
+	var failure error
+	remaining := make([]*staleBranch, 0)
+	refreshed := make(map[*git.Repository]bool)
+	for _, s := range gui.State.staleBranches {
+		if !s.selected {
+			remaining = append(remaining, s)
+			continue
+		}
+		if _, err := command.DeleteStaleBranch(s.Repository, s.Branch, &command.DeleteBranchOptions{
+			Remote: gui.State.deleteRemote,
+		}); err != nil {
+			if failure == nil {
+				failure = fmt.Errorf("%s: %s: %v", repositoryName(s.Repository), s.Branch.Branch.Name, err)
+			}
+			remaining = append(remaining, s)
+			continue
+		}
+		refreshed[s.Repository] = true
+	}
+	for r := range refreshed {
+		_ = r.Refresh()
+	}
+	gui.State.staleBranches = remaining
+	gui.State.staleCursor = 0
+	if err := gui.closeHygieneConfirmView(g, v); err != nil {
+		return err
+	}
+	if err := gui.renderHygiene(); err != nil {
+		return err
+	}
+	if failure != nil {
+		return gui.openErrorView(g, failure.Error(),
+			"The branch is kept, you may need to delete it manually",
+			hygieneViewFeature.Name)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package gui
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/jroimartin/gocui"
 )
 
@@ -174,6 +176,52 @@ func (gui *Gui) generateKeybindings() error {
 		}
 		gui.KeyBindings = append(gui.KeyBindings, commitKeybindings...)
 	}
+	for _, view := range createTagViews {
+		createTagKeybindings := []*KeyBinding{
+			{
+				View:        view.Name,
+				Key:         gocui.KeyEsc,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.closeCreateTagView,
+				Display:     "esc",
+				Description: "Close/Cancel",
+				Vital:       true,
+			}, {
+				View:        view.Name,
+				Key:         gocui.KeyTab,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.nextCreateTagView,
+				Display:     "tab",
+				Description: "Next Panel",
+				Vital:       true,
+			}, {
+				View:        view.Name,
+				Key:         gocui.KeyCtrlG,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.toggleSignTag,
+				Display:     "ctrl + g",
+				Description: "Sign",
+				Vital:       true,
+			}, {
+				View:        view.Name,
+				Key:         gocui.KeyCtrlP,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.togglePushTag,
+				Display:     "ctrl + p",
+				Description: "Push",
+				Vital:       true,
+			}, {
+				View:        view.Name,
+				Key:         gocui.KeyEnter,
+				Modifier:    gocui.ModNone,
+				Handler:     gui.submitCreateTagView,
+				Display:     "enter",
+				Description: "Submit",
+				Vital:       true,
+			},
+		}
+		gui.KeyBindings = append(gui.KeyBindings, createTagKeybindings...)
+	}
 	individualKeybindings := []*KeyBinding{
 		// Main view controls
 		{
@@ -216,6 +264,22 @@ func (gui *Gui) generateKeybindings() error {
 			Display:     "c",
 			Description: "Checkout mode",
 			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         's',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.switchStrategy,
+			Display:     "s",
+			Description: "Pull/merge strategy",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'P',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.switchToPushMode,
+			Display:     "P",
+			Description: "Push mode",
+			Vital:       false,
 		}, {
 			View:        mainViewFeature.Name,
 			Key:         gocui.KeyTab,
@@ -328,6 +392,94 @@ func (gui *Gui) generateKeybindings() error {
 			Display:     "backspace",
 			Description: "Deselect All",
 			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'x',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.cancelJob,
+			Display:     "x",
+			Description: "Cancel job",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'X',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.cancelAllJobs,
+			Display:     "X",
+			Description: "Cancel all jobs",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'S',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.syncManifest,
+			Display:     "S",
+			Description: "Sync with workspace manifest",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'D',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.checkoutDefaultBranches,
+			Display:     "D",
+			Description: "Checkout default branch of selected",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'T',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openCreateTagView,
+			Display:     "T",
+			Description: "Create tag on selected",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'B',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openHygieneView,
+			Display:     "B",
+			Description: "Clean up stale branches",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'z',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openStashAllView,
+			Display:     "z",
+			Description: "Stash dirty repositories",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'Z',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openUnstashAllView,
+			Display:     "Z",
+			Description: "Pop stashes by message",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         '/',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openFilterView,
+			Display:     "/",
+			Description: "Filter repositories",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'g',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.nextGroup,
+			Display:     "g",
+			Description: "Show next group",
+			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         't',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openTagView,
+			Display:     "t",
+			Description: "Tag repository",
+			Vital:       false,
 		}, {
 			View:        mainViewFeature.Name,
 			Key:         'h',
@@ -368,6 +520,14 @@ func (gui *Gui) generateKeybindings() error {
 			Display:     "d",
 			Description: "Sort repositories by Modification date",
 			Vital:       false,
+		}, {
+			View:        mainViewFeature.Name,
+			Key:         'e',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openRawErrorView,
+			Display:     "e",
+			Description: "Show the output of the last error",
+			Vital:       false,
 		}, {
 			View:        "",
 			Key:         gocui.KeyCtrlC,
@@ -448,6 +608,38 @@ func (gui *Gui) generateKeybindings() error {
 			Display:     "enter",
 			Description: "add",
 			Vital:       true,
+		}, {
+			View:        filterViewFeature.Name,
+			Key:         gocui.KeyEsc,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.clearFilter,
+			Display:     "esc",
+			Description: "clear filter",
+			Vital:       true,
+		}, {
+			View:        filterViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeFilterView,
+			Display:     "enter",
+			Description: "apply filter",
+			Vital:       true,
+		}, {
+			View:        tagViewFeature.Name,
+			Key:         gocui.KeyEsc,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeTagView,
+			Display:     "esc",
+			Description: "close/cancel",
+			Vital:       true,
+		}, {
+			View:        tagViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeTagViewWithToggle,
+			Display:     "enter",
+			Description: "toggle tag",
+			Vital:       true,
 		},
 		// CommitView
 		{
@@ -572,6 +764,202 @@ func (gui *Gui) generateKeybindings() error {
 			Display:     "o",
 			Description: "Pop item",
 			Vital:       true,
+		}, {
+			View:        stashViewFeature.Name,
+			Key:         'a',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.stashApply,
+			Display:     "a",
+			Description: "Apply item",
+			Vital:       true,
+		}, {
+			View:        stashViewFeature.Name,
+			Key:         'x',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.stashDrop,
+			Display:     "x",
+			Description: "Drop item",
+			Vital:       true,
+		}, {
+			View:        stashViewFeature.Name,
+			Key:         'b',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openStashBranchView,
+			Display:     "b",
+			Description: "Branch from item",
+			Vital:       true,
+		}, {
+			View:        stashViewFeature.Name,
+			Key:         'n',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openStashPushView,
+			Display:     "n",
+			Description: "Stash changes",
+			Vital:       true,
+		}, {
+			View:        stashViewFeature.Name,
+			Key:         'C',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openStashConfirmView,
+			Display:     "C",
+			Description: "Clear stash",
+			Vital:       false,
+		}, {
+			View:        stashConfirmViewFeature.Name,
+			Key:         'q',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeStashConfirmView,
+			Display:     "q",
+			Description: "close/cancel",
+			Vital:       true,
+		}, {
+			View:        stashConfirmViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.confirmStashClear,
+			Display:     "enter",
+			Description: "clear",
+			Vital:       true,
+		}, {
+			View:        stashPromptViewFeature.Name,
+			Key:         gocui.KeyEsc,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeStashPromptView,
+			Display:     "esc",
+			Description: "close/cancel",
+			Vital:       true,
+		}, {
+			View:        stashPromptViewFeature.Name,
+			Key:         gocui.KeyCtrlU,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.toggleStashUntracked,
+			Display:     "ctrl + u",
+			Description: "Untracked",
+			Vital:       true,
+		}, {
+			View:        stashPromptViewFeature.Name,
+			Key:         gocui.KeyCtrlK,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.toggleStashKeepIndex,
+			Display:     "ctrl + k",
+			Description: "Keep index",
+			Vital:       true,
+		}, {
+			View:        stashPromptViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.submitStashPromptView,
+			Display:     "enter",
+			Description: "Submit",
+			Vital:       true,
+		},
+		// discard confirmation of the patch view
+		{
+			View:        patchConfirmViewFeature.Name,
+			Key:         'q',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closePatchConfirmView,
+			Display:     "q",
+			Description: "close/cancel",
+			Vital:       true,
+		}, {
+			View:        patchConfirmViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.confirmPatchDiscard,
+			Display:     "enter",
+			Description: "discard",
+			Vital:       true,
+		},
+		// branch cleanup
+		{
+			View:        hygieneViewFeature.Name,
+			Key:         'q',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeHygieneView,
+			Display:     "q",
+			Description: "close/cancel",
+			Vital:       true,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         gocui.KeyArrowDown,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.hygieneCursorDown,
+			Display:     "↓",
+			Description: "Cursor Down",
+			Vital:       false,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         gocui.KeyArrowUp,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.hygieneCursorUp,
+			Display:     "↑",
+			Description: "Cursor Up",
+			Vital:       false,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         'j',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.hygieneCursorDown,
+			Display:     "j",
+			Description: "Cursor Down",
+			Vital:       false,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         'k',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.hygieneCursorUp,
+			Display:     "k",
+			Description: "Cursor Up",
+			Vital:       false,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         gocui.KeySpace,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.toggleStaleBranch,
+			Display:     "space",
+			Description: "select",
+			Vital:       true,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         'a',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.toggleAllStaleBranches,
+			Display:     "a",
+			Description: "select all",
+			Vital:       true,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         'r',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.toggleDeleteRemote,
+			Display:     "r",
+			Description: "toggle remote branches",
+			Vital:       true,
+		}, {
+			View:        hygieneViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.openHygieneConfirmView,
+			Display:     "enter",
+			Description: "delete selected",
+			Vital:       true,
+		}, {
+			View:        hygieneConfirmViewFeature.Name,
+			Key:         'q',
+			Modifier:    gocui.ModNone,
+			Handler:     gui.closeHygieneConfirmView,
+			Display:     "q",
+			Description: "Close/Cancel",
+			Vital:       true,
+		}, {
+			View:        hygieneConfirmViewFeature.Name,
+			Key:         gocui.KeyEnter,
+			Modifier:    gocui.ModNone,
+			Handler:     gui.confirmHygieneDelete,
+			Display:     "enter",
+			Description: "Delete",
+			Vital:       true,
 		},
 		// upstream confirmation
 		{
@@ -689,6 +1077,15 @@ func (gui *Gui) keybindings(g *gocui.Gui) error {
 	return nil
 }
 
+// strategyLabel is the strategy of pull and merge modes to display next to
+// the mode, nothing is displayed for the default one
+func (gui *Gui) strategyLabel() string {
+	if gui.State.Settings.Strategy == command.StrategyDefault {
+		return ""
+	}
+	return " (" + strings.ToUpper(string(gui.State.Settings.Strategy)) + ")"
+}
+
 // the bottom line of the gui is mode indicator and keybindings view. Only the
 // important controls (marked as vital) are shown
 func (gui *Gui) updateKeyBindingsView(g *gocui.Gui, viewName string) error {
@@ -708,13 +1105,16 @@ func (gui *Gui) updateKeyBindingsView(g *gocui.Gui, viewName string) error {
 		modeLabel = fetchSymbol + ws + "FETCH"
 	case PullMode:
 		v.BgColor = gocui.ColorMagenta
-		modeLabel = pullSymbol + ws + "PULL"
+		modeLabel = pullSymbol + ws + "PULL" + gui.strategyLabel()
 	case MergeMode:
 		v.BgColor = gocui.ColorCyan
-		modeLabel = mergeSymbol + ws + "MERGE"
+		modeLabel = mergeSymbol + ws + "MERGE" + gui.strategyLabel()
 	case CheckoutMode:
 		v.BgColor = gocui.ColorGreen
 		modeLabel = checkoutSymbol + ws + "CHECKOUT"
+	case PushMode:
+		v.BgColor = gocui.ColorYellow
+		modeLabel = pushSymbol + ws + "PUSH"
 	default:
 		modeLabel = "No mode selected"
 	}
@@ -62,6 +62,15 @@ func (gui *Gui) overviewLayout(g *gocui.Gui) error {
 		v.Autoscroll = false
 		_, _ = g.SetViewOnBottom(v.Name())
 	}
+	if v, err := g.SetView(hygieneViewFeature.Name, int(0.15*float32(maxX)), int(0.20*float32(maxY)), int(0.85*float32(maxX)), int(0.80*float32(maxY))); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = hygieneViewFeature.Title
+		v.Wrap = false
+		v.Autoscroll = false
+		_, _ = g.SetViewOnBottom(v.Name())
+	}
 	if v, err := g.SetView(suggestBranchViewFeature.Name, int(0.30*float32(maxX)), int(0.45*float32(maxY)), int(0.70*float32(maxX)), int(0.55*float32(maxY))); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
@@ -72,6 +81,27 @@ func (gui *Gui) overviewLayout(g *gocui.Gui) error {
 		v.Autoscroll = false
 		_, _ = g.SetViewOnBottom(v.Name())
 	}
+	if v, err := g.SetView(tagViewFeature.Name, int(0.30*float32(maxX)), int(0.45*float32(maxY)), int(0.70*float32(maxX)), int(0.55*float32(maxY))); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = tagViewFeature.Title
+		v.Editable = true
+		v.Wrap = false
+		v.Autoscroll = false
+		_, _ = g.SetViewOnBottom(v.Name())
+	}
+	if v, err := g.SetView(filterViewFeature.Name, 0, maxY-4, maxX-1, maxY-2); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = filterViewFeature.Title
+		v.Editable = true
+		v.Editor = gui.filterEditor()
+		v.Wrap = false
+		v.Autoscroll = false
+		_, _ = g.SetViewOnBottom(v.Name())
+	}
 	if v, err := g.SetView(stashViewFeature.Name, -1*int(0.20*float32(maxX)), 0, -1, maxY); err != nil {
 		if err != gocui.ErrUnknownView {
 			return err
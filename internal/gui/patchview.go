@@ -0,0 +1,273 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/jroimartin/gocui"
+)
+
+var (
+	patchConfirmViewFeature = viewFeature{Name: "patch-confirm", Title: " Discard Changes "}
+)
+
+// open the hunks of the file under the cursor of the status, the unstaged
+// changes are shown if there are any
+func (gui *Gui) openPatch(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	line, err := v.Line(cy)
+	if err != nil {
+		return err
+	}
+	r := gui.getSelectedRepository()
+	files, err := command.Status(r)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !strings.Contains(line, f.Name) || f.Unmerged() {
+			continue
+		}
+		if f.Y == git.StatusUntracked {
+			return gui.openErrorView(g, f.Name+" is not tracked yet",
+				"Add the file as a whole with space, its hunks can be staged after",
+				dynamicViewFeature.Name)
+		}
+		gui.State.patchFile = f
+		gui.State.patchStaged = f.Y == git.StatusNotupdated
+		return gui.loadPatch(true)
+	}
+	return nil
+}
+
+// loads the diff of the patch file and renders it, the cursor is moved to
+// the first change if reset is true
+func (gui *Gui) loadPatch(reset bool) error {
+	r := gui.getSelectedRepository()
+	d, err := command.FileHunks(r, gui.State.patchFile, gui.State.patchStaged)
+	if err != nil {
+		return err
+	}
+	if len(d.Hunks) == 0 {
+		// nothing is left on this side of the file
+		return gui.initFocusStat(r)
+	}
+	gui.State.patchDiff = d
+	gui.State.patchSelection = command.LineSelection{}
+	if reset || gui.State.patchHunk >= len(d.Hunks) {
+		gui.State.patchHunk = 0
+		gui.State.patchLine = -1
+	}
+	// the cursor is kept unless the line under it is gone
+	h := d.Hunks[gui.State.patchHunk]
+	if gui.State.patchLine < 0 || gui.State.patchLine >= len(h.Lines) || !h.IsChange(gui.State.patchLine) {
+		gui.State.patchLine = -1
+		gui.movePatchCursor(1)
+	}
+	v, err := gui.g.View(dynamicViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	v.Title = string(PatchMode)
+	if err := gui.updateDynamicKeybindings(); err != nil {
+		return err
+	}
+	return gui.renderPatch()
+}
+
+// renders the hunks, the selected lines are marked and the line under the
+// cursor is highlighted
+func (gui *Gui) renderPatch() error {
+	v, err := gui.g.View(dynamicViewFeature.Name)
+	if err != nil {
+		return err
+	}
+	v.Clear()
+	side := "Unstaged changes of "
+	if gui.State.patchStaged {
+		side = "Staged changes of "
+	}
+	fmt.Fprintln(v, side+cyan.Sprint(gui.State.patchFile.Name))
+	total, cursor := 1, 0
+	for hi, h := range gui.State.patchDiff.Hunks {
+		fmt.Fprintln(v, tab+tab+cyan.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)+h.Section)
+		total++
+		for li, line := range h.Lines {
+			mark := ws
+			if gui.State.patchSelection.Selected(hi, li) {
+				mark = yellow.Sprint("*")
+			}
+			text := colorizeDiff(line)[0]
+			if hi == gui.State.patchHunk && li == gui.State.patchLine {
+				cursor = total
+				fmt.Fprintln(v, selectionIndicator+mark+text)
+			} else {
+				fmt.Fprintln(v, tab+tab+mark+text)
+			}
+			total++
+		}
+	}
+	return adjustAnchor(cursor, total, v)
+}
+
+// moves the cursor to the next or the previous changed line
+func (gui *Gui) movePatchCursor(step int) bool {
+	hunks := gui.State.patchDiff.Hunks
+	hi, li := gui.State.patchHunk, gui.State.patchLine+step
+	for hi >= 0 && hi < len(hunks) {
+		for li >= 0 && li < len(hunks[hi].Lines) {
+			if hunks[hi].IsChange(li) {
+				gui.State.patchHunk, gui.State.patchLine = hi, li
+				return true
+			}
+			li += step
+		}
+		hi += step
+		if hi >= 0 && hi < len(hunks) {
+			li = 0
+			if step < 0 {
+				li = len(hunks[hi].Lines) - 1
+			}
+		}
+	}
+	return false
+}
+
+// moves the cursor to the first change of the next or the previous hunk
+func (gui *Gui) movePatchHunk(step int) {
+	hi := gui.State.patchHunk + step
+	if hi < 0 || hi >= len(gui.State.patchDiff.Hunks) {
+		return
+	}
+	gui.State.patchHunk, gui.State.patchLine = hi, -1
+	gui.movePatchCursor(1)
+}
+
+// moves the cursor to the next change
+func (gui *Gui) patchCursorDown(g *gocui.Gui, v *gocui.View) error {
+	gui.movePatchCursor(1)
+	return gui.renderPatch()
+}
+
+// moves the cursor to the previous change
+func (gui *Gui) patchCursorUp(g *gocui.Gui, v *gocui.View) error {
+	gui.movePatchCursor(-1)
+	return gui.renderPatch()
+}
+
+// moves the cursor to the next hunk
+func (gui *Gui) patchNextHunk(g *gocui.Gui, v *gocui.View) error {
+	gui.movePatchHunk(1)
+	return gui.renderPatch()
+}
+
+// moves the cursor to the previous hunk
+func (gui *Gui) patchPrevHunk(g *gocui.Gui, v *gocui.View) error {
+	gui.movePatchHunk(-1)
+	return gui.renderPatch()
+}
+
+// select or deselect the line under the cursor
+func (gui *Gui) togglePatchLine(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.patchLine < 0 {
+		return nil
+	}
+	gui.State.patchSelection.Toggle(gui.State.patchHunk, gui.State.patchLine)
+	return gui.renderPatch()
+}
+
+// select or deselect the changes of the hunk under the cursor
+func (gui *Gui) togglePatchHunk(g *gocui.Gui, v *gocui.View) error {
+	gui.State.patchSelection.SelectHunk(gui.State.patchDiff, gui.State.patchHunk)
+	return gui.renderPatch()
+}
+
+// switch between the staged and the unstaged changes of the file
+func (gui *Gui) switchPatchSide(g *gocui.Gui, v *gocui.View) error {
+	gui.State.patchStaged = !gui.State.patchStaged
+	d, err := command.FileHunks(gui.getSelectedRepository(), gui.State.patchFile, gui.State.patchStaged)
+	if err != nil {
+		return err
+	}
+	if len(d.Hunks) == 0 {
+		// the other side has no changes, stay on this one
+		gui.State.patchStaged = !gui.State.patchStaged
+		return nil
+	}
+	return gui.loadPatch(true)
+}
+
+// returns the selected lines, or the hunk under the cursor if nothing is
+// selected
+func (gui *Gui) patchSelection() command.LineSelection {
+	if !gui.State.patchSelection.Empty() {
+		return gui.State.patchSelection
+	}
+	s := command.LineSelection{}
+	s.SelectHunk(gui.State.patchDiff, gui.State.patchHunk)
+	return s
+}
+
+// stage the selected unstaged lines or unstage the selected staged lines
+func (gui *Gui) applyPatch(g *gocui.Gui, v *gocui.View) error {
+	action := command.PatchStage
+	if gui.State.patchStaged {
+		action = command.PatchUnstage
+	}
+	return gui.patchApplied(g, command.ApplyPatch(gui.getSelectedRepository(), gui.State.patchDiff, gui.patchSelection(), action))
+}
+
+// opens the confirmation of discarding the selected unstaged lines
+func (gui *Gui) openPatchConfirmView(g *gocui.Gui, v *gocui.View) error {
+	if gui.State.patchStaged {
+		return nil
+	}
+	s := gui.patchSelection()
+	count := 0
+	for _, lines := range s {
+		count += len(lines)
+	}
+	maxX, maxY := g.Size()
+	cv, err := g.SetView(patchConfirmViewFeature.Name, maxX/2-30, maxY/2-2, maxX/2+30, maxY/2+2)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		cv.Title = patchConfirmViewFeature.Title
+		cv.Wrap = true
+	}
+	cv.Clear()
+	fmt.Fprintf(cv, "%s%s %d changed line(s) of %s\n", ws, red.Sprint("discard"), count, gui.State.patchFile.Name)
+	return gui.focusToView(patchConfirmViewFeature.Name)
+}
+
+// close the confirmation without discarding anything
+func (gui *Gui) closePatchConfirmView(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView(patchConfirmViewFeature.Name); err != nil {
+		return err
+	}
+	return gui.focusToView(dynamicViewFeature.Name)
+}
+
+// discards the selected lines from the worktree
+func (gui *Gui) confirmPatchDiscard(g *gocui.Gui, v *gocui.View) error {
+	if err := gui.closePatchConfirmView(g, v); err != nil {
+		return err
+	}
+	return gui.patchApplied(g, command.ApplyPatch(gui.getSelectedRepository(), gui.State.patchDiff, gui.patchSelection(), command.PatchDiscard))
+}
+
+// reloads the hunks after a patch is applied, the error is shown if the
+// patch could not be applied
+func (gui *Gui) patchApplied(g *gocui.Gui, err error) error {
+	if err != nil {
+		return gui.openErrorView(g, err.Error(),
+			"The patch is not applied, you may need to stage the file as a whole",
+			dynamicViewFeature.Name)
+	}
+	r := gui.getSelectedRepository()
+	_ = r.Refresh()
+	return gui.loadPatch(false)
+}
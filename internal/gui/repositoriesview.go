@@ -1,13 +1,17 @@
 package gui
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/isacikgoz/gitbatch/internal/command"
 	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/isacikgoz/gitbatch/internal/job"
+	"github.com/isacikgoz/gitbatch/internal/manifest"
 	"github.com/jroimartin/gocui"
 )
 
@@ -177,25 +181,49 @@ func (gui *Gui) addToQueue(r *git.Repository) error {
 	j := &job.Job{
 		Repository: r,
 	}
+	// the remote operations read the configured token of each remote's
+	// host, if any
+	sources := gui.State.Settings.Credentials
 	switch mode := gui.State.Mode.ModeID; mode {
 	case FetchMode:
 		j.JobType = job.FetchJob
+		opts := gui.State.Settings.Fetch
+		opts.RemoteName = r.State.Remote.Name
+		opts.Sources = sources
+		j.Options = &opts
 	case PullMode:
 		if r.State.Branch.Upstream == nil {
 			return nil
 		}
 		j.JobType = job.PullJob
+		j.Options = &command.PullOptions{
+			RemoteName:  r.State.Remote.Name,
+			Strategy:    gui.State.Settings.Strategy,
+			CommandMode: command.ModeNative,
+			Sources:     sources,
+		}
 	case MergeMode:
 		if r.State.Branch.Upstream == nil {
 			return nil
 		}
 		j.JobType = job.MergeJob
+		j.Options = &command.MergeOptions{
+			Strategy: gui.State.Settings.Strategy,
+		}
 	case CheckoutMode:
 		j.JobType = job.CheckoutJob
 		j.Options = &command.CheckoutOptions{
 			TargetRef:      gui.State.targetBranch,
 			CreateIfAbsent: true,
 		}
+	case PushMode:
+		j.JobType = job.PushJob
+		j.Options = &command.PushOptions{
+			RemoteName:  r.State.Remote.Name,
+			SetUpstream: r.State.Branch.Upstream == nil,
+			CommandMode: command.ModeNative,
+			Sources:     sources,
+		}
 	default:
 		return nil
 	}
@@ -220,16 +248,149 @@ func (gui *Gui) removeFromQueue(r *git.Repository) error {
 // this function starts the queue and updates the gui with the result of an
 // operation
 func (gui *Gui) startQueue(g *gocui.Gui, v *gocui.View) error {
+	gui.runQueue(gui.State.Queue, true)
+	return nil
+}
+
+// runQueue starts the jobs of the queue in background, the queue of the marked
+// repositories is renewed once they are done if renew is set. The jobs failed
+// for the credentials are paused to ask for them
+func (gui *Gui) runQueue(q *job.Queue, renew bool) {
 	go func(gui_go *Gui) {
-		fails := gui_go.State.Queue.StartJobsAsync()
-		gui_go.State.Queue = job.CreateJobQueue()
+		fails := q.StartJobsAsync()
+		if renew {
+			gui_go.State.Queue = gui_go.createJobQueue()
+		}
 		for j, err := range fails {
-			if err == gerr.ErrAuthenticationRequired {
+			if errors.Is(err, gerr.ErrAuthenticationRequired) || errors.Is(err, gerr.ErrAuthorizationFailed) ||
+				errors.Is(err, gerr.ErrPassphraseRequired) {
 				j.Repository.SetWorkStatus(git.Paused)
 				_ = gui_go.State.FailoverQueue.AddJob(j)
 			}
 		}
 	}(gui)
+}
+
+// releaseRepository makes the repository free for a job of its own, the job
+// that a marked repository waits for is dropped. false is returned if the
+// repository is busy with a started job or it has failed
+func (gui *Gui) releaseRepository(r *git.Repository) (bool, error) {
+	if r.WorkStatus().Ready {
+		return true, nil
+	}
+	if r.WorkStatus() != git.Queued || gui.State.Queue.Started() {
+		return false, nil
+	}
+	if ok, _ := gui.State.Queue.IsInTheQueue(r); !ok {
+		return false, nil
+	}
+	return true, gui.State.Queue.RemoveFromQueue(r)
+}
+
+// markedRepositories returns the queued repositories, or the selected one if
+// nothing is queued
+func (gui *Gui) markedRepositories() []*git.Repository {
+	marked := make([]*git.Repository, 0)
+	for _, r := range gui.State.allRepositories {
+		if r.WorkStatus() == git.Queued {
+			marked = append(marked, r)
+		}
+	}
+	if len(marked) == 0 {
+		if r := gui.getSelectedRepository(); r != nil && r.WorkStatus().Ready {
+			marked = append(marked, r)
+		}
+	}
+	return marked
+}
+
+// switches every marked repository to its own default branch, if nothing is
+// marked the selected one is switched. The checkout jobs are started right
+// away in a queue of their own, the busy repositories are skipped
+func (gui *Gui) checkoutDefaultBranches(g *gocui.Gui, v *gocui.View) error {
+	q := gui.createJobQueue()
+	queued := 0
+	for _, r := range gui.markedRepositories() {
+		if ok, err := gui.releaseRepository(r); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		// without a target the job switches to the default branch
+		if err := q.AddJob(&job.Job{
+			JobType:    job.CheckoutJob,
+			Repository: r,
+		}); err != nil {
+			return err
+		}
+		r.SetWorkStatus(git.Queued)
+		queued++
+	}
+	if queued > 0 {
+		gui.runQueue(q, false)
+	}
+	return nil
+}
+
+// clones the missing repositories of the workspace manifest in background,
+// the cloned ones are added to the list and a summary is shown at the end
+func (gui *Gui) syncManifest(g *gocui.Gui, v *gocui.View) error {
+	if len(gui.State.Manifest) == 0 {
+		return gui.openErrorView(g, "There is no workspace manifest",
+			"Create "+manifest.WorkspaceFileName+" in the workspace root",
+			v.Name())
+	}
+	m, err := manifest.Load(gui.State.Manifest)
+	if err != nil {
+		return gui.openErrorView(g, err.Error(),
+			"Fix the manifest and try again",
+			v.Name())
+	}
+	root := m.RootDir() + string(filepath.Separator)
+	found := make([]string, 0)
+	for _, r := range gui.State.allRepositories {
+		if strings.HasPrefix(r.AbsPath, root) {
+			found = append(found, r.AbsPath)
+		}
+	}
+	go func(gui_go *Gui) {
+		report := m.Sync(gui_go.createJobQueue(), found, gui_go.State.Settings.Credentials, func(r *git.Repository) {
+			gui_go.g.Update(func(g *gocui.Gui) error {
+				gui_go.State.Directories = append(gui_go.State.Directories, r.AbsPath)
+				gui_go.loadRepository(r)
+				return nil
+			})
+		})
+		message := fmt.Sprintf("Sync finished: %d cloned, %d failed, %d not in the manifest",
+			len(report.Cloned), len(report.Failed), len(report.Untracked))
+		note := "Nothing to do"
+		if len(report.Failed) > 0 {
+			note = report.Failed[0].Path + ": " + report.Failed[0].Error
+		} else if len(report.Untracked) > 0 {
+			note = "Not in the manifest: " + strings.Join(report.Untracked, ", ")
+		} else if len(report.Cloned) > 0 || len(report.RemotesAdded) > 0 {
+			note = "Workspace is in sync with the manifest"
+		}
+		gui_go.g.Update(func(g *gocui.Gui) error {
+			return gui_go.openErrorView(g, message, note, mainViewFeature.Name)
+		})
+	}(gui)
+	return nil
+}
+
+// cancels the job of the selected repository whether it is queued or running
+func (gui *Gui) cancelJob(g *gocui.Gui, v *gocui.View) error {
+	r := gui.getSelectedRepository()
+	if r == nil {
+		return nil
+	}
+	_ = gui.State.Queue.Cancel(r)
+	return nil
+}
+
+// cancels all of the queued and running jobs
+func (gui *Gui) cancelAllJobs(g *gocui.Gui, v *gocui.View) error {
+	gui.State.Queue.CancelAll()
 	return nil
 }
 
@@ -304,6 +465,7 @@ func (gui *Gui) unmarkAllRepositories(g *gocui.Gui, v *gocui.View) error {
 // sortByName sorts the repositories by A to Z order
 func (gui *Gui) sortByName(g *gocui.Gui, v *gocui.View) error {
 	sort.Sort(git.Alphabetical(gui.State.Repositories))
+	sort.Sort(git.Alphabetical(gui.State.allRepositories))
 	_ = gui.renderMain()
 	return nil
 }
@@ -312,6 +474,7 @@ func (gui *Gui) sortByName(g *gocui.Gui, v *gocui.View) error {
 // the top element will be the last modified
 func (gui *Gui) sortByMod(g *gocui.Gui, v *gocui.View) error {
 	sort.Sort(git.LastModified(gui.State.Repositories))
+	sort.Sort(git.LastModified(gui.State.allRepositories))
 	_ = gui.renderMain()
 	return nil
 }
@@ -101,6 +101,10 @@ func (gui *Gui) renderRemotes(r *git.Repository) error {
 		shortURL := "URL not found."
 		if len(rb.URL) > 0 {
 			_, shortURL = trimRemoteURL(rb.URL[0])
+			// show where the token of the remote is read from
+			if s := gui.State.Settings.Credentials.Match(rb.URL[0]); s != nil {
+				shortURL += " (" + s.String() + ")"
+			}
 		}
 		if rb.Name == rc.Name {
 			si = i
@@ -6,9 +6,29 @@ import (
 
 	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/job"
 	"github.com/jroimartin/gocui"
 )
 
+// stashPrompt is the purpose of the stash prompt view
+type stashPrompt int
+
+const (
+	// stash the changes of the selected repository
+	stashPromptPush stashPrompt = iota
+	// create a branch from the selected stashed item
+	stashPromptBranch
+	// stash the changes of all dirty repositories
+	stashPromptBatch
+	// pop the stashes of the batch stash
+	stashPromptBatchPop
+)
+
+var (
+	stashPromptViewFeature  = viewFeature{Name: "stash-prompt", Title: " Stash Message "}
+	stashConfirmViewFeature = viewFeature{Name: "stash-confirm", Title: " Clear Stash "}
+)
+
 // initialize stashed items
 func (gui *Gui) initStashedView(r *git.Repository) error {
 	v, err := gui.g.View(stashViewFeature.Name)
@@ -102,28 +122,275 @@ func (gui *Gui) stashDiff(g *gocui.Gui, v *gocui.View) error {
 
 // pop out the stash
 func (gui *Gui) stashPop(g *gocui.Gui, v *gocui.View) error {
+	s := gui.selectedStash(v)
+	if s == nil {
+		return nil
+	}
+	// since the pop is a func of stashed item, the entity is refreshed after
+	output, err := s.Pop()
+	return gui.stashOperationDone(g, output, err)
+}
+
+// returns the stashed item under the cursor of the stash view
+func (gui *Gui) selectedStash(v *gocui.View) *git.StashedItem {
 	r := gui.getSelectedRepository()
+	if r == nil || v == nil {
+		return nil
+	}
 	_, oy := v.Origin()
 	_, cy := v.Cursor()
-	if len(r.Stasheds) <= 0 {
+	if oy+cy >= len(r.Stasheds) {
 		return nil
 	}
-	stashedItem := r.Stasheds[oy+cy]
-	output, err := stashedItem.Pop()
+	return r.Stasheds[oy+cy]
+}
+
+// refreshes the selected repository and the stash view after an operation
+// on the stash, the output is shown if the operation failed
+func (gui *Gui) stashOperationDone(g *gocui.Gui, output string, err error) error {
+	r := gui.getSelectedRepository()
+	_ = r.Refresh()
+	if err := gui.focusToRepository(g, nil); err != nil {
+		return err
+	}
+	if err := gui.initStashedView(r); err != nil {
+		return err
+	}
+	if err := gui.focusToView(stashViewFeature.Name); err != nil {
+		return err
+	}
 	if err != nil {
-		if err = gui.openErrorView(g, output,
+		return gui.openErrorView(g, output,
 			"You should manually resolve this issue",
-			stashViewFeature.Name); err != nil {
+			stashViewFeature.Name)
+	}
+	return nil
+}
+
+// apply the stashed item, it is kept in the stash
+func (gui *Gui) stashApply(g *gocui.Gui, v *gocui.View) error {
+	s := gui.selectedStash(v)
+	if s == nil {
+		return nil
+	}
+	output, err := s.Apply()
+	return gui.stashOperationDone(g, output, err)
+}
+
+// drop the stashed item
+func (gui *Gui) stashDrop(g *gocui.Gui, v *gocui.View) error {
+	s := gui.selectedStash(v)
+	if s == nil {
+		return nil
+	}
+	output, err := s.Drop()
+	return gui.stashOperationDone(g, output, err)
+}
+
+// open the confirmation of clearing the stash of the selected repository
+func (gui *Gui) openStashConfirmView(g *gocui.Gui, v *gocui.View) error {
+	r := gui.getSelectedRepository()
+	if r == nil || len(r.Stasheds) == 0 {
+		return nil
+	}
+	maxX, maxY := g.Size()
+	cv, err := g.SetView(stashConfirmViewFeature.Name, maxX/2-30, maxY/2-2, maxX/2+30, maxY/2+2)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
 			return err
 		}
+		cv.Title = stashConfirmViewFeature.Title
+		cv.Wrap = true
 	}
-	// since the pop is a func of stashed item, we need to refresh entity here
-	_ = r.Refresh()
-	if err := gui.focusToRepository(g, v); err != nil {
+	cv.Clear()
+	fmt.Fprintf(cv, "%s%s all of the %d stashed items of %s\n", ws, red.Sprint("drop"), len(r.Stasheds), repositoryName(r))
+	return gui.focusToView(stashConfirmViewFeature.Name)
+}
+
+// close the confirmation without clearing the stash
+func (gui *Gui) closeStashConfirmView(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView(stashConfirmViewFeature.Name); err != nil {
 		return err
 	}
-	if err := gui.initStashedView(r); err != nil {
+	return gui.focusToView(stashViewFeature.Name)
+}
+
+// clear the stash of the selected repository
+func (gui *Gui) confirmStashClear(g *gocui.Gui, v *gocui.View) error {
+	if err := g.DeleteView(stashConfirmViewFeature.Name); err != nil {
 		return err
 	}
+	output, err := gui.getSelectedRepository().ClearStash()
+	return gui.stashOperationDone(g, output, err)
+}
+
+// open the prompt to stash the changes of the selected repository
+func (gui *Gui) openStashPushView(g *gocui.Gui, v *gocui.View) error {
+	return gui.openStashPromptView(g, stashPromptPush, "")
+}
+
+// open the prompt to create a branch from the selected stashed item
+func (gui *Gui) openStashBranchView(g *gocui.Gui, v *gocui.View) error {
+	s := gui.selectedStash(v)
+	if s == nil {
+		return nil
+	}
+	gui.State.stashID = s.StashID
+	return gui.openStashPromptView(g, stashPromptBranch, "")
+}
+
+// open the prompt to stash the changes of all dirty repositories
+func (gui *Gui) openStashAllView(g *gocui.Gui, v *gocui.View) error {
+	return gui.openStashPromptView(g, stashPromptBatch, "")
+}
+
+// open the prompt to pop the stashes of the batch stash, the last message is
+// suggested
+func (gui *Gui) openUnstashAllView(g *gocui.Gui, v *gocui.View) error {
+	return gui.openStashPromptView(g, stashPromptBatchPop, gui.State.stashMessage)
+}
+
+// opens the prompt of the stash operations with the initial text
+func (gui *Gui) openStashPromptView(g *gocui.Gui, prompt stashPrompt, text string) error {
+	maxX, maxY := g.Size()
+	v, err := g.SetView(stashPromptViewFeature.Name, maxX/2-30, maxY/2-1, maxX/2+30, maxY/2+1)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Editable = true
+		v.Wrap = false
+	}
+	gui.State.stashPrompt = prompt
+	v.Clear()
+	fmt.Fprint(v, text)
+	_ = v.SetCursor(len(text), 0)
+	gui.renderStashPromptTitle()
+	g.Cursor = true
+	return gui.focusToView(stashPromptViewFeature.Name)
+}
+
+// renders the purpose and the options of the prompt into its title
+func (gui *Gui) renderStashPromptTitle() {
+	v, err := gui.g.View(stashPromptViewFeature.Name)
+	if err != nil {
+		return
+	}
+	switch gui.State.stashPrompt {
+	case stashPromptBranch:
+		v.Title = fmt.Sprintf(" Branch From stash@{%d} ", gui.State.stashID)
+		return
+	case stashPromptBatch:
+		v.Title = " Stash Dirty Repositories "
+	case stashPromptBatchPop:
+		v.Title = " Pop Stashes With Message "
+		return
+	default:
+		v.Title = stashPromptViewFeature.Title
+	}
+	if gui.State.stashUntracked {
+		v.Title = v.Title + "+untracked "
+	}
+	if gui.State.stashKeepIndex {
+		v.Title = v.Title + "+keep index "
+	}
+}
+
+// switch stashing the untracked files
+func (gui *Gui) toggleStashUntracked(g *gocui.Gui, v *gocui.View) error {
+	gui.State.stashUntracked = !gui.State.stashUntracked
+	gui.renderStashPromptTitle()
+	return nil
+}
+
+// switch keeping the staged changes in the worktree
+func (gui *Gui) toggleStashKeepIndex(g *gocui.Gui, v *gocui.View) error {
+	gui.State.stashKeepIndex = !gui.State.stashKeepIndex
+	gui.renderStashPromptTitle()
+	return nil
+}
+
+// close the prompt without doing anything
+func (gui *Gui) closeStashPromptView(g *gocui.Gui, v *gocui.View) error {
+	g.Cursor = false
+	if err := g.DeleteView(stashPromptViewFeature.Name); err != nil {
+		return err
+	}
+	switch gui.State.stashPrompt {
+	case stashPromptBatch, stashPromptBatchPop:
+		return gui.focusToView(mainViewFeature.Name)
+	}
+	return gui.focusToView(stashViewFeature.Name)
+}
+
+// runs the operation of the prompt with the entered text
+func (gui *Gui) submitStashPromptView(g *gocui.Gui, v *gocui.View) error {
+	text := strings.TrimSpace(v.ViewBuffer())
+	if err := gui.closeStashPromptView(g, v); err != nil {
+		return err
+	}
+	opts := git.StashOptions{
+		Message:          text,
+		IncludeUntracked: gui.State.stashUntracked,
+		KeepIndex:        gui.State.stashKeepIndex,
+	}
+	switch gui.State.stashPrompt {
+	case stashPromptPush:
+		output, err := gui.getSelectedRepository().StashWithOptions(&opts)
+		return gui.stashOperationDone(g, output, err)
+	case stashPromptBranch:
+		r := gui.getSelectedRepository()
+		if len(text) == 0 {
+			return nil
+		}
+		for _, s := range r.Stasheds {
+			if s.StashID == gui.State.stashID {
+				output, err := s.Branch(text)
+				return gui.stashOperationDone(g, output, err)
+			}
+		}
+		return nil
+	case stashPromptBatch:
+		if len(text) == 0 {
+			return nil
+		}
+		gui.State.stashMessage = text
+		return gui.queueStashJobs(g, v, job.StashJob, &opts, func(r *git.Repository) bool {
+			return r.State.Branch != nil && !r.State.Branch.Clean
+		})
+	case stashPromptBatchPop:
+		if len(text) == 0 {
+			return nil
+		}
+		return gui.queueStashJobs(g, v, job.UnstashJob, &opts, func(r *git.Repository) bool {
+			return r.FindStash(text) != nil
+		})
+	}
+	return nil
+}
+
+// queues the stash jobs of the shown repositories that match and starts them
+// in a queue of their own, so the jobs of the marked repositories are kept
+func (gui *Gui) queueStashJobs(g *gocui.Gui, v *gocui.View, jt job.Type, opts *git.StashOptions, match func(*git.Repository) bool) error {
+	q := gui.createJobQueue()
+	queued := 0
+	for _, r := range gui.State.Repositories {
+		if !r.WorkStatus().Ready || !r.Kind.HasWorktree() || !match(r) {
+			continue
+		}
+		o := *opts
+		if err := q.AddJob(&job.Job{
+			JobType:    jt,
+			Repository: r,
+			Options:    &o,
+		}); err != nil {
+			return err
+		}
+		r.SetWorkStatus(git.Queued)
+		queued++
+	}
+	if queued > 0 {
+		gui.runQueue(q, false)
+	}
 	return nil
 }
@@ -50,7 +50,12 @@ func (gui *Gui) initFocusStat(r *git.Repository) error {
 	}
 	stagedFiles := make([]*git.File, 0)
 	unstagedFiles := make([]*git.File, 0)
+	unmergedFiles := make([]*git.File, 0)
 	for _, file := range files {
+		if file.Unmerged() {
+			unmergedFiles = append(unmergedFiles, file)
+			continue
+		}
 		if file.X != git.StatusNotupdated && file.X != git.StatusUntracked && file.X != git.StatusIgnored && file.X != git.StatusUpdated {
 			stagedFiles = append(stagedFiles, file)
 		}
@@ -58,7 +63,15 @@ func (gui *Gui) initFocusStat(r *git.Repository) error {
 			unstagedFiles = append(unstagedFiles, file)
 		}
 	}
-	if len(stagedFiles) == 0 && len(unstagedFiles) == 0 {
+	if len(unmergedFiles) > 0 {
+		fmt.Fprintln(v, "\nUnmerged paths:")
+		fmt.Fprintln(v, "(\"x\" to resolve the conflicts)")
+		fmt.Fprintln(v, "")
+		for _, f := range unmergedFiles {
+			fmt.Fprintln(v, " "+red.Sprint(string(f.X)+string(f.Y)+" "+f.Name))
+		}
+	}
+	if len(stagedFiles) == 0 && len(unstagedFiles) == 0 && len(unmergedFiles) == 0 {
 		fmt.Fprintln(v, "\nNothing to commit, working tree clean")
 	} else {
 		if len(stagedFiles) > 0 {
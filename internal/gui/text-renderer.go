@@ -37,11 +37,13 @@ const (
 	workingSymbol = "•"
 	successSymbol = "✔"
 	failSymbol    = "✗"
+	cancelSymbol  = "⊘"
 
 	fetchSymbol         = "↓"
 	pullSymbol          = "↓↳"
 	mergeSymbol         = "↳"
 	checkoutSymbol      = "↱"
+	pushSymbol          = "↑"
 	modeSeperator       = ""
 	keyBindingSeperator = "░"
 
@@ -74,7 +76,7 @@ func (gui *Gui) renderRules() *RepositoryDecorationRules {
 		if len(r.State.Branch.Name) > maxBranchLength {
 			rules.MaxBranch = maxBranchLength
 		}
-		if len(r.Name) > maxRepositoryLength {
+		if len(repositoryName(r)) > maxRepositoryLength {
 			rules.MaxName = maxRepositoryLength
 		}
 	}
@@ -93,7 +95,7 @@ func (gui *Gui) repositoryLabel(r *git.Repository) string {
 	var line string
 
 	line = line + renderRevCount(r, renderRules) + sep
-	line = line + renderBranchName(r, renderRules) + sep
+	line = line + renderBranchName(r, gui.State.revisions[r.AbsPath], renderRules) + sep
 	line = line + gui.renderRepoName(r, renderRules) + sep
 	line = line + gui.renderStatus(r)
 
@@ -103,24 +105,41 @@ func (gui *Gui) repositoryLabel(r *git.Repository) string {
 // render repo name, print green if cursor is on the repository
 func (gui *Gui) renderRepoName(r *git.Repository, rule *RepositoryDecorationRules) string {
 	var repoName string
+	name := repositoryName(r)
 	sr := gui.getSelectedRepository()
 	if sr == r {
-		n, in := align(r.Name, rule.MaxName-2, true)
+		n, in := align(name, rule.MaxName-2, true)
 		in = in + strings.Repeat(" ", n)
 		return selectionIndicator + green.Sprint(in)
 	}
 
-	n, in := align(r.Name, rule.MaxName, true)
+	n, in := align(name, rule.MaxName, true)
 	in = in + strings.Repeat(" ", n)
 	repoName = in
 
 	return repoName
 }
 
-// render branch, add x if it is dirty
-func renderBranchName(r *git.Repository, rule *RepositoryDecorationRules) string {
+// repositoryName labels the repositories that are not regular with their kind
+func repositoryName(r *git.Repository) string {
+	switch r.Kind {
+	case git.KindBare, git.KindWorktree, git.KindSubmodule:
+		return r.Name + " [" + r.Kind.String() + "]"
+	}
+	return r.Name
+}
+
+// render branch, add x if it is dirty. The declared revision of the manifest
+// is appended if it differs from the branch
+func renderBranchName(r *git.Repository, revision string, rule *RepositoryDecorationRules) string {
 	b := r.State.Branch
 	branch := b.Name
+	if len(revision) > 0 && revision != branch {
+		if len(revision) == 40 {
+			revision = revision[:7]
+		}
+		branch = branch + " (" + revision + ")"
+	}
 	if !b.Clean {
 		n, in := align(branch, rule.MaxBranch-2, true)
 		return cyan.Sprint(in) + " " + yellow.Sprint("✗") + strings.Repeat(" ", n)
@@ -162,6 +181,8 @@ func (gui *Gui) renderStatus(r *git.Repository) string {
 		status = yellow.Sprint("! authentication required (u)")
 	} else if r.WorkStatus() == git.Fail {
 		status = red.Sprint(failSymbol) + ws + red.Sprint(r.State.Message)
+	} else if r.WorkStatus() == git.Cancelled {
+		status = yellow.Sprint(cancelSymbol) + ws + yellow.Sprint(r.State.Message)
 	}
 	return status
 }
@@ -195,8 +216,19 @@ func printQueued(r *git.Repository, j *job.Job) string {
 	case job.MergeJob:
 		info = cyan.Sprint(queuedSymbol) + ws + "(" + cyan.Sprint("merge") + ws + r.State.Branch.Upstream.Name + ")"
 	case job.CheckoutJob:
-		refName := j.Options.(*command.CheckoutOptions).TargetRef
+		refName := "default branch"
+		if opts, ok := j.Options.(*command.CheckoutOptions); ok && len(opts.TargetRef) > 0 {
+			refName = opts.TargetRef
+		}
 		info = green.Sprint(queuedSymbol) + ws + "(" + cyan.Sprint("switch branch to") + ws + refName + ")"
+	case job.PushJob:
+		info = yellow.Sprint(queuedSymbol) + ws + "(" + yellow.Sprint("push") + ws + r.State.Remote.Name + ")"
+	case job.TagJob:
+		info = green.Sprint(queuedSymbol) + ws + "(" + green.Sprint("tag") + ws + j.Options.(*command.TagOptions).Name + ")"
+	case job.StashJob:
+		info = yellow.Sprint(queuedSymbol) + ws + "(" + yellow.Sprint("stash") + ws + j.Options.(*git.StashOptions).Message + ")"
+	case job.UnstashJob:
+		info = yellow.Sprint(queuedSymbol) + ws + "(" + yellow.Sprint("pop stash") + ws + j.Options.(*git.StashOptions).Message + ")"
 	default:
 		info = green.Sprint(queuedSymbol)
 	}
@@ -1,7 +1,14 @@
 package job
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/isacikgoz/gitbatch/internal/command"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 )
 
@@ -13,6 +20,11 @@ type Job struct {
 	Repository *git.Repository
 	// Options is a placeholder for operation options
 	Options interface{}
+
+	// attempt is the number of the current try, it is kept per job since the
+	// jobs of a queue run concurrently
+	attempt     int
+	maxAttempts int
 }
 
 // Type is the a git operation supported
@@ -30,15 +42,68 @@ const (
 
 	// CheckoutJob is wrapper of git merge command
 	CheckoutJob Type = "checkout"
+
+	// PushJob is wrapper of git push command
+	PushJob Type = "push"
+
+	// CloneJob is wrapper of git clone command
+	CloneJob Type = "clone"
+
+	// TagJob is wrapper of git tag command
+	TagJob Type = "tag"
+
+	// StashJob is wrapper of git stash push command
+	StashJob Type = "stash"
+
+	// UnstashJob pops the latest stash with the message of the options
+	UnstashJob Type = "unstash"
 )
 
-// starts the job
-func (j *Job) start() error {
+// run starts the job and tries it again with a backoff as long as it fails
+// with a transient error and the policy allows
+func (j *Job) run(ctx context.Context, policy RetryPolicy) error {
+	j.maxAttempts = policy.attempts()
+	for j.attempt = 1; ; j.attempt++ {
+		err := j.start(ctx)
+		if err == nil || ctx.Err() != nil || !gerr.IsTransient(err) {
+			return err
+		}
+		if j.attempt >= j.maxAttempts {
+			if j.attempt > 1 {
+				j.Repository.State.Message = fmt.Sprintf("%s (after %d attempts)", err.Error(), j.attempt)
+				j.Repository.SetWorkStatus(git.Fail)
+			}
+			return err
+		}
+		wait := policy.backoff(j.attempt)
+		j.Repository.State.Message = fmt.Sprintf("%s, retrying in %s (attempt %d/%d)",
+			err.Error(), wait.Round(time.Millisecond), j.attempt+1, j.maxAttempts)
+		j.Repository.SetWorkStatus(git.Working)
+		select {
+		case <-ctx.Done():
+			return j.fail(ctx, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// progress decorates the message of a running job with the attempt count if
+// the job is being retried
+func (j *Job) progress(msg string) string {
+	if j.attempt > 1 {
+		return fmt.Sprintf("%s (attempt %d/%d)", msg, j.attempt, j.maxAttempts)
+	}
+	return msg
+}
+
+// starts the job, the operation is aborted when the context is done
+func (j *Job) start(ctx context.Context) error {
 	j.Repository.SetWorkStatus(git.Working)
+	j.Repository.State.Error = nil
 	// TODO: Better implementation required
 	switch mode := j.JobType; mode {
 	case FetchJob:
-		j.Repository.State.Message = "fetching.."
+		j.Repository.State.Message = j.progress("fetching..")
 		var opts *command.FetchOptions
 		if j.Options != nil {
 			opts = j.Options.(*command.FetchOptions)
@@ -48,18 +113,19 @@ func (j *Job) start() error {
 				CommandMode: command.ModeNative,
 			}
 		}
-		if err := command.Fetch(j.Repository, opts); err != nil {
-			j.Repository.SetWorkStatus(git.Fail)
-			j.Repository.State.Message = err.Error()
-			return err
+		if err := command.FetchWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
 		}
 	case PullJob:
-		j.Repository.State.Message = "pulling.."
+		j.Repository.State.Message = j.progress("pulling..")
+		if err := j.requireWorktree(); err != nil {
+			return err
+		}
 		var opts *command.PullOptions
 		if j.Repository.State.Branch.Upstream == nil {
 			j.Repository.SetWorkStatus(git.Fail)
 			j.Repository.State.Message = "upstream not set"
-			return nil
+			return gerr.ErrRemoteBranchNotSpecified
 		}
 		if j.Options != nil {
 			opts = j.Options.(*command.PullOptions)
@@ -69,44 +135,214 @@ func (j *Job) start() error {
 				CommandMode: command.ModeNative,
 			}
 		}
-		if err := command.Pull(j.Repository, opts); err != nil {
-			j.Repository.SetWorkStatus(git.Fail)
-			j.Repository.State.Message = err.Error()
-			return err
+		if err := command.PullWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
 		}
 	case MergeJob:
-		j.Repository.State.Message = "merging.."
+		j.Repository.State.Message = j.progress("merging..")
+		if err := j.requireWorktree(); err != nil {
+			return err
+		}
 		if j.Repository.State.Branch.Upstream == nil {
 			j.Repository.SetWorkStatus(git.Fail)
 			j.Repository.State.Message = "upstream not set"
-			return nil
+			return gerr.ErrRemoteBranchNotSpecified
 		}
-		if err := command.Merge(j.Repository, &command.MergeOptions{
-			BranchName: j.Repository.State.Branch.Upstream.Name,
-		}); err != nil {
-			j.Repository.SetWorkStatus(git.Fail)
-			j.Repository.State.Message = err.Error()
-			return err
+		opts := &command.MergeOptions{}
+		if j.Options != nil {
+			opts = j.Options.(*command.MergeOptions)
+		}
+		if len(opts.BranchName) == 0 {
+			opts.BranchName = j.Repository.State.Branch.Upstream.Name
+		}
+		if err := command.MergeWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
 		}
 	case CheckoutJob:
-		j.Repository.State.Message = "switching to.."
+		j.Repository.State.Message = j.progress("switching to..")
+		if err := j.requireWorktree(); err != nil {
+			return err
+		}
 		var opts *command.CheckoutOptions
 		if j.Options != nil {
 			opts = j.Options.(*command.CheckoutOptions)
 		} else {
+			// switch to the default branch of the repository
 			opts = &command.CheckoutOptions{
-				TargetRef:   "master",
 				CommandMode: command.ModeNative,
 			}
 		}
-		if err := command.Checkout(j.Repository, opts); err != nil {
-			j.Repository.SetWorkStatus(git.Fail)
-			j.Repository.State.Message = err.Error()
+		if err := command.CheckoutWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
+		}
+	case PushJob:
+		j.Repository.State.Message = j.progress("pushing..")
+		var opts *command.PushOptions
+		if j.Options != nil {
+			opts = j.Options.(*command.PushOptions)
+		} else {
+			opts = &command.PushOptions{
+				RemoteName:  j.Repository.State.Remote.Name,
+				SetUpstream: j.Repository.State.Branch.Upstream == nil,
+				CommandMode: command.ModeNative,
+			}
+		}
+		if err := command.PushWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
+		}
+	case CloneJob:
+		j.Repository.State.Message = j.progress("cloning..")
+		opts, ok := j.Options.(*command.CloneOptions)
+		if !ok {
+			return j.fail(ctx, fmt.Errorf("clone options are required"))
+		}
+		if err := command.CloneWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
+		}
+	case TagJob:
+		j.Repository.State.Message = j.progress("tagging..")
+		opts, ok := j.Options.(*command.TagOptions)
+		if !ok {
+			return j.fail(ctx, fmt.Errorf("tag options are required"))
+		}
+		if err := command.TagWithContext(ctx, j.Repository, opts); err != nil {
+			return j.fail(ctx, err)
+		}
+	case StashJob:
+		j.Repository.State.Message = j.progress("stashing..")
+		if err := j.requireWorktree(); err != nil {
 			return err
 		}
+		opts, ok := j.Options.(*git.StashOptions)
+		if !ok {
+			return j.fail(ctx, fmt.Errorf("stash options are required"))
+		}
+		// git succeeds without stashing if there are only untracked files
+		// and they are not included
+		before := stashHead(j.Repository)
+		if out, err := j.Repository.StashWithOptions(opts); err != nil {
+			return j.fail(ctx, gerr.ParseGitError(out, err))
+		}
+		if stashHead(j.Repository) == before {
+			return j.fail(ctx, fmt.Errorf("nothing to stash"))
+		}
+		j.Repository.SetWorkStatus(git.Success)
+		j.Repository.State.Message = "stashed"
+	case UnstashJob:
+		j.Repository.State.Message = j.progress("popping stash..")
+		if err := j.requireWorktree(); err != nil {
+			return err
+		}
+		opts, ok := j.Options.(*git.StashOptions)
+		if !ok {
+			return j.fail(ctx, fmt.Errorf("stash options are required"))
+		}
+		s := j.Repository.FindStash(opts.Message)
+		if s == nil {
+			return j.fail(ctx, fmt.Errorf("no stash with message %q", opts.Message))
+		}
+		out, err := s.Pop()
+		_ = j.Repository.Refresh()
+		if err != nil {
+			return j.fail(ctx, gerr.ParseGitError(out, err))
+		}
+		j.Repository.SetWorkStatus(git.Success)
+		j.Repository.State.Message = "popped stash@{" + fmt.Sprint(s.StashID) + "}"
 	default:
 		j.Repository.SetWorkStatus(git.Available)
 		return nil
 	}
 	return nil
 }
+
+// SetCredentials sets the credentials to the options of a fetch, pull, push or
+// tag job and keeps the rest of its options. The job runs natively afterwards,
+// since git is never given the credentials
+func (j *Job) SetCredentials(c *git.Credentials) {
+	remote := ""
+	if j.Repository.State.Remote != nil {
+		remote = j.Repository.State.Remote.Name
+	}
+	switch j.JobType {
+	case FetchJob:
+		opts := command.FetchOptions{RemoteName: remote}
+		if o, ok := j.Options.(*command.FetchOptions); ok && o != nil {
+			opts = *o
+		}
+		opts.Credentials = c
+		opts.CommandMode = command.ModeNative
+		j.Options = &opts
+	case PullJob:
+		opts := command.PullOptions{RemoteName: remote}
+		if o, ok := j.Options.(*command.PullOptions); ok && o != nil {
+			opts = *o
+		}
+		opts.Credentials = c
+		opts.CommandMode = command.ModeNative
+		j.Options = &opts
+	case PushJob:
+		opts := command.PushOptions{RemoteName: remote}
+		if o, ok := j.Options.(*command.PushOptions); ok && o != nil {
+			opts = *o
+		} else if j.Repository.State.Branch != nil {
+			opts.SetUpstream = j.Repository.State.Branch.Upstream == nil
+		}
+		opts.Credentials = c
+		opts.CommandMode = command.ModeNative
+		j.Options = &opts
+	case TagJob:
+		// a tag job is always queued with its options
+		if o, ok := j.Options.(*command.TagOptions); ok && o != nil {
+			opts := *o
+			opts.Credentials = c
+			opts.CommandMode = command.ModeNative
+			j.Options = &opts
+		}
+	}
+}
+
+// stashHead returns the hash of the latest stash, an empty string if there is
+// no stash
+func stashHead(r *git.Repository) string {
+	ref, err := r.Repo.Reference(plumbing.ReferenceName("refs/stash"), true)
+	if err != nil {
+		return ""
+	}
+	return ref.Hash().String()
+}
+
+// requireWorktree fails the job if the repository is bare, since the
+// operation needs the files to be checked out
+func (j *Job) requireWorktree() error {
+	if j.Repository.Kind.HasWorktree() {
+		return nil
+	}
+	j.Repository.SetWorkStatus(git.Fail)
+	j.Repository.State.Message = "skipped, bare repository has no worktree"
+	return gerr.ErrBareRepository
+}
+
+// fail sets the state of the repository according to the error. If the
+// context is done, the job is considered as cancelled rather than failed
+func (j *Job) fail(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		j.Repository.SetWorkStatus(git.Cancelled)
+		j.Repository.State.Message = "cancelled"
+		return ctx.Err()
+	case context.DeadlineExceeded:
+		j.Repository.SetWorkStatus(git.Cancelled)
+		j.Repository.State.Message = "timed out"
+		return ctx.Err()
+	}
+	// the errors of git tell where they occurred
+	var gitErr *gerr.Error
+	if errors.As(err, &gitErr) {
+		gitErr.Repository = j.Repository.Name
+		gitErr.Operation = string(j.JobType)
+	}
+	j.Repository.SetWorkStatus(git.Fail)
+	j.Repository.State.Message = err.Error()
+	j.Repository.State.Error = err
+	return err
+}
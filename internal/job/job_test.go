@@ -1,12 +1,154 @@
 package job
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
+	gerr "github.com/isacikgoz/gitbatch/internal/errors"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/stretchr/testify/require"
 )
 
+func TestStartPush(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	git.CreateTestCommit(t, th.RepoPath, "push.txt")
+	require.NoError(t, th.Repository.Refresh())
+	require.Equal(t, "1", th.Repository.State.Branch.Pushables)
+
+	j := &Job{
+		JobType:    PushJob,
+		Repository: th.Repository,
+	}
+	err := j.start(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, git.Success, th.Repository.WorkStatus())
+	require.Equal(t, "0", th.Repository.State.Branch.Pushables)
+}
+
+func TestStartStash(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "config", "user.name", "gitbatch")
+	git.RunTestGit(t, th.RepoPath, "config", "user.email", "gitbatch@localhost")
+
+	// only an untracked file, which is left alone unless it is included
+	require.NoError(t, ioutil.WriteFile(filepath.Join(th.RepoPath, "untracked.txt"), []byte("new"), 0644))
+	require.NoError(t, th.Repository.Refresh())
+
+	var tests = []struct {
+		opts    *git.StashOptions
+		status  git.WorkStatus
+		message string
+	}{
+		{&git.StashOptions{Message: "batch"}, git.Fail, "nothing to stash"},
+		{&git.StashOptions{Message: "batch", IncludeUntracked: true}, git.Success, "stashed"},
+	}
+	for _, test := range tests {
+		j := &Job{
+			JobType:    StashJob,
+			Repository: th.Repository,
+			Options:    test.opts,
+		}
+		err := j.start(context.Background())
+		require.Equal(t, test.status == git.Success, err == nil)
+		require.Equal(t, test.status, th.Repository.WorkStatus())
+		require.Equal(t, test.message, th.Repository.State.Message)
+	}
+	require.NotNil(t, th.Repository.FindStash("batch"))
+}
+
+func TestStartBare(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	mirror := filepath.Join(filepath.Dir(th.RepoPath), "mirror.git")
+	git.RunTestGit(t, th.RepoPath, "clone", "--bare", th.RemoteRepoPath(), mirror)
+	r, err := git.InitializeRepo(mirror)
+	require.NoError(t, err)
+
+	var tests = []struct {
+		input Type
+		err   error
+	}{
+		{PullJob, gerr.ErrBareRepository},
+		{MergeJob, gerr.ErrBareRepository},
+		{CheckoutJob, gerr.ErrBareRepository},
+		{FetchJob, nil},
+	}
+	for _, test := range tests {
+		j := &Job{
+			JobType:    test.input,
+			Repository: r,
+		}
+		err := j.start(context.Background())
+		require.Equal(t, test.err, err)
+	}
+}
+
+func TestRunRetry(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", ts.URL+"/repo.git")
+
+	j := &Job{
+		JobType:    FetchJob,
+		Repository: th.Repository,
+		Options: &command.FetchOptions{
+			RemoteName:  "origin",
+			CommandMode: command.ModeLegacy,
+		},
+	}
+	err := j.run(context.Background(), RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+	require.ErrorIs(t, err, gerr.ErrRemoteServerError)
+	require.Equal(t, 3, j.attempt)
+	require.True(t, atomic.LoadInt32(&hits) >= 3)
+	require.Equal(t, git.Fail, th.Repository.WorkStatus())
+	require.True(t, strings.HasSuffix(th.Repository.State.Message, "(after 3 attempts)"))
+	require.ErrorIs(t, th.Repository.State.Error, gerr.ErrRemoteServerError)
+}
+
+func TestRunPermanentError(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	j := &Job{
+		JobType:    FetchJob,
+		Repository: th.Repository,
+		Options: &command.FetchOptions{
+			RemoteName:  "nonexistent",
+			CommandMode: command.ModeLegacy,
+		},
+	}
+	err := j.run(context.Background(), RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, j.attempt)
+}
+
 func TestStart(t *testing.T) {
 	th := git.InitTestRepositoryFromLocal(t)
 	defer th.CleanUp(t)
@@ -32,7 +174,76 @@ func TestStart(t *testing.T) {
 		{mockJob3},
 	}
 	for _, test := range tests {
-		err := test.input.start()
+		err := test.input.start(context.Background())
 		require.NoError(t, err)
 	}
 }
+
+func TestSetCredentials(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	creds := &git.Credentials{User: "me", Password: "secret"}
+	var tests = []struct {
+		input    *Job
+		expected interface{}
+	}{
+		{&Job{JobType: FetchJob, Options: &command.FetchOptions{RemoteName: "origin", Prune: true}},
+			&command.FetchOptions{RemoteName: "origin", Prune: true, Credentials: creds, CommandMode: command.ModeNative}},
+		{&Job{JobType: PullJob, Options: &command.PullOptions{RemoteName: "origin", Strategy: command.StrategyRebase}},
+			&command.PullOptions{RemoteName: "origin", Strategy: command.StrategyRebase, Credentials: creds, CommandMode: command.ModeNative}},
+		{&Job{JobType: PushJob},
+			&command.PushOptions{RemoteName: "origin", Credentials: creds, CommandMode: command.ModeNative}},
+		{&Job{JobType: TagJob, Options: &command.TagOptions{Name: "v1.0.0", Push: true, RemoteName: "origin"}},
+			&command.TagOptions{Name: "v1.0.0", Push: true, RemoteName: "origin", Credentials: creds, CommandMode: command.ModeNative}},
+		{&Job{JobType: TagJob}, nil},
+		{&Job{JobType: MergeJob}, nil},
+	}
+	for _, test := range tests {
+		test.input.Repository = th.Repository
+		test.input.SetCredentials(creds)
+		if test.expected == nil {
+			require.Nil(t, test.input.Options)
+			continue
+		}
+		require.Equal(t, test.expected, test.input.Options, test.input.JobType)
+	}
+}
+
+func TestSetCredentialsRun(t *testing.T) {
+	var mutex sync.Mutex
+	users := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		mutex.Lock()
+		users = append(users, user)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+	git.RunTestGit(t, th.RepoPath, "remote", "set-url", "origin", ts.URL+"/repo.git")
+
+	// the jobs are queued in legacy mode, the credentials switch them to the
+	// native one
+	var tests = []struct {
+		input *Job
+	}{
+		{&Job{JobType: FetchJob, Options: &command.FetchOptions{RemoteName: "origin"}}},
+		{&Job{JobType: PullJob, Options: &command.PullOptions{RemoteName: "origin"}}},
+		{&Job{JobType: PushJob, Options: &command.PushOptions{RemoteName: "origin"}}},
+	}
+	for _, test := range tests {
+		users = users[:0]
+		test.input.Repository = th.Repository
+		test.input.SetCredentials(&git.Credentials{User: "me", Password: "secret"})
+		err := test.input.start(context.Background())
+		require.ErrorIs(t, err, gerr.ErrAuthenticationRequired, test.input.JobType)
+		require.NotEmpty(t, users, test.input.JobType)
+		for _, user := range users {
+			require.Equal(t, "me", user, test.input.JobType)
+		}
+	}
+}
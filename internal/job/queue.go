@@ -5,27 +5,78 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"time"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"golang.org/x/sync/semaphore"
 )
 
 // Queue holds the slice of Jobs
 type Queue struct {
-	series []*Job
+	series    []*Job
+	running   map[*Job]context.CancelFunc
+	timeout   time.Duration
+	retry     RetryPolicy
+	workers   int
+	hostLimit int
+	hosts     map[string]int
+	started   bool
+	mutex     *sync.Mutex
+	cond      *sync.Cond
 }
 
 // CreateJobQueue creates a jobqueue struct and initialize its slice then return
 // its pointer
 func CreateJobQueue() (jq *Queue) {
 	s := make([]*Job, 0)
+	mx := &sync.Mutex{}
 	return &Queue{
-		series: s,
+		series:  s,
+		running: make(map[*Job]context.CancelFunc),
+		hosts:   make(map[string]int),
+		retry:   DefaultRetryPolicy,
+		mutex:   mx,
+		cond:    sync.NewCond(mx),
 	}
 }
 
+// SetWorkers sets the count of jobs that can run at the same time. Zero or
+// negative values mean the count is limited by the number of CPUs
+func (jq *Queue) SetWorkers(n int) {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	jq.workers = n
+}
+
+// SetHostLimit sets the count of jobs that can run at the same time against
+// the same remote host. Zero or negative values mean there is no limit
+func (jq *Queue) SetHostLimit(n int) {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	jq.hostLimit = n
+}
+
+// SetTimeout sets the maximum duration of a single job, the job is cancelled
+// if it takes longer. Zero duration means there is no time limit
+func (jq *Queue) SetTimeout(d time.Duration) {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	jq.timeout = d
+}
+
+// SetRetryPolicy sets the policy applied to the jobs failed with a transient
+// error
+func (jq *Queue) SetRetryPolicy(p RetryPolicy) {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	jq.retry = p
+}
+
 // AddJob adds a job to the queue
 func (jq *Queue) AddJob(j *Job) error {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
 	for _, job := range jq.series {
 		if job.Repository.RepoID == j.Repository.RepoID && job.JobType == j.JobType {
 			return fmt.Errorf("same job already is in the queue")
@@ -37,28 +88,89 @@ func (jq *Queue) AddJob(j *Job) error {
 	return nil
 }
 
-// StartNext starts the next job in the queue
+// StartNext starts the next job in the queue. If a host limit is set, the
+// jobs of the hosts that are already busy are skipped and the call waits
+// until one of the remaining jobs becomes available
 func (jq *Queue) StartNext() (j *Job, finished bool, err error) {
 	finished = false
+	jq.mutex.Lock()
+	i := jq.nextAvailable()
+	for i < 0 && len(jq.series) > 0 {
+		jq.cond.Wait()
+		i = jq.nextAvailable()
+	}
 	if len(jq.series) < 1 {
+		jq.mutex.Unlock()
 		finished = true
 		return nil, finished, nil
 	}
-	i := len(jq.series) - 1
 	lastJob := jq.series[i]
-	jq.series = jq.series[:i]
-	if err = lastJob.start(); err != nil {
+	jq.series = append(jq.series[:i], jq.series[i+1:]...)
+	host := jobHost(lastJob)
+	jq.hosts[host]++
+	ctx, cancel := jq.jobContext()
+	jq.running[lastJob] = cancel
+	policy := jq.retry
+	jq.mutex.Unlock()
+
+	defer func() {
+		jq.mutex.Lock()
+		delete(jq.running, lastJob)
+		jq.hosts[host]--
+		jq.cond.Broadcast()
+		jq.mutex.Unlock()
+		cancel()
+	}()
+	if err = lastJob.run(ctx, policy); err != nil {
 		return lastJob, finished, err
 	}
 	return lastJob, finished, nil
 }
 
+// nextAvailable returns the index of the oldest job whose host is not busy,
+// caller should hold the lock. It returns -1 if there is no such job
+func (jq *Queue) nextAvailable() int {
+	for i := len(jq.series) - 1; i >= 0; i-- {
+		host := jobHost(jq.series[i])
+		if jq.hostLimit <= 0 || len(host) == 0 || jq.hosts[host] < jq.hostLimit {
+			return i
+		}
+	}
+	return -1
+}
+
+// jobHost returns the remote host that the job is going to communicate with
+func jobHost(j *Job) string {
+	if o, ok := j.Options.(*command.CloneOptions); ok {
+		return (&git.Remote{URL: []string{o.URL}}).Host()
+	}
+	if j.Repository.State.Remote == nil {
+		return ""
+	}
+	return j.Repository.State.Remote.Host()
+}
+
+// jobContext creates the context that a single job runs with
+func (jq *Queue) jobContext() (context.Context, context.CancelFunc) {
+	if jq.timeout > 0 {
+		return context.WithTimeout(context.Background(), jq.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
 // RemoveFromQueue deletes the given entity and its job from the queue
-// TODO: it is not safe if the job has been started
 func (jq *Queue) RemoveFromQueue(r *git.Repository) error {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	defer jq.cond.Broadcast()
+	return jq.remove(r)
+}
+
+// remove deletes the given entity from the series, caller should hold the lock
+func (jq *Queue) remove(r *git.Repository) error {
 	removed := false
-	for i, job := range jq.series {
-		if job.Repository.RepoID == r.RepoID {
+	for i := len(jq.series) - 1; i >= 0; i-- {
+		if jq.series[i].Repository.RepoID == r.RepoID {
 			jq.series = append(jq.series[:i], jq.series[i+1:]...)
 			removed = true
 		}
@@ -69,10 +181,48 @@ func (jq *Queue) RemoveFromQueue(r *git.Repository) error {
 	return nil
 }
 
+// Cancel stops the job of the given repository. If the job has not been
+// started yet it is removed from the queue, otherwise its context is cancelled
+func (jq *Queue) Cancel(r *git.Repository) error {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	for j, cancel := range jq.running {
+		if j.Repository.RepoID == r.RepoID {
+			cancel()
+			return nil
+		}
+	}
+	if err := jq.remove(r); err != nil {
+		return err
+	}
+	jq.cond.Broadcast()
+	r.State.Message = "cancelled"
+	r.SetWorkStatus(git.Cancelled)
+	return nil
+}
+
+// CancelAll stops the running jobs and removes the remaining ones from the
+// queue
+func (jq *Queue) CancelAll() {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	for _, cancel := range jq.running {
+		cancel()
+	}
+	for _, j := range jq.series {
+		j.Repository.State.Message = "cancelled"
+		j.Repository.SetWorkStatus(git.Cancelled)
+	}
+	jq.series = jq.series[:0]
+	jq.cond.Broadcast()
+}
+
 // IsInTheQueue function; since the job and entity is not tied with its own
 // struct, this function returns true if that entity is in the queue along with
 // the jobs type
 func (jq *Queue) IsInTheQueue(r *git.Repository) (inTheQueue bool, j *Job) {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
 	inTheQueue = false
 	for _, job := range jq.series {
 		if job.Repository.RepoID == r.RepoID {
@@ -83,19 +233,43 @@ func (jq *Queue) IsInTheQueue(r *git.Repository) (inTheQueue bool, j *Job) {
 	return inTheQueue, j
 }
 
+// Jobs returns a copy of the jobs in the queue
+func (jq *Queue) Jobs() []*Job {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	jobs := make([]*Job, len(jq.series))
+	copy(jobs, jq.series)
+	return jobs
+}
+
+// Started returns true once the jobs of the queue are started
+func (jq *Queue) Started() bool {
+	jq.mutex.Lock()
+	defer jq.mutex.Unlock()
+	return jq.started
+}
+
 // StartJobsAsync start he jobs in the queue asynchronously
 func (jq *Queue) StartJobsAsync() map[*Job]error {
 
 	ctx := context.TODO()
 
-	var (
+	jq.mutex.Lock()
+	jq.started = true
+	count := len(jq.series)
+	maxWorkers := jq.workers
+	jq.mutex.Unlock()
+	if maxWorkers <= 0 {
 		maxWorkers = runtime.GOMAXPROCS(0)
-		sem        = semaphore.NewWeighted(int64(maxWorkers))
-		fails      = make(map[*Job]error)
+	}
+
+	var (
+		sem   = semaphore.NewWeighted(int64(maxWorkers))
+		fails = make(map[*Job]error)
 	)
 
 	var mx sync.Mutex
-	for range jq.series {
+	for i := 0; i < count; i++ {
 
 		if err := sem.Acquire(ctx, 1); err != nil {
 			break
@@ -1,8 +1,11 @@
 package job
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/isacikgoz/gitbatch/internal/command"
 	"github.com/isacikgoz/gitbatch/internal/git"
 	"github.com/stretchr/testify/require"
 )
@@ -89,3 +92,95 @@ func TestStartJobsAsync(t *testing.T) {
 		require.Empty(t, output)
 	}
 }
+
+func TestCancel(t *testing.T) {
+	th := git.InitTestRepositoryFromLocal(t)
+	defer th.CleanUp(t)
+
+	q := CreateJobQueue()
+	j := &Job{Repository: th.Repository}
+	err := q.AddJob(j)
+	require.NoError(t, err)
+
+	err = q.Cancel(th.Repository)
+	require.NoError(t, err)
+	require.Equal(t, git.Cancelled, th.Repository.WorkStatus())
+	inTheQueue, _ := q.IsInTheQueue(th.Repository)
+	require.False(t, inTheQueue)
+
+	err = q.Cancel(th.Repository)
+	require.Error(t, err)
+}
+
+func TestCancelAll(t *testing.T) {
+	th := git.InitTestRepositoryFromLocal(t)
+	defer th.CleanUp(t)
+
+	q := CreateJobQueue()
+	err := q.AddJob(&Job{JobType: FetchJob, Repository: th.Repository})
+	require.NoError(t, err)
+	err = q.AddJob(&Job{JobType: PullJob, Repository: th.Repository})
+	require.NoError(t, err)
+
+	q.CancelAll()
+	require.Equal(t, git.Cancelled, th.Repository.WorkStatus())
+	require.False(t, q.Started())
+	output := q.StartJobsAsync()
+	require.Empty(t, output)
+	require.True(t, q.Started())
+}
+
+func TestStartJobsAsyncTimeout(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	q := CreateJobQueue()
+	q.SetTimeout(time.Nanosecond)
+	j := &Job{
+		JobType:    FetchJob,
+		Repository: th.Repository,
+		Options: &command.FetchOptions{
+			RemoteName:  "origin",
+			CommandMode: command.ModeLegacy,
+		},
+	}
+	err := q.AddJob(j)
+	require.NoError(t, err)
+
+	output := q.StartJobsAsync()
+	require.Equal(t, context.DeadlineExceeded, output[j])
+	require.Equal(t, git.Cancelled, th.Repository.WorkStatus())
+	require.Equal(t, "timed out", th.Repository.State.Message)
+}
+
+func TestNextAvailable(t *testing.T) {
+	repo := func(id, url string) *git.Repository {
+		return &git.Repository{
+			RepoID: id,
+			State: &git.RepositoryState{
+				Remote: &git.Remote{URL: []string{url}},
+			},
+		}
+	}
+	q := CreateJobQueue()
+	q.SetHostLimit(1)
+	jobs := []*Job{
+		{JobType: FetchJob, Repository: repo("1", "git@github.com:a/a.git")},
+		{JobType: FetchJob, Repository: repo("2", "https://github.com/b/b.git")},
+		{JobType: FetchJob, Repository: repo("3", "https://gitlab.com/c/c.git")},
+	}
+	for _, j := range jobs {
+		require.NoError(t, q.AddJob(j))
+	}
+	// the oldest job is the last item of the series
+	require.Equal(t, jobs[0], q.series[q.nextAvailable()])
+
+	q.hosts["github.com"] = 1
+	require.Equal(t, jobs[2], q.series[q.nextAvailable()])
+
+	q.hosts["gitlab.com"] = 1
+	require.Equal(t, -1, q.nextAvailable())
+
+	q.SetHostLimit(0)
+	require.Equal(t, jobs[0], q.series[q.nextAvailable()])
+}
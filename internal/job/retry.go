@@ -0,0 +1,55 @@
+package job
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how many times a job is tried again if it fails because
+// of a transient error, such as a timeout or a server side error
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries including the first one. Zero
+	// or one means the job is not retried
+	MaxAttempts int
+	// InitialBackoff is the wait duration before the second attempt, it is
+	// doubled for each following attempt
+	InitialBackoff time.Duration
+	// MaxBackoff is the upper limit of the wait duration between attempts.
+	// Zero means there is no limit
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the policy used by a queue unless it is set
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Second,
+	MaxBackoff:     30 * time.Second,
+}
+
+// attempts returns the total number of tries allowed by the policy
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the wait duration after the given attempt. The duration
+// grows exponentially and a random jitter is applied so that the jobs failed
+// at the same time do not hit the remote again all together
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
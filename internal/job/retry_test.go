@@ -0,0 +1,45 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     4 * time.Second,
+	}
+	var tests = []struct {
+		input int
+		max   time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 4 * time.Second},
+		{50, 4 * time.Second},
+	}
+	for _, test := range tests {
+		output := p.backoff(test.input)
+		require.True(t, output >= test.max/2, "backoff %s is less than %s", output, test.max/2)
+		require.True(t, output <= test.max, "backoff %s is more than %s", output, test.max)
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	var tests = []struct {
+		input  RetryPolicy
+		output int
+	}{
+		{RetryPolicy{}, 1},
+		{RetryPolicy{MaxAttempts: -1}, 1},
+		{DefaultRetryPolicy, 3},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.output, test.input.attempts())
+	}
+}
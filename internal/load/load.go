@@ -50,14 +50,16 @@ func SyncLoad(directories []string) (entities []*git.Repository, err error) {
 	return entities, nil
 }
 
-// AsyncLoad asynchronously adds to AsyncAdd function
-func AsyncLoad(directories []string, add AsyncAdd, d chan bool) error {
+// AsyncLoad asynchronously adds to AsyncAdd function. At most maxWorkers
+// repositories are loaded at the same time, if it is not positive the number
+// of CPUs is used
+func AsyncLoad(directories []string, add AsyncAdd, d chan bool, maxWorkers int) error {
 	ctx := context.TODO()
 
-	var (
+	if maxWorkers <= 0 {
 		maxWorkers = runtime.GOMAXPROCS(0)
-		sem        = semaphore.NewWeighted(int64(maxWorkers))
-	)
+	}
+	sem := semaphore.NewWeighted(int64(maxWorkers))
 
 	var mx sync.Mutex
 
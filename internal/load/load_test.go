@@ -41,11 +41,13 @@ func TestAsyncLoad(t *testing.T) {
 		inp1 []string
 		inp2 AsyncAdd
 		inp3 chan bool
+		inp4 int
 	}{
-		{[]string{th.BasicRepoPath(), th.DirtyRepoPath()}, testAsyncMockFunc, testChannel},
+		{[]string{th.BasicRepoPath(), th.DirtyRepoPath()}, testAsyncMockFunc, testChannel, 0},
+		{[]string{th.BasicRepoPath(), th.DirtyRepoPath()}, testAsyncMockFunc, testChannel, 1},
 	}
 	for _, test := range tests {
-		err := AsyncLoad(test.inp1, test.inp2, test.inp3)
+		err := AsyncLoad(test.inp1, test.inp2, test.inp3, test.inp4)
 		require.NoError(t, err)
 	}
 }
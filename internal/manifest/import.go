@@ -0,0 +1,284 @@
+package manifest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// GitmodulesFileName is the name of the file that declares the submodules of a
+// superproject
+const GitmodulesFileName = ".gitmodules"
+
+// maxIncludeDepth stops the include elements of repo tool manifests that
+// include each other
+const maxIncludeDepth = 16
+
+var (
+	scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?[^@/:]+:`)
+	commitHash = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// repoManifest is the xml manifest of the repo tool. Only the elements that
+// are needed to locate the projects are parsed
+type repoManifest struct {
+	Remotes  []repoRemote  `xml:"remote"`
+	Default  *repoDefault  `xml:"default"`
+	Projects []repoProject `xml:"project"`
+	Includes []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"include"`
+	Removes []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"remove-project"`
+}
+
+type repoRemote struct {
+	Name     string `xml:"name,attr"`
+	Fetch    string `xml:"fetch,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type repoDefault struct {
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+}
+
+type repoProject struct {
+	Name     string `xml:"name,attr"`
+	Path     string `xml:"path,attr"`
+	Remote   string `xml:"remote,attr"`
+	Revision string `xml:"revision,attr"`
+	Groups   string `xml:"groups,attr"`
+}
+
+// loadRepoTool converts a repo tool manifest, the project paths are relative
+// to the directory that holds the .repo directory
+func loadRepoTool(file string) (*Manifest, error) {
+	rm := &repoManifest{}
+	if err := rm.read(file, 0); err != nil {
+		return nil, err
+	}
+	remotes := make(map[string]repoRemote)
+	for _, r := range rm.Remotes {
+		remotes[r.Name] = r
+	}
+	def := repoDefault{}
+	if rm.Default != nil {
+		def = *rm.Default
+	}
+	removed := make(map[string]bool)
+	for _, r := range rm.Removes {
+		removed[r.Name] = true
+	}
+	base := originURL(filepath.Dir(file))
+	m := &Manifest{Root: repoToolRoot(file)}
+	for _, p := range rm.Projects {
+		if removed[p.Name] {
+			continue
+		}
+		name := p.Remote
+		if len(name) == 0 {
+			name = def.Remote
+		}
+		remote, ok := remotes[name]
+		if !ok {
+			return nil, fmt.Errorf("project %s of manifest %s has an undefined remote %q", p.Name, file, name)
+		}
+		path := p.Path
+		if len(path) == 0 {
+			path = p.Name
+		}
+		revision := firstOf(p.Revision, remote.Revision, def.Revision)
+		fetch := strings.TrimSuffix(resolveURL(base, remote.Fetch, false), "/")
+		m.Repositories = append(m.Repositories, &Repository{
+			Path:     path,
+			URL:      fetch + "/" + p.Name,
+			Branch:   revisionBranch(revision),
+			Revision: revision,
+			Groups:   strings.FieldsFunc(p.Groups, func(r rune) bool { return r == ',' || r == ' ' }),
+		})
+	}
+	return m, nil
+}
+
+// read parses the manifest and the manifests it includes, the include names
+// are relative to the directory of the including manifest
+func (rm *repoManifest) read(file string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("manifest %s exceeds the maximum include depth", file)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	part := &repoManifest{}
+	if err := xml.Unmarshal(b, part); err != nil {
+		return fmt.Errorf("could not parse manifest %s: %v", file, err)
+	}
+	rm.Remotes = append(rm.Remotes, part.Remotes...)
+	rm.Projects = append(rm.Projects, part.Projects...)
+	rm.Removes = append(rm.Removes, part.Removes...)
+	if part.Default != nil {
+		rm.Default = part.Default
+	}
+	for _, include := range part.Includes {
+		if err := rm.read(filepath.Join(filepath.Dir(file), include.Name), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repoToolRoot returns the parent of the .repo directory if the manifest is
+// inside one, otherwise the directory of the manifest
+func repoToolRoot(file string) string {
+	for dir := filepath.Dir(file); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".repo" {
+			return filepath.Dir(dir)
+		}
+	}
+	return filepath.Dir(file)
+}
+
+// loadGitmodules converts the submodules of a superproject, the declared
+// revision of a submodule is its branch or the commit that the superproject
+// records for it
+func loadGitmodules(file string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	modules := config.NewModules()
+	if err := modules.Unmarshal(b); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", file, err)
+	}
+	dir := filepath.Dir(file)
+	base := originURL(dir)
+	m := &Manifest{}
+	for _, s := range modules.Submodules {
+		r := &Repository{
+			Path:   s.Path,
+			URL:    resolveURL(base, s.URL, true),
+			Branch: s.Branch,
+		}
+		if len(r.Branch) == 0 {
+			r.Revision = gitlink(dir, s.Path)
+		}
+		m.Repositories = append(m.Repositories, r)
+	}
+	// the submodules are kept in a map, keep the order stable
+	sort.Slice(m.Repositories, func(i, j int) bool {
+		return m.Repositories[i].Path < m.Repositories[j].Path
+	})
+	return m, nil
+}
+
+// gitlink returns the commit recorded by the HEAD of the superproject for
+// the submodule path, or an empty string if it cannot be read
+func gitlink(dir, path string) string {
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return ""
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return ""
+	}
+	entry, err := tree.FindEntry(filepath.ToSlash(path))
+	if err != nil {
+		return ""
+	}
+	return entry.Hash.String()
+}
+
+// originURL returns the url of the origin remote of the repository that the
+// directory belongs to. The manifests of the repo tool are kept in the
+// .repo/manifests repository
+func originURL(dir string) string {
+	if filepath.Base(dir) == ".repo" {
+		dir = filepath.Join(dir, "manifests")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return ""
+	}
+	repo, err := gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+// resolveURL resolves a relative url such as "../foo.git" against base. If dir
+// is true the base is treated as a directory, as git does for the relative
+// submodule urls
+func resolveURL(base, ref string, dir bool) string {
+	if len(base) == 0 || !(ref == "." || ref == ".." || strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../")) {
+		return ref
+	}
+	if dir {
+		base = strings.TrimSuffix(base, "/") + "/"
+	}
+	var host string
+	if !strings.Contains(base, "://") {
+		if loc := scpLikeURL.FindString(base); len(loc) > 0 {
+			host, base = loc, "/"+base[len(loc):]
+		}
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	resolved := b.ResolveReference(r).String()
+	if len(host) > 0 {
+		return host + strings.TrimPrefix(resolved, "/")
+	}
+	return resolved
+}
+
+// revisionBranch returns the branch name of the revision, or an empty string
+// if the revision is a tag or a commit
+func revisionBranch(revision string) string {
+	if strings.HasPrefix(revision, "refs/heads/") {
+		return plumbing.ReferenceName(revision).Short()
+	}
+	if strings.HasPrefix(revision, "refs/") || commitHash.MatchString(revision) {
+		return ""
+	}
+	return revision
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
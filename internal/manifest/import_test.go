@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRepoTool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitbatch-manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	manifests := filepath.Join(dir, ".repo", "manifests")
+	require.NoError(t, os.MkdirAll(manifests, 0755))
+	writeManifest(t, manifests, "extra.xml", `<manifest>
+  <project name="tools/extra" remote="mirror" revision="refs/tags/v1.0"/>
+  <remove-project name="platform/removed"/>
+</manifest>`)
+	file := writeManifest(t, manifests, "default.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<manifest>
+  <remote name="aosp" fetch="https://android.googlesource.com/"/>
+  <remote name="mirror" fetch="ssh://git@mirror.local/" revision="stable"/>
+  <default remote="aosp" revision="refs/heads/main"/>
+  <project name="platform/build" path="build/make" groups="pdk,tools"/>
+  <project name="platform/art" revision="0123456789abcdef0123456789abcdef01234567"/>
+  <project name="platform/removed"/>
+  <project name="device/x" remote="mirror"/>
+  <include name="extra.xml"/>
+</manifest>`)
+	require.True(t, IsImport(file))
+
+	m, err := Load(file)
+	require.NoError(t, err)
+	require.Equal(t, dir, m.RootDir())
+
+	var tests = []struct {
+		path     string
+		url      string
+		branch   string
+		revision string
+		groups   []string
+	}{
+		{"build/make", "https://android.googlesource.com/platform/build", "main", "refs/heads/main", []string{"pdk", "tools"}},
+		{"platform/art", "https://android.googlesource.com/platform/art", "", "0123456789abcdef0123456789abcdef01234567", nil},
+		{"device/x", "ssh://git@mirror.local/device/x", "stable", "stable", nil},
+		{"tools/extra", "ssh://git@mirror.local/tools/extra", "", "refs/tags/v1.0", nil},
+	}
+	require.Len(t, m.Repositories, len(tests))
+	for i, test := range tests {
+		r := m.Repositories[i]
+		require.Equal(t, test.path, r.Path)
+		require.Equal(t, test.url, r.URL)
+		require.Equal(t, test.branch, r.Branch)
+		require.Equal(t, test.revision, r.DeclaredRevision())
+		if test.groups != nil {
+			require.Equal(t, test.groups, r.Groups)
+		}
+	}
+	require.Equal(t, "refs/heads/main", m.Revisions()[filepath.Join(dir, "build", "make")])
+
+	// a project with an unknown remote is an error
+	file = writeManifest(t, manifests, "broken.xml", `<manifest><project name="a" remote="none"/></manifest>`)
+	_, err = Load(file)
+	require.Error(t, err)
+}
+
+func TestLoadGitmodules(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	hash := strings.TrimSpace(git.RunTestGit(t, th.RepoPath, "rev-parse", "HEAD"))
+	git.RunTestGit(t, th.RepoPath, "update-index", "--add", "--cacheinfo", "160000,"+hash+",libs/core")
+	file := writeManifest(t, th.RepoPath, GitmodulesFileName, `[submodule "core"]
+	path = libs/core
+	url = ../core.git
+[submodule "ui"]
+	path = libs/ui
+	url = https://example.com/ui.git
+	branch = develop
+`)
+	git.RunTestGit(t, th.RepoPath, "add", GitmodulesFileName)
+	git.RunTestGit(t, th.RepoPath, "commit", "-m", "add submodules")
+
+	require.True(t, IsImport(file))
+	m, err := Load(file)
+	require.NoError(t, err)
+	require.Len(t, m.Repositories, 2)
+
+	core, ui := m.Repositories[0], m.Repositories[1]
+	require.Equal(t, filepath.Join(th.RepoPath, "libs", "core"), m.AbsPath(core))
+	require.Equal(t, filepath.Join(filepath.Dir(th.RepoPath), "core.git"), core.URL)
+	require.Equal(t, hash, core.DeclaredRevision())
+	require.Equal(t, "https://example.com/ui.git", ui.URL)
+	require.Equal(t, "develop", ui.DeclaredRevision())
+}
+
+func TestResolveURL(t *testing.T) {
+	var tests = []struct {
+		base     string
+		ref      string
+		dir      bool
+		expected string
+	}{
+		{"https://host/platform/manifest", "..", false, "https://host/"},
+		{"https://host/org/super.git", "../lib.git", true, "https://host/org/lib.git"},
+		{"git@host:org/super.git", "../lib.git", true, "git@host:org/lib.git"},
+		{"/srv/git/super.git", "./lib.git", true, "/srv/git/super.git/lib.git"},
+		{"https://host/org/super.git", "https://other/lib.git", true, "https://other/lib.git"},
+		{"", "../lib.git", true, "../lib.git"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, resolveURL(test.base, test.ref, test.dir))
+	}
+}
@@ -0,0 +1,187 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// WorkspaceFileName is the name of the manifest in a workspace root
+	WorkspaceFileName = ".gitbatch.yml"
+	// FileName is the name of the manifest in the configuration directory
+	FileName = "manifest.yml"
+)
+
+// Manifest declares the repositories of a workspace
+type Manifest struct {
+	// Root is the directory that the repository paths are relative to. If
+	// empty, the directory of the manifest file is used
+	Root string `yaml:"root,omitempty"`
+	// Repositories of the workspace
+	Repositories []*Repository `yaml:"repositories"`
+
+	file string
+}
+
+// Repository is a single repository entry of the manifest
+type Repository struct {
+	// Path of the repository relative to the root
+	Path string `yaml:"path"`
+	// URL is the clone URL, it is added as the origin remote
+	URL string `yaml:"url"`
+	// Branch is the default branch to check out after cloning
+	Branch string `yaml:"branch,omitempty"`
+	// Remotes are the additional remotes of the repository keyed by name
+	Remotes map[string]string `yaml:"remotes,omitempty"`
+	// Revision is the declared revision of the repository, it may be a
+	// branch, a tag or a commit. If empty, the branch is the declared one
+	Revision string `yaml:"revision,omitempty"`
+	// Groups are the names of the groups that the repository belongs to
+	Groups []string `yaml:"groups,omitempty"`
+}
+
+// Find returns the first manifest file found in given directories, it looks
+// for both workspace and configuration directory file names. It returns an
+// empty string if there is no manifest
+func Find(dirs ...string) string {
+	for _, dir := range dirs {
+		for _, name := range []string{WorkspaceFileName, FileName} {
+			file := filepath.Join(dir, name)
+			if fi, err := os.Stat(file); err == nil && !fi.IsDir() {
+				return file
+			}
+		}
+	}
+	return ""
+}
+
+// IsImport returns true if the file is not a gitbatch manifest but a repo
+// tool manifest or a .gitmodules file. Such files list the exact checkouts to
+// work on rather than the directories to discover repositories in
+func IsImport(file string) bool {
+	return filepath.Base(file) == GitmodulesFileName || strings.EqualFold(filepath.Ext(file), ".xml")
+}
+
+// Load reads and validates the manifest file. Repo tool manifests and
+// .gitmodules files are converted to a manifest as well
+func Load(file string) (*Manifest, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	var m *Manifest
+	switch {
+	case filepath.Base(abs) == GitmodulesFileName:
+		m, err = loadGitmodules(abs)
+	case strings.EqualFold(filepath.Ext(abs), ".xml"):
+		m, err = loadRepoTool(abs)
+	default:
+		m, err = loadYAML(abs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.file = abs
+	seen := make(map[string]bool)
+	for i, r := range m.Repositories {
+		if len(r.Path) == 0 || len(r.URL) == 0 {
+			return nil, fmt.Errorf("repository %d of manifest %s requires a path and an url", i+1, file)
+		}
+		path := m.AbsPath(r)
+		if seen[path] {
+			return nil, fmt.Errorf("repository %s is declared more than once in manifest %s", r.Path, file)
+		}
+		seen[path] = true
+	}
+	return m, nil
+}
+
+func loadYAML(file string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %v", file, err)
+	}
+	return m, nil
+}
+
+// Revisions returns the declared revisions of the repositories keyed by their
+// absolute paths
+func (m *Manifest) Revisions() map[string]string {
+	revisions := make(map[string]string)
+	for _, r := range m.Repositories {
+		if rev := r.DeclaredRevision(); len(rev) > 0 {
+			revisions[m.AbsPath(r)] = rev
+		}
+	}
+	return revisions
+}
+
+// RootDir returns the absolute path of the directory that the repositories
+// reside in
+func (m *Manifest) RootDir() string {
+	root := m.Root
+	if len(root) == 0 {
+		return filepath.Dir(m.file)
+	}
+	if strings.HasPrefix(root, "~"+string(os.PathSeparator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			root = filepath.Join(home, root[2:])
+		}
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(filepath.Dir(m.file), root)
+	}
+	return filepath.Clean(root)
+}
+
+// AbsPath returns the absolute path of the repository
+func (m *Manifest) AbsPath(r *Repository) string {
+	if filepath.IsAbs(r.Path) {
+		return filepath.Clean(r.Path)
+	}
+	return filepath.Join(m.RootDir(), r.Path)
+}
+
+// Depth returns the directory depth needed to discover all of the
+// repositories under the root
+func (m *Manifest) Depth() int {
+	depth := 1
+	root := m.RootDir()
+	for _, r := range m.Repositories {
+		rel, err := filepath.Rel(root, m.AbsPath(r))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if d := len(strings.Split(rel, string(os.PathSeparator))); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// DeclaredRevision returns the revision if it is set, otherwise the branch
+func (r *Repository) DeclaredRevision() string {
+	if len(r.Revision) > 0 {
+		return r.Revision
+	}
+	return r.Branch
+}
+
+// remotes returns all of the remotes of the repository including the origin
+func (r *Repository) remotes() map[string]string {
+	remotes := make(map[string]string)
+	for name, url := range r.Remotes {
+		remotes[name] = url
+	}
+	remotes["origin"] = r.URL
+	return remotes
+}
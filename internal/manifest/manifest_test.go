@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitbatch-manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		input string
+		valid bool
+		root  string
+		depth int
+	}{
+		{"repositories:\n  - path: a\n    url: file:///tmp/a.git\n", true, dir, 1},
+		{"root: ws\nrepositories:\n  - path: services/api\n    url: file:///tmp/a.git\n    branch: main\n    remotes:\n      upstream: file:///tmp/b.git\n", true, filepath.Join(dir, "ws"), 2},
+		{"root: /srv\nrepositories: []\n", true, "/srv", 1},
+		{"repositories:\n  - path: a\n", false, "", 0},
+		{"repositories:\n  - path: a\n    url: x\n  - path: ./a\n    url: y\n", false, "", 0},
+		{"repositories: {", false, "", 0},
+	}
+	for _, test := range tests {
+		file := writeManifest(t, dir, WorkspaceFileName, test.input)
+		m, err := Load(file)
+		if !test.valid {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.root, m.RootDir())
+		require.Equal(t, test.depth, m.Depth())
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir1, err := ioutil.TempDir("", "gitbatch-manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir1)
+	dir2, err := ioutil.TempDir("", "gitbatch-manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir2)
+
+	require.Empty(t, Find(dir1, dir2))
+	file := writeManifest(t, dir2, FileName, "repositories: []\n")
+	require.Equal(t, file, Find(dir1, dir2))
+	file = writeManifest(t, dir1, WorkspaceFileName, "repositories: []\n")
+	require.Equal(t, file, Find(dir1, dir2))
+}
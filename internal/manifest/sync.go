@@ -0,0 +1,140 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/isacikgoz/gitbatch/internal/command"
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/job"
+)
+
+// Report is the outcome of a sync
+type Report struct {
+	// Cloned are the paths of the repositories that were missing and cloned
+	Cloned []string `json:"cloned"`
+	// RemotesAdded are the names of the remotes added to the existing
+	// repositories, keyed by repository path
+	RemotesAdded map[string][]string `json:"remotes_added"`
+	// Untracked are the paths of the repositories found on the disk but not
+	// declared in the manifest
+	Untracked []string `json:"untracked"`
+	// Failed are the repositories that could not be cloned or updated
+	Failed []*Failure `json:"failed"`
+}
+
+// Failure is a repository that could not be synced
+type Failure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+	Err   error  `json:"-"`
+}
+
+// Sync clones the missing repositories of the manifest in parallel through the
+// queue and adds the missing remotes to the existing ones. found is the list of
+// repositories on the disk, the ones that are not declared in the manifest are
+// reported as untracked. The remotes are authenticated with the credentials of
+// the matching sources. cloned is called for each cloned repository, if given
+func (m *Manifest) Sync(q *job.Queue, found []string, sources command.CredentialSources, cloned func(r *git.Repository)) *Report {
+	report := &Report{
+		Cloned:       make([]string, 0),
+		RemotesAdded: make(map[string][]string),
+		Untracked:    make([]string, 0),
+		Failed:       make([]*Failure, 0),
+	}
+	declared := make(map[string]bool)
+	jobs := make([]*job.Job, 0)
+	for _, entry := range m.Repositories {
+		path := m.AbsPath(entry)
+		declared[path] = true
+		if git.DetectKind(path) != git.KindNone {
+			added, err := addMissingRemotes(path, entry.remotes())
+			if err != nil {
+				report.fail(path, err)
+			} else if len(added) > 0 {
+				report.RemotesAdded[path] = added
+			}
+			continue
+		}
+		// a clone never writes into the files of the user
+		if notEmpty(path) {
+			report.fail(path, fmt.Errorf("%s is not a git repository and not empty, it is not cloned", path))
+			continue
+		}
+		remotes := entry.remotes()
+		delete(remotes, "origin")
+		j := &job.Job{
+			JobType:    job.CloneJob,
+			Repository: git.NewRepository(path),
+			Options: &command.CloneOptions{
+				URL:         entry.URL,
+				Branch:      entry.Branch,
+				Remotes:     remotes,
+				Sources:     sources,
+				CommandMode: command.ModeNative,
+			},
+		}
+		if err := q.AddJob(j); err != nil {
+			report.fail(path, err)
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+	fails := q.StartJobsAsync()
+	for _, j := range jobs {
+		if err, ok := fails[j]; ok {
+			report.fail(j.Repository.AbsPath, err)
+			continue
+		}
+		report.Cloned = append(report.Cloned, j.Repository.AbsPath)
+		if cloned != nil {
+			cloned(j.Repository)
+		}
+	}
+	for _, dir := range found {
+		if !declared[filepath.Clean(dir)] {
+			report.Untracked = append(report.Untracked, dir)
+		}
+	}
+	sort.Strings(report.Cloned)
+	sort.Strings(report.Untracked)
+	sort.Slice(report.Failed, func(i, j int) bool {
+		return report.Failed[i].Path < report.Failed[j].Path
+	})
+	return report
+}
+
+// addMissingRemotes adds the remotes that the repository does not have, it
+// returns the names of the added ones
+func addMissingRemotes(path string, remotes map[string]string) ([]string, error) {
+	r, err := git.FastInitializeRepo(path)
+	if err != nil {
+		return nil, err
+	}
+	missing, err := command.MissingRemotes(r, remotes)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range missing {
+		if err := command.AddRemote(r, name, remotes[name]); err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+// notEmpty returns true if the directory exists and has any entries
+func notEmpty(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+func (report *Report) fail(path string, err error) {
+	report.Failed = append(report.Failed, &Failure{
+		Path:  path,
+		Error: err.Error(),
+		Err:   err,
+	})
+}
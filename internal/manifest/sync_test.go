@@ -0,0 +1,84 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isacikgoz/gitbatch/internal/git"
+	"github.com/isacikgoz/gitbatch/internal/job"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	th := git.InitTestRepositoryWithLocalRemote(t)
+	defer th.CleanUp(t)
+
+	git.RunTestGit(t, th.RepoPath, "checkout", "-b", "develop")
+	git.CreateTestCommit(t, th.RepoPath, "develop.txt")
+	git.RunTestGit(t, th.RepoPath, "push", "origin", "develop")
+
+	workspace := filepath.Join(filepath.Dir(th.RepoPath), "workspace")
+	url := "file://" + filepath.ToSlash(th.RemoteRepoPath())
+	content := fmt.Sprintf(`root: workspace
+repositories:
+  - path: services/api
+    url: %s
+    branch: develop
+    remotes:
+      upstream: %s
+  - path: web
+    url: %s
+  - path: existing
+    url: %s
+    remotes:
+      upstream: %s
+  - path: broken
+    url: %s
+  - path: occupied
+    url: %s
+`, url, url, url, url, url, url+"-missing", url)
+	file := writeManifest(t, filepath.Dir(th.RepoPath), WorkspaceFileName, content)
+	m, err := Load(file)
+	require.NoError(t, err)
+
+	existing := filepath.Join(workspace, "existing")
+	git.RunTestGit(t, filepath.Dir(th.RepoPath), "clone", th.RemoteRepoPath(), existing)
+	occupied := filepath.Join(workspace, "occupied")
+	require.NoError(t, os.MkdirAll(occupied, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(occupied, "notes.txt"), []byte("notes"), 0644))
+	untracked := filepath.Join(workspace, "untracked")
+	git.RunTestGit(t, filepath.Dir(th.RepoPath), "init", untracked)
+
+	cloned := make([]*git.Repository, 0)
+	q := job.CreateJobQueue()
+	q.SetRetryPolicy(job.RetryPolicy{})
+	report := m.Sync(q, []string{existing, untracked}, nil, func(r *git.Repository) {
+		cloned = append(cloned, r)
+	})
+
+	api := filepath.Join(workspace, "services", "api")
+	web := filepath.Join(workspace, "web")
+	require.Equal(t, []string{api, web}, report.Cloned)
+	require.Len(t, cloned, 2)
+	require.Equal(t, map[string][]string{existing: {"upstream"}}, report.RemotesAdded)
+	require.Equal(t, []string{untracked}, report.Untracked)
+	require.Len(t, report.Failed, 2)
+	require.Equal(t, filepath.Join(workspace, "broken"), report.Failed[0].Path)
+	require.Equal(t, occupied, report.Failed[1].Path)
+	_, err = os.Stat(filepath.Join(occupied, "notes.txt"))
+	require.NoError(t, err)
+
+	branch := git.RunTestGit(t, api, "rev-parse", "--abbrev-ref", "HEAD")
+	require.Equal(t, "develop", strings.TrimSpace(branch))
+	remotes := git.RunTestGit(t, api, "remote")
+	require.ElementsMatch(t, []string{"origin", "upstream"}, strings.Fields(remotes))
+
+	// a second sync has nothing to do
+	report = m.Sync(job.CreateJobQueue(), nil, nil, nil)
+	require.Empty(t, report.Cloned)
+	require.Empty(t, report.RemotesAdded)
+}